@@ -0,0 +1,45 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+
+	"github.com/srava/swiftssh/internal/config"
+)
+
+// runCheck implements "sssh check": it parses the SSH config and reports
+// problems found by the config audits. Exit codes: 0 clean, 1 findings,
+// 2 usage or parse error.
+func runCheck(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("check", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configFlag := fs.String("config", "", "Path to SSH config file")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	configPath := resolveConfigPath(*configFlag)
+	hosts, err := config.ParseWithWildcards(configPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "sssh: could not parse SSH config: %v\n", err)
+		return 2
+	}
+
+	// Weak algorithms are most often set under "Host *"; the other audits
+	// and the host count only look at real hosts.
+	diags := config.AuditAlgorithms(hosts)
+	hosts = slices.DeleteFunc(hosts, func(h config.Host) bool { return h.Alias == "*" })
+	diags = append(diags, config.AuditIdentityPerms(hosts)...)
+	for _, d := range diags {
+		fmt.Fprintln(stdout, d.String())
+	}
+
+	if len(diags) > 0 {
+		fmt.Fprintf(stdout, "%d problem(s) found in %d host(s)\n", len(diags), len(hosts))
+		return 1
+	}
+	fmt.Fprintf(stdout, "No problems found in %d host(s)\n", len(hosts))
+	return 0
+}
@@ -0,0 +1,64 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeConfig writes content to a temp SSH config and returns its path.
+func writeConfig(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("failed to write config: %v", err)
+	}
+	return path
+}
+
+func TestRunCheck_ReportsWeakAlgorithms(t *testing.T) {
+	path := writeConfig(t, "Host legacy\n    Hostname 10.0.0.9\n    Ciphers aes128-cbc\n")
+
+	var stdout, stderr bytes.Buffer
+	code := runCheck([]string{"--config", path}, &stdout, &stderr)
+
+	if code != 1 {
+		t.Errorf("expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(stdout.String(), "legacy") || !strings.Contains(stdout.String(), "aes128-cbc") {
+		t.Errorf("expected finding for legacy/aes128-cbc, got:\n%s", stdout.String())
+	}
+}
+
+func TestRunCheck_ReportsWildcardBlock(t *testing.T) {
+	path := writeConfig(t, "Host dev\n    Hostname dev.example.com\n\nHost *\n    KexAlgorithms diffie-hellman-group1-sha1\n")
+
+	var stdout, stderr bytes.Buffer
+	code := runCheck([]string{"--config", path}, &stdout, &stderr)
+
+	if code != 1 {
+		t.Errorf("expected exit code 1, got %d", code)
+	}
+	if !strings.Contains(stdout.String(), ": *: KexAlgorithms") || !strings.Contains(stdout.String(), "in 1 host(s)") {
+		t.Errorf("expected a finding for Host * counted against 1 host, got:\n%s", stdout.String())
+	}
+}
+
+func TestRunCheck_Clean(t *testing.T) {
+	path := writeConfig(t, "Host dev\n    Hostname dev.example.com\n")
+
+	var stdout, stderr bytes.Buffer
+	if code := runCheck([]string{"--config", path}, &stdout, &stderr); code != 0 {
+		t.Errorf("expected exit code 0, got %d (output: %s)", code, stdout.String())
+	}
+}
+
+func TestRunCheck_ParseError(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	code := runCheck([]string{"--config", filepath.Join(t.TempDir(), "missing")}, &stdout, &stderr)
+	if code != 2 {
+		t.Errorf("expected exit code 2 for missing config, got %d", code)
+	}
+}
@@ -0,0 +1,82 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/srava/swiftssh/internal/config"
+)
+
+// cleanBackupsInput answers the confirmation prompt; tests replace it.
+var cleanBackupsInput io.Reader = os.Stdin
+
+// runCleanBackups implements "sssh clean-backups": it lists the backups
+// SwiftSSH wrote next to the config and its included files (config.bak,
+// <file>.bak and their .bak.N generations) with their sizes, and deletes them
+// after confirmation. --yes skips the question. Exit codes: 0 done or nothing
+// to delete, 1 declined or a file could not be removed, 2 usage or parse
+// error.
+func runCleanBackups(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("clean-backups", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configFlag := fs.String("config", "", "Path to SSH config file")
+	yes := fs.Bool("yes", false, "Delete without asking")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	configPath := resolveConfigPath(*configFlag)
+	hosts, err := config.Parse(configPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "sssh: could not parse SSH config: %v\n", err)
+		return 2
+	}
+	backups, err := config.FindBackups(configPath, hosts)
+	if err != nil {
+		fmt.Fprintf(stderr, "sssh clean-backups: %v\n", err)
+		return 1
+	}
+	if len(backups) == 0 {
+		fmt.Fprintln(stdout, "No backups found.")
+		return 0
+	}
+
+	var total int64
+	for _, b := range backups {
+		fmt.Fprintf(stdout, "%8s  %s\n", formatSize(b.Size), b.Path)
+		total += b.Size
+	}
+	fmt.Fprintf(stdout, "%8s  total\n", formatSize(total))
+
+	ask := newPrompter(cleanBackupsInput, stdout, *yes)
+	if !ask(fmt.Sprintf("Delete %d backup files?", len(backups)), false) {
+		fmt.Fprintln(stdout, "Nothing deleted.")
+		return 1
+	}
+	failed := 0
+	for _, b := range backups {
+		if err := os.Remove(b.Path); err != nil {
+			fmt.Fprintf(stderr, "sssh clean-backups: %v\n", err)
+			failed++
+		}
+	}
+	fmt.Fprintf(stdout, "Deleted %d backup files.\n", len(backups)-failed)
+	if failed > 0 {
+		return 1
+	}
+	return 0
+}
+
+// formatSize renders n bytes as e.g. "512 B", "3.4 KB", or "1.2 MB".
+func formatSize(n int64) string {
+	switch {
+	case n < 1024:
+		return fmt.Sprintf("%d B", n)
+	case n < 1024*1024:
+		return fmt.Sprintf("%.1f KB", float64(n)/1024)
+	default:
+		return fmt.Sprintf("%.1f MB", float64(n)/(1024*1024))
+	}
+}
@@ -0,0 +1,73 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// writeBackups creates each named file in dir.
+func writeBackups(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	for _, name := range names {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte("old"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestRunCleanBackups_DeletesAfterConfirmation(t *testing.T) {
+	configPath := writeConfig(t, "Host web\n    Hostname 10.0.0.5\n")
+	dir := filepath.Dir(configPath)
+	writeBackups(t, dir, "config.bak", "config.bak.1", "id_ed25519.bak")
+	old := cleanBackupsInput
+	cleanBackupsInput = strings.NewReader("y\n")
+	t.Cleanup(func() { cleanBackupsInput = old })
+
+	var stdout, stderr bytes.Buffer
+	if code := runCleanBackups([]string{"--config", configPath}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr.String())
+	}
+	out := stdout.String()
+	if !strings.Contains(out, "3 B  "+filepath.Join(dir, "config.bak.1")) || !strings.Contains(out, "Delete 2 backup files?") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+	for name, wantExists := range map[string]bool{
+		"config": true, "config.bak": false, "config.bak.1": false, "id_ed25519.bak": true,
+	} {
+		_, err := os.Stat(filepath.Join(dir, name))
+		if exists := err == nil; exists != wantExists {
+			t.Errorf("%s exists = %v; want %v", name, exists, wantExists)
+		}
+	}
+}
+
+func TestRunCleanBackups_DeclinedKeepsFiles(t *testing.T) {
+	configPath := writeConfig(t, "Host web\n    Hostname 10.0.0.5\n")
+	writeBackups(t, filepath.Dir(configPath), "config.bak")
+	old := cleanBackupsInput
+	cleanBackupsInput = strings.NewReader("\n")
+	t.Cleanup(func() { cleanBackupsInput = old })
+
+	var stdout, stderr bytes.Buffer
+	if code := runCleanBackups([]string{"--config", configPath}, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit 1, got %d", code)
+	}
+	if _, err := os.Stat(configPath + ".bak"); err != nil {
+		t.Errorf("backup removed after declining: %v", err)
+	}
+}
+
+func TestRunCleanBackups_NothingToDelete(t *testing.T) {
+	configPath := writeConfig(t, "Host web\n    Hostname 10.0.0.5\n")
+
+	var stdout, stderr bytes.Buffer
+	if code := runCleanBackups([]string{"--config", configPath, "--yes"}, &stdout, &stderr); code != 0 {
+		t.Errorf("expected exit 0, got %d", code)
+	}
+	if !strings.Contains(stdout.String(), "No backups found.") {
+		t.Errorf("unexpected output: %q", stdout.String())
+	}
+}
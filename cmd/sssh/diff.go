@@ -0,0 +1,54 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/srava/swiftssh/internal/config"
+)
+
+// runDiff implements "sssh diff": it compares the hosts in the SSH config with
+// those in a reference config (--against), such as a copy checked into git,
+// and prints one line per added (+), removed (-) or changed (~) host. Exit
+// codes: 0 no differences, 1 differences, 2 usage or parse error.
+func runDiff(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("diff", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configFlag := fs.String("config", "", "Path to SSH config file")
+	against := fs.String("against", "", "Reference SSH config to compare with (required)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *against == "" {
+		fmt.Fprintln(stderr, "usage: sssh diff --against <reference-config> [--config <path>]")
+		return 2
+	}
+
+	reference, err := config.Parse(*against)
+	if err != nil {
+		fmt.Fprintf(stderr, "sssh: could not parse %s: %v\n", *against, err)
+		return 2
+	}
+	hosts, err := config.Parse(resolveConfigPath(*configFlag))
+	if err != nil {
+		fmt.Fprintf(stderr, "sssh: could not parse SSH config: %v\n", err)
+		return 2
+	}
+
+	d := config.DiffHosts(reference, hosts)
+	for _, h := range d.Added {
+		fmt.Fprintf(stdout, "+ %s\n", h.Alias)
+	}
+	for _, h := range d.Removed {
+		fmt.Fprintf(stdout, "- %s\n", h.Alias)
+	}
+	for _, c := range d.Changed {
+		fmt.Fprintf(stdout, "~ %s (%s changed)\n", c.New.Alias, strings.Join(c.Fields, ", "))
+	}
+	if d.Empty() {
+		return 0
+	}
+	return 1
+}
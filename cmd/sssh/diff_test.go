@@ -0,0 +1,45 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestRunDiff_ReportsChanges(t *testing.T) {
+	configPath := writeConfig(t, "Host api\n  Hostname a2\nHost db\n  Hostname d\nHost new\n  Hostname n\n")
+	reference := filepath.Join(t.TempDir(), "config.git")
+	if err := os.WriteFile(reference, []byte("Host api\n  Hostname a\nHost db\n  Hostname d\nHost gone\n  Hostname g\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := runDiff([]string{"--config", configPath, "--against", reference}, &stdout, &stderr); code != 1 {
+		t.Fatalf("expected exit 1 for differences, got %d: %s", code, stderr.String())
+	}
+	want := "+ new\n- gone\n~ api (hostname changed)\n"
+	if stdout.String() != want {
+		t.Errorf("stdout = %q; want %q", stdout.String(), want)
+	}
+
+	stdout.Reset()
+	if code := runDiff([]string{"--config", configPath, "--against", configPath}, &stdout, &stderr); code != 0 {
+		t.Errorf("expected exit 0 for identical configs, got %d", code)
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("expected no output for identical configs, got %q", stdout.String())
+	}
+}
+
+func TestRunDiff_UsageErrors(t *testing.T) {
+	configPath := writeConfig(t, "Host api\n  Hostname a\n")
+	var stdout, stderr bytes.Buffer
+	if code := runDiff([]string{"--config", configPath}, &stdout, &stderr); code != 2 {
+		t.Errorf("expected exit 2 without --against, got %d", code)
+	}
+	missing := filepath.Join(t.TempDir(), "missing")
+	if code := runDiff([]string{"--config", configPath, "--against", missing}, &stdout, &stderr); code != 2 {
+		t.Errorf("expected exit 2 for an unreadable reference, got %d", code)
+	}
+}
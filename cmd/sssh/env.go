@@ -0,0 +1,95 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/srava/swiftssh/internal/config"
+	"github.com/srava/swiftssh/internal/ssh"
+)
+
+// runEnv implements "sssh env <alias>": it prints the host's connection
+// details as shell exports for eval, in sh syntax or, with --fish, fish
+// syntax. Exit codes: 0 done, 2 usage, parse, or expansion error.
+func runEnv(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("env", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configFlag := fs.String("config", "", "Path to SSH config file")
+	fish := fs.Bool("fish", false, "Print fish 'set -gx' commands instead of sh exports")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(stderr, "usage: sssh env [--fish] [--config <path>] <alias>")
+		return 2
+	}
+
+	hosts, err := config.Parse(resolveConfigPath(*configFlag))
+	if err != nil {
+		fmt.Fprintf(stderr, "sssh: could not parse SSH config: %v\n", err)
+		return 2
+	}
+	host, ok := findHost(hosts, fs.Arg(0))
+	if !ok {
+		fmt.Fprintf(stderr, "sssh env: no host named %q\n", fs.Arg(0))
+		return 2
+	}
+
+	out, err := envExports(host, *fish)
+	if err != nil {
+		fmt.Fprintf(stderr, "sssh env: %v\n", err)
+		return 2
+	}
+	fmt.Fprintln(stdout, out)
+	return 0
+}
+
+// envExports returns SSH_HOST, SSH_USER, SSH_PORT, and SSH_KEY for h as one
+// sh "export" line, or one fish "set -gx" line per variable. The key path has
+// its tokens and "~" expanded; an unset field exports an empty value.
+func envExports(h config.Host, fish bool) (string, error) {
+	key := h.IdentityFile
+	if key != "" {
+		expanded, err := config.ExpandTokens(key, h)
+		if err != nil {
+			return "", err
+		}
+		key = expanded
+	}
+	hostname := h.Hostname
+	if hostname == "" {
+		hostname = h.Alias
+	}
+	port := h.Port
+	if port == "" {
+		port = "22"
+	}
+	vars := [][2]string{
+		{"SSH_HOST", hostname},
+		{"SSH_USER", h.User},
+		{"SSH_PORT", port},
+		{"SSH_KEY", key},
+	}
+
+	if fish {
+		lines := make([]string, len(vars))
+		for i, v := range vars {
+			lines[i] = "set -gx " + v[0] + " " + fishQuote(v[1])
+		}
+		return strings.Join(lines, "\n"), nil
+	}
+	parts := make([]string, len(vars))
+	for i, v := range vars {
+		parts[i] = v[0] + "=" + ssh.ShellJoin([]string{v[1]})
+	}
+	return "export " + strings.Join(parts, " "), nil
+}
+
+// fishQuote returns s in fish single quotes, where only "\" and "'" need
+// escaping.
+func fishQuote(s string) string {
+	s = strings.ReplaceAll(s, `\`, `\\`)
+	return "'" + strings.ReplaceAll(s, "'", `\'`) + "'"
+}
@@ -0,0 +1,66 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/srava/swiftssh/internal/config"
+)
+
+func TestEnvExports(t *testing.T) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("cannot get home dir")
+	}
+	h := config.Host{Alias: "prod", Hostname: "prod.example.com", User: "o'neil", Port: "2222", IdentityFile: "~/my keys/id_%h"}
+	key := filepath.Join(home, "my keys", "id_prod.example.com")
+
+	got, err := envExports(h, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := "export SSH_HOST=prod.example.com SSH_USER='o'\\''neil' SSH_PORT=2222 SSH_KEY='" + key + "'"
+	if got != want {
+		t.Errorf("sh exports:\n got %s\nwant %s", got, want)
+	}
+
+	got, err = envExports(h, true)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want = "set -gx SSH_HOST 'prod.example.com'\n" +
+		"set -gx SSH_USER 'o\\'neil'\n" +
+		"set -gx SSH_PORT '2222'\n" +
+		"set -gx SSH_KEY '" + key + "'"
+	if got != want {
+		t.Errorf("fish exports:\n got %s\nwant %s", got, want)
+	}
+}
+
+func TestEnvExports_Defaults(t *testing.T) {
+	got, err := envExports(config.Host{Alias: "box"}, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "export SSH_HOST=box SSH_USER='' SSH_PORT=22 SSH_KEY=''"; got != want {
+		t.Errorf("got %s; want %s", got, want)
+	}
+}
+
+func TestRunEnv(t *testing.T) {
+	configPath := writeConfig(t, "Host web\n    Hostname 10.0.0.5\n    User deploy\n")
+
+	var stdout, stderr bytes.Buffer
+	if code := runEnv([]string{"--config", configPath, "web"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr.String())
+	}
+	if want := "export SSH_HOST=10.0.0.5 SSH_USER=deploy SSH_PORT=22 SSH_KEY=''\n"; stdout.String() != want {
+		t.Errorf("stdout = %q; want %q", stdout.String(), want)
+	}
+
+	if code := runEnv([]string{"--config", configPath, "nope"}, &stdout, &stderr); code != 2 {
+		t.Errorf("unknown alias: exit %d; want 2", code)
+	}
+}
@@ -0,0 +1,46 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/srava/swiftssh/internal/config"
+)
+
+// runExport implements "sssh export": it prints every host as an SSH config
+// block, as SwiftSSH would write it. --anonymize replaces aliases, hostnames,
+// users, and key paths with placeholders so the structure can be shared in a
+// bug report. --group keeps only hosts in that group. Exit codes: 0 ok,
+// 2 usage or parse error.
+func runExport(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("export", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configFlag := fs.String("config", "", "Path to SSH config file")
+	group := fs.String("group", "", "Only export hosts in this group (case-insensitive)")
+	anonymize := fs.Bool("anonymize", false, "Replace identifying values with placeholders (host1, user1, ip1, ...)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	hosts, err := config.Parse(resolveConfigPath(*configFlag))
+	if err != nil {
+		fmt.Fprintf(stderr, "sssh: could not parse SSH config: %v\n", err)
+		return 2
+	}
+	if *anonymize {
+		hosts = config.Anonymize(hosts)
+	}
+	if *group != "" {
+		var inGroup []config.Host
+		for _, h := range hosts {
+			if h.InGroup(*group) {
+				inGroup = append(inGroup, h)
+			}
+		}
+		hosts = inGroup
+	}
+
+	fmt.Fprint(stdout, config.FormatHosts(hosts))
+	return 0
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRunExport_PrintsHostBlocks(t *testing.T) {
+	configPath := writeConfig(t, listConfig)
+
+	var stdout, stderr bytes.Buffer
+	if code := runExport([]string{"--config", configPath, "--group", "work"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr.String())
+	}
+	out := stdout.String()
+	for _, want := range []string{"Host api\n", "Hostname a\n", "Host db\n"} {
+		if !strings.Contains(out, want) {
+			t.Errorf("export output missing %q:\n%s", want, out)
+		}
+	}
+	if strings.Contains(out, "Host pi") {
+		t.Errorf("expected pi to be filtered out by --group:\n%s", out)
+	}
+}
+
+func TestRunExport_AnonymizeHidesIdentifyingValues(t *testing.T) {
+	configPath := writeConfig(t, "Host prod\n  Hostname prod.example.com\n  User deploy\n  IdentityFile ~/.ssh/prod_key\n")
+
+	var stdout, stderr bytes.Buffer
+	if code := runExport([]string{"--config", configPath, "--anonymize"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr.String())
+	}
+	out := stdout.String()
+	for _, leaked := range []string{"prod", "example.com", "deploy"} {
+		if strings.Contains(out, leaked) {
+			t.Errorf("anonymized export leaks %q:\n%s", leaked, out)
+		}
+	}
+	if !strings.Contains(out, "Host alias1\n") {
+		t.Errorf("expected placeholder alias in output:\n%s", out)
+	}
+}
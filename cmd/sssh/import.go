@@ -0,0 +1,66 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/srava/swiftssh/internal/config"
+)
+
+// runImport implements "sssh import --from-ansible <inventory>": it appends
+// the inventory's hosts to the SSH config, skipping any whose alias or
+// hostname the config already has. Ansible groups become SwiftSSH groups.
+// --dry-run prints what would be added without writing. Exit codes: 0 done,
+// 1 the config could not be written, 2 usage or parse error.
+func runImport(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("import", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configFlag := fs.String("config", "", "Path to SSH config file")
+	inventory := fs.String("from-ansible", "", "Ansible INI inventory to import (required)")
+	dryRun := fs.Bool("dry-run", false, "Print the hosts that would be added without writing")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *inventory == "" {
+		fmt.Fprintln(stderr, "usage: sssh import --from-ansible <inventory.ini> [--dry-run] [--config <path>]")
+		return 2
+	}
+
+	configPath := resolveConfigPath(*configFlag)
+	existing, err := config.Parse(configPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "sssh: could not parse SSH config: %v\n", err)
+		return 2
+	}
+	imported, err := config.ParseAnsibleInventory(*inventory)
+	if err != nil {
+		fmt.Fprintf(stderr, "sssh import: %v\n", err)
+		return 2
+	}
+
+	aliases := make(map[string]bool, len(existing))
+	for _, h := range existing {
+		aliases[h.Alias] = true
+	}
+	var added []config.Host
+	for _, h := range imported {
+		if aliases[h.Alias] || config.IsKnownHost(existing, h.Hostname) || config.IsKnownHost(added, h.Hostname) {
+			fmt.Fprintf(stdout, "skip %s (already in config)\n", h.Alias)
+			continue
+		}
+		added = append(added, h)
+		fmt.Fprintf(stdout, "add  %s (%s)\n", h.Alias, h.Hostname)
+	}
+
+	if len(added) == 0 || *dryRun {
+		fmt.Fprintf(stdout, "%d hosts to add.\n", len(added))
+		return 0
+	}
+	if err := config.AppendHosts(configPath, config.BackupPath(configPath), added); err != nil {
+		fmt.Fprintf(stderr, "sssh import: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "Added %d hosts to %s.\n", len(added), configPath)
+	return 0
+}
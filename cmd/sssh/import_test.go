@@ -0,0 +1,70 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/srava/swiftssh/internal/config"
+)
+
+func TestRunImport_AppendsNewAnsibleHosts(t *testing.T) {
+	configPath := writeConfig(t, "Host web1\n    Hostname 10.0.0.11\n\nHost legacy-db\n    Hostname 10.0.0.21\n")
+	inventory := filepath.Join(t.TempDir(), "inventory.ini")
+	content := "[web]\nweb1 ansible_host=10.0.0.11\nweb2 ansible_host=10.0.0.12 ansible_user=deploy\n\n" +
+		"[db]\ndb1 ansible_host=10.0.0.21\ndb2 ansible_host=10.0.0.22 ansible_port=5022\n\n[prod:children]\nweb\ndb\n"
+	if err := os.WriteFile(inventory, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := runImport([]string{"--config", configPath, "--from-ansible", inventory}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr.String())
+	}
+	if out := stdout.String(); !strings.Contains(out, "skip web1") || !strings.Contains(out, "skip db1") || !strings.Contains(out, "Added 2 hosts") {
+		t.Errorf("unexpected output:\n%s", out)
+	}
+
+	hosts, _ := config.Parse(configPath)
+	if len(hosts) != 4 {
+		t.Fatalf("expected 4 hosts after import, got %d", len(hosts))
+	}
+	web2, db2 := hosts[2], hosts[3]
+	if web2.Alias != "web2" || web2.User != "deploy" || strings.Join(web2.Groups, ",") != "web,prod" {
+		t.Errorf("web2 = %+v", web2)
+	}
+	if db2.Alias != "db2" || db2.Port != "5022" || strings.Join(db2.Groups, ",") != "db,prod" {
+		t.Errorf("db2 = %+v", db2)
+	}
+
+	// One backup holds the config from before the whole import.
+	backup, _ := os.ReadFile(filepath.Join(filepath.Dir(configPath), "config.bak"))
+	if strings.Contains(string(backup), "web2") || !strings.Contains(string(backup), "legacy-db") {
+		t.Errorf("backup is not the pre-import config:\n%s", backup)
+	}
+}
+
+func TestRunImport_DryRunWritesNothing(t *testing.T) {
+	configPath := writeConfig(t, "")
+	inventory := filepath.Join(t.TempDir(), "inventory.ini")
+	if err := os.WriteFile(inventory, []byte("web1 ansible_host=10.0.0.11\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := runImport([]string{"--config", configPath, "--from-ansible", inventory, "--dry-run"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr.String())
+	}
+	if data, _ := os.ReadFile(configPath); len(data) != 0 {
+		t.Errorf("dry run wrote the config:\n%s", data)
+	}
+}
+
+func TestRunImport_RequiresInventory(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := runImport(nil, &stdout, &stderr); code != 2 {
+		t.Errorf("expected exit 2, got %d", code)
+	}
+}
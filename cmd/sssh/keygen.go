@@ -0,0 +1,148 @@
+package main
+
+import (
+	"bufio"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/srava/swiftssh/internal/config"
+	"github.com/srava/swiftssh/internal/platform"
+	"github.com/srava/swiftssh/internal/ssh"
+)
+
+// keygenRun runs ssh-keygen and ssh-copy-id; keygenInput answers the
+// prompts. Tests replace both.
+var (
+	keygenRun   ssh.AttachedRunner = ssh.RunAttached
+	keygenInput io.Reader          = os.Stdin
+)
+
+// runKeygen implements "sssh keygen --host <alias>": it creates a new key with
+// ssh-keygen, offers to save it as the host's IdentityFile, and offers to
+// install it with ssh-copy-id. An existing key file is only replaced after
+// confirmation. --yes answers yes to every prompt. Exit codes: 0 done,
+// 1 a step failed or was declined, 2 usage or parse error.
+func runKeygen(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("keygen", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configFlag := fs.String("config", "", "Path to SSH config file")
+	alias := fs.String("host", "", "Host alias to create the key for (required)")
+	keyType := fs.String("type", ssh.DefaultKeyType, "Key type passed to ssh-keygen -t")
+	comment := fs.String("comment", "", "Key comment passed to ssh-keygen -C")
+	file := fs.String("file", "", "Key path (default: ~/.ssh/id_<type>_<alias>)")
+	yes := fs.Bool("yes", false, "Answer yes to every prompt")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if *alias == "" {
+		fmt.Fprintln(stderr, "usage: sssh keygen --host <alias> [--type <type>] [--comment <text>] [--file <path>] [--yes]")
+		return 2
+	}
+
+	hosts, err := config.Parse(resolveConfigPath(*configFlag))
+	if err != nil {
+		fmt.Fprintf(stderr, "sssh: could not parse SSH config: %v\n", err)
+		return 2
+	}
+	host, ok := findHost(hosts, *alias)
+	if !ok {
+		fmt.Fprintf(stderr, "sssh keygen: no host named %q\n", *alias)
+		return 2
+	}
+
+	keyPath := *file
+	if keyPath == "" {
+		keyPath = filepath.Join(platform.SSHKeyDir(), "id_"+*keyType+"_"+host.Alias)
+	}
+	ask := newPrompter(keygenInput, stdout, *yes)
+
+	genPath := keyPath
+	if _, err := os.Stat(keyPath); err == nil {
+		if !ask(keyPath+" already exists. Overwrite it?", false) {
+			fmt.Fprintln(stdout, "Kept the existing key.")
+			return 1
+		}
+		// ssh-keygen would ask again, so the new key is generated beside the
+		// old one and only moved over it once ssh-keygen succeeds.
+		tmpDir, err := os.MkdirTemp(filepath.Dir(keyPath), ".sssh-keygen-")
+		if err != nil {
+			fmt.Fprintf(stderr, "sssh keygen: %v\n", err)
+			return 1
+		}
+		defer os.RemoveAll(tmpDir)
+		genPath = filepath.Join(tmpDir, filepath.Base(keyPath))
+	}
+
+	if err := keygenRun("ssh-keygen", ssh.KeygenArgs(genPath, *keyType, *comment)...); err != nil {
+		fmt.Fprintf(stderr, "sssh keygen: ssh-keygen failed: %v\n", err)
+		return 1
+	}
+	if genPath != keyPath {
+		for _, suffix := range []string{"", ".pub"} {
+			if err := os.Rename(genPath+suffix, keyPath+suffix); err != nil {
+				fmt.Fprintf(stderr, "sssh keygen: %v\n", err)
+				return 1
+			}
+		}
+	}
+
+	switch {
+	case host.ReadOnly || host.LineStart == 0:
+		fmt.Fprintf(stdout, "%s cannot be edited; add \"IdentityFile %s\" to it yourself.\n", host.Alias, keyPath)
+	case ask("Use "+keyPath+" as the IdentityFile for "+host.Alias+"?", true):
+		host.IdentityFile = keyPath
+		if _, err := config.ReplaceHostBlock(host); err != nil {
+			fmt.Fprintf(stderr, "sssh keygen: could not update %s: %v\n", host.Alias, err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "Set IdentityFile for %s.\n", host.Alias)
+	}
+
+	if ask("Install the key on "+host.Alias+" with ssh-copy-id?", false) {
+		if err := keygenRun("ssh-copy-id", ssh.CopyIDArgs(host, keyPath)...); err != nil {
+			fmt.Fprintf(stderr, "sssh keygen: ssh-copy-id failed: %v\n", err)
+			return 1
+		}
+	}
+	return 0
+}
+
+// findHost returns the first host named alias.
+func findHost(hosts []config.Host, alias string) (config.Host, bool) {
+	for _, h := range hosts {
+		if h.Alias == alias {
+			return h, true
+		}
+	}
+	return config.Host{}, false
+}
+
+// newPrompter returns a function asking yes/no questions on out and reading
+// answers from in. An empty answer (or end of input) picks def; with all set
+// every question is answered yes without reading.
+func newPrompter(in io.Reader, out io.Writer, all bool) func(question string, def bool) bool {
+	reader := bufio.NewReader(in)
+	return func(question string, def bool) bool {
+		if all {
+			return true
+		}
+		hint := "[y/N]"
+		if def {
+			hint = "[Y/n]"
+		}
+		fmt.Fprintf(out, "%s %s ", question, hint)
+		line, _ := reader.ReadString('\n')
+		switch strings.ToLower(strings.TrimSpace(line)) {
+		case "y", "yes":
+			return true
+		case "n", "no":
+			return false
+		default:
+			return def
+		}
+	}
+}
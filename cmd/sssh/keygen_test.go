@@ -0,0 +1,142 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/srava/swiftssh/internal/config"
+)
+
+// fakeKeygen replaces the keygen runner and prompt input for one test and
+// records every command run. ssh-keygen is simulated by creating the key.
+func fakeKeygen(t *testing.T, answers string) *[]string {
+	t.Helper()
+	var ran []string
+	oldRun, oldInput := keygenRun, keygenInput
+	keygenRun = func(name string, args ...string) error {
+		ran = append(ran, name+" "+strings.Join(args, " "))
+		if name == "ssh-keygen" {
+			for i, a := range args {
+				if a == "-f" {
+					_ = os.WriteFile(args[i+1], []byte("new"), 0600)
+					_ = os.WriteFile(args[i+1]+".pub", []byte("new.pub"), 0644)
+				}
+			}
+		}
+		return nil
+	}
+	keygenInput = strings.NewReader(answers)
+	t.Cleanup(func() { keygenRun, keygenInput = oldRun, oldInput })
+	return &ran
+}
+
+func TestRunKeygen_CreatesKeyAndSetsIdentityFile(t *testing.T) {
+	configPath := writeConfig(t, "Host web\n    Hostname 10.0.0.5\n    User deploy\n")
+	keyPath := filepath.Join(t.TempDir(), "id_web")
+	ran := fakeKeygen(t, "\ny\n") // accept IdentityFile default, then install
+
+	var stdout, stderr bytes.Buffer
+	code := runKeygen([]string{"--config", configPath, "--host", "web", "--file", keyPath, "--comment", "me@laptop"}, &stdout, &stderr)
+	if code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr.String())
+	}
+
+	want := []string{
+		"ssh-keygen -t ed25519 -f " + keyPath + " -C me@laptop",
+		"ssh-copy-id -i " + keyPath + ".pub deploy@10.0.0.5",
+	}
+	if strings.Join(*ran, "\n") != strings.Join(want, "\n") {
+		t.Errorf("ran:\n%s\nwant:\n%s", strings.Join(*ran, "\n"), strings.Join(want, "\n"))
+	}
+
+	hosts, _ := config.Parse(configPath)
+	if hosts[0].IdentityFile != keyPath {
+		t.Errorf("IdentityFile = %q; want %q", hosts[0].IdentityFile, keyPath)
+	}
+}
+
+func TestRunKeygen_DeclinedOverwriteKeepsKey(t *testing.T) {
+	configPath := writeConfig(t, "Host web\n    Hostname 10.0.0.5\n")
+	keyPath := filepath.Join(t.TempDir(), "id_web")
+	if err := os.WriteFile(keyPath, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	ran := fakeKeygen(t, "\n") // default is No
+
+	var stdout, stderr bytes.Buffer
+	if code := runKeygen([]string{"--config", configPath, "--host", "web", "--file", keyPath}, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit 1, got %d", code)
+	}
+	if len(*ran) != 0 {
+		t.Errorf("expected nothing to run, got %v", *ran)
+	}
+	if data, _ := os.ReadFile(keyPath); string(data) != "old" {
+		t.Errorf("existing key was modified: %q", data)
+	}
+}
+
+func TestRunKeygen_OverwriteReplacesKeyOnlyOnSuccess(t *testing.T) {
+	configPath := writeConfig(t, "Host web\n    Hostname 10.0.0.5\n")
+	keyPath := filepath.Join(t.TempDir(), "id_web")
+	if err := os.WriteFile(keyPath, []byte("old"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	fakeKeygen(t, "")
+	succeed := keygenRun
+	keygenRun = func(name string, args ...string) error { return errors.New("interrupted") }
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"--config", configPath, "--host", "web", "--file", keyPath, "--yes"}
+	if code := runKeygen(args, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit 1 when ssh-keygen fails, got %d", code)
+	}
+	if data, _ := os.ReadFile(keyPath); string(data) != "old" {
+		t.Errorf("existing key lost after a failed ssh-keygen: %q", data)
+	}
+
+	keygenRun = succeed
+	if code := runKeygen(args, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr.String())
+	}
+	if data, _ := os.ReadFile(keyPath); string(data) != "new" {
+		t.Errorf("key = %q; want the new key", data)
+	}
+	if data, _ := os.ReadFile(keyPath + ".pub"); string(data) != "new.pub" {
+		t.Errorf("public key = %q; want the new one", data)
+	}
+	if entries, _ := os.ReadDir(filepath.Dir(keyPath)); len(entries) != 2 {
+		t.Errorf("expected only the key pair to remain, got %d entries", len(entries))
+	}
+}
+
+func TestRunKeygen_NoPromptsLeavesConfig(t *testing.T) {
+	content := "Host web\n    Hostname 10.0.0.5\n"
+	configPath := writeConfig(t, content)
+	keyPath := filepath.Join(t.TempDir(), "id_web")
+	ran := fakeKeygen(t, "n\n")
+
+	var stdout, stderr bytes.Buffer
+	if code := runKeygen([]string{"--config", configPath, "--host", "web", "--file", keyPath, "--type", "rsa"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr.String())
+	}
+	if len(*ran) != 1 || !strings.HasPrefix((*ran)[0], "ssh-keygen -t rsa") {
+		t.Errorf("expected only ssh-keygen -t rsa, got %v", *ran)
+	}
+	if data, _ := os.ReadFile(configPath); string(data) != content {
+		t.Errorf("config changed after declining:\n%s", data)
+	}
+}
+
+func TestRunKeygen_UnknownHost(t *testing.T) {
+	configPath := writeConfig(t, "Host web\n    Hostname 10.0.0.5\n")
+	fakeKeygen(t, "")
+
+	var stdout, stderr bytes.Buffer
+	if code := runKeygen([]string{"--config", configPath, "--host", "nope"}, &stdout, &stderr); code != 2 {
+		t.Errorf("expected exit 2, got %d", code)
+	}
+}
@@ -0,0 +1,101 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/srava/swiftssh/internal/config"
+)
+
+// runKeys implements "sssh keys": it lists every distinct IdentityFile the
+// config references and the hosts using it. With --check each key is marked
+// ok, missing, or loose (readable by group/others). --json prints the same
+// report as JSON. Exit codes: 0 ok, 1 a key is missing or unreadable (with
+// --check), 2 usage or parse error.
+func runKeys(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("keys", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configFlag := fs.String("config", "", "Path to SSH config file")
+	check := fs.Bool("check", false, "Report whether each key exists and has safe permissions")
+	jsonOut := fs.Bool("json", false, "Print the report as JSON")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	hosts, err := config.Parse(resolveConfigPath(*configFlag))
+	if err != nil {
+		fmt.Fprintf(stderr, "sssh: could not parse SSH config: %v\n", err)
+		return 2
+	}
+
+	keys := config.CheckIdentityFiles(hosts)
+	missing, loose := 0, 0
+	for _, k := range keys {
+		switch {
+		case !k.Exists:
+			missing++
+		case !k.PermsOK:
+			loose++
+		}
+	}
+
+	if *jsonOut {
+		if keys == nil {
+			keys = []config.KeyStatus{}
+		}
+		data, err := json.MarshalIndent(keys, "", "  ")
+		if err != nil {
+			fmt.Fprintf(stderr, "sssh: %v\n", err)
+			return 1
+		}
+		fmt.Fprintln(stdout, string(data))
+	} else {
+		for _, k := range keys {
+			hostList := strings.Join(k.Hosts, ", ")
+			if !*check {
+				fmt.Fprintf(stdout, "%s (%s)\n", k.Path, hostList)
+				continue
+			}
+			fmt.Fprintf(stdout, "%-8s %s (%s)%s\n", keyLabel(k), k.Path, hostList, keyDetail(k))
+		}
+		if *check {
+			fmt.Fprintf(stdout, "%d key(s) referenced: %d missing, %d with loose permissions\n", len(keys), missing, loose)
+		}
+	}
+
+	if *check && missing > 0 {
+		return 1
+	}
+	return 0
+}
+
+// keyLabel returns the status column for k in "sssh keys --check".
+func keyLabel(k config.KeyStatus) string {
+	switch {
+	case k.Error != "":
+		return "error"
+	case !k.Exists:
+		return "missing"
+	case !k.PermsOK:
+		return "loose"
+	default:
+		return "ok"
+	}
+}
+
+// keyDetail returns a trailing explanation for keys that need attention.
+func keyDetail(k config.KeyStatus) string {
+	switch {
+	case k.Error != "":
+		return ": " + k.Error
+	case !k.Exists && k.Expanded != k.Path:
+		return ": " + k.Expanded + " not found"
+	case k.Exists && !k.PermsOK:
+		return ": accessible by group/others (run chmod 600)"
+	default:
+		return ""
+	}
+}
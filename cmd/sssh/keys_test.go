@@ -0,0 +1,92 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/srava/swiftssh/internal/config"
+)
+
+// writeKeyConfig creates one existing key and returns a config referencing it
+// from two hosts and a missing key from a third, plus both key paths.
+func writeKeyConfig(t *testing.T) (configPath, present, missing string) {
+	t.Helper()
+	dir := t.TempDir()
+	present = filepath.Join(dir, "id_present")
+	if err := os.WriteFile(present, []byte("key"), 0600); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+	missing = filepath.Join(dir, "id_missing")
+	configPath = writeConfig(t, "Host a\n    IdentityFile "+present+"\n\n"+
+		"Host b\n    IdentityFile "+missing+"\n\n"+
+		"Host c\n    IdentityFile "+present+"\n\n"+
+		"Host d\n    Hostname d.example.com\n")
+	return configPath, present, missing
+}
+
+func TestRunKeys_CheckReportsMissing(t *testing.T) {
+	path, present, missing := writeKeyConfig(t)
+
+	var stdout, stderr bytes.Buffer
+	code := runKeys([]string{"--config", path, "--check"}, &stdout, &stderr)
+
+	if code != 1 {
+		t.Errorf("expected exit code 1, got %d", code)
+	}
+	out := stdout.String()
+	if !strings.Contains(out, "ok       "+present+" (a, c)") {
+		t.Errorf("expected present key listed once for a and c, got:\n%s", out)
+	}
+	if !strings.Contains(out, "missing  "+missing+" (b)") {
+		t.Errorf("expected missing key flagged, got:\n%s", out)
+	}
+	if !strings.Contains(out, "2 key(s) referenced: 1 missing") {
+		t.Errorf("expected summary line, got:\n%s", out)
+	}
+}
+
+func TestRunKeys_WithoutCheckExitsZero(t *testing.T) {
+	path, _, missing := writeKeyConfig(t)
+
+	var stdout, stderr bytes.Buffer
+	if code := runKeys([]string{"--config", path}, &stdout, &stderr); code != 0 {
+		t.Errorf("expected exit code 0, got %d", code)
+	}
+	if !strings.Contains(stdout.String(), missing+" (b)") {
+		t.Errorf("expected key list, got:\n%s", stdout.String())
+	}
+}
+
+func TestRunKeys_JSON(t *testing.T) {
+	path, present, _ := writeKeyConfig(t)
+
+	var stdout, stderr bytes.Buffer
+	code := runKeys([]string{"--config", path, "--check", "--json"}, &stdout, &stderr)
+	if code != 1 {
+		t.Errorf("expected exit code 1, got %d", code)
+	}
+
+	var keys []config.KeyStatus
+	if err := json.Unmarshal(stdout.Bytes(), &keys); err != nil {
+		t.Fatalf("output is not JSON: %v\n%s", err, stdout.String())
+	}
+	if len(keys) != 2 || keys[0].Path != present || !keys[0].Exists || keys[1].Exists {
+		t.Errorf("unexpected report: %+v", keys)
+	}
+}
+
+func TestRunKeys_AllPresent(t *testing.T) {
+	path := writeConfig(t, "Host d\n    Hostname d.example.com\n")
+
+	var stdout, stderr bytes.Buffer
+	if code := runKeys([]string{"--config", path, "--check"}, &stdout, &stderr); code != 0 {
+		t.Errorf("expected exit code 0, got %d", code)
+	}
+	if !strings.Contains(stdout.String(), "0 key(s) referenced") {
+		t.Errorf("expected empty summary, got:\n%s", stdout.String())
+	}
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/srava/swiftssh/internal/config"
+)
+
+// runList implements "sssh list": it prints host aliases in config order, one
+// per line. --group keeps only hosts in that group; --count prints just the
+// number of hosts, for scripts and dashboards; --anonymize prints placeholder
+// aliases for sharing.
+func runList(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configFlag := fs.String("config", "", "Path to SSH config file")
+	group := fs.String("group", "", "Only list hosts in this group (case-insensitive)")
+	count := fs.Bool("count", false, "Print only the number of hosts")
+	anonymize := fs.Bool("anonymize", false, "Replace aliases with placeholders (alias1, alias2, ...)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	hosts, err := config.Parse(resolveConfigPath(*configFlag))
+	if err != nil {
+		fmt.Fprintf(stderr, "sssh: could not parse SSH config: %v\n", err)
+		return 2
+	}
+
+	// Anonymize the whole config first so placeholders match "sssh export
+	// --anonymize" whatever the group filter.
+	if *anonymize {
+		hosts = config.Anonymize(hosts)
+	}
+
+	if *group != "" {
+		var inGroup []config.Host
+		for _, h := range hosts {
+			if h.InGroup(*group) {
+				inGroup = append(inGroup, h)
+			}
+		}
+		hosts = inGroup
+	}
+
+	if *count {
+		fmt.Fprintln(stdout, len(hosts))
+		return 0
+	}
+	for _, h := range hosts {
+		fmt.Fprintln(stdout, h.Alias)
+	}
+	return 0
+}
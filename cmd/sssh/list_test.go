@@ -0,0 +1,72 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"testing"
+)
+
+const listConfig = "# @group Work\nHost api\n  Hostname a\n# @group work, Home\nHost db\n  Hostname d\nHost pi\n  Hostname p\n"
+
+func TestRunList_Count(t *testing.T) {
+	configPath := writeConfig(t, listConfig)
+
+	var stdout, stderr bytes.Buffer
+	if code := runList([]string{"--config", configPath, "--count"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr.String())
+	}
+	if stdout.String() != "3\n" {
+		t.Errorf("stdout = %q; want %q", stdout.String(), "3\n")
+	}
+	if stderr.Len() != 0 {
+		t.Errorf("expected no stderr output, got %q", stderr.String())
+	}
+}
+
+func TestRunList_CountWithGroup(t *testing.T) {
+	configPath := writeConfig(t, listConfig)
+
+	var stdout, stderr bytes.Buffer
+	if code := runList([]string{"--config", configPath, "--group", "WORK", "--count"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr.String())
+	}
+	if stdout.String() != "2\n" {
+		t.Errorf("stdout = %q; want %q", stdout.String(), "2\n")
+	}
+
+	stdout.Reset()
+	runList([]string{"--config", configPath, "--group", "Home"}, &stdout, &stderr)
+	if stdout.String() != "db\n" {
+		t.Errorf("list --group Home = %q; want %q", stdout.String(), "db\n")
+	}
+}
+
+func TestRunList_ParseErrorExitCode(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	missing := filepath.Join(t.TempDir(), "missing")
+	if code := runList([]string{"--config", missing, "--count"}, &stdout, &stderr); code != 2 {
+		t.Errorf("expected exit 2 for unreadable config, got %d", code)
+	}
+	if stdout.Len() != 0 {
+		t.Errorf("expected nothing on stdout, got %q", stdout.String())
+	}
+}
+
+func TestRunList_Anonymize(t *testing.T) {
+	configPath := writeConfig(t, listConfig)
+
+	var stdout, stderr bytes.Buffer
+	if code := runList([]string{"--config", configPath, "--anonymize"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr.String())
+	}
+	if want := "alias1\nalias2\nalias3\n"; stdout.String() != want {
+		t.Errorf("stdout = %q; want %q", stdout.String(), want)
+	}
+
+	// Placeholders come from the whole config, so filtering keeps db as alias2.
+	stdout.Reset()
+	runList([]string{"--config", configPath, "--anonymize", "--group", "Home"}, &stdout, &stderr)
+	if stdout.String() != "alias2\n" {
+		t.Errorf("list --anonymize --group Home = %q; want %q", stdout.String(), "alias2\n")
+	}
+}
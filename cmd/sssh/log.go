@@ -0,0 +1,53 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+	"time"
+
+	"github.com/srava/swiftssh/internal/audit"
+)
+
+// runLog implements "sssh log": it prints the most recent entries of the
+// connection log as a table, newest last.
+func runLog(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("log", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	fileFlag := fs.String("file", "", "Path to connection log (default: $SWIFTSSH_CONNECTION_LOG or platform path)")
+	n := fs.Int("n", 20, "Number of most recent entries to show (0 = all)")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+
+	path := *fileFlag
+	if path == "" {
+		path = connectionLogPath()
+	}
+	entries, err := audit.ReadLog(path)
+	if err != nil {
+		fmt.Fprintf(stderr, "sssh: could not read connection log: %v\n", err)
+		return 1
+	}
+	if *n > 0 && len(entries) > *n {
+		entries = entries[len(entries)-*n:]
+	}
+
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TIME\tALIAS\tHOSTNAME\tUSER\tIDENTITY")
+	for _, e := range entries {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\n",
+			e.Time.Local().Format(time.DateTime), orDash(e.Alias), orDash(e.Hostname), orDash(e.User), orDash(e.IdentityFile))
+	}
+	tw.Flush()
+	return 0
+}
+
+// orDash returns s, or "-" if s is empty, so table columns stay aligned.
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
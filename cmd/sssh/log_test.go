@@ -0,0 +1,37 @@
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/srava/swiftssh/internal/audit"
+)
+
+func TestRunLog_ShowsMostRecentEntries(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "connections.log")
+	for i := 1; i <= 3; i++ {
+		entry := audit.ConnectionLogEntry{Time: time.Now(), Alias: fmt.Sprintf("host%d", i), Hostname: "10.0.0.1"}
+		if err := audit.LogConnection(path, entry); err != nil {
+			t.Fatalf("LogConnection failed: %v", err)
+		}
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := runLog([]string{"--file", path, "-n", "2"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr.String())
+	}
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected header + 2 entries, got:\n%s", stdout.String())
+	}
+	if !strings.Contains(lines[1], "host2") || !strings.Contains(lines[2], "host3") {
+		t.Errorf("expected the two newest entries in order, got:\n%s", stdout.String())
+	}
+	if !strings.Contains(lines[2], "-") {
+		t.Errorf("expected empty user/identity shown as '-', got %q", lines[2])
+	}
+}
@@ -1,14 +1,19 @@
 package main
 
 import (
+	"errors"
 	"flag"
 	"fmt"
+	"io"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/srava/swiftssh/internal/audit"
 	"github.com/srava/swiftssh/internal/config"
 	"github.com/srava/swiftssh/internal/platform"
 	"github.com/srava/swiftssh/internal/state"
@@ -17,6 +22,44 @@ import (
 
 var version = "dev"
 
+// subcommands maps a leading positional argument to its handler. Each handler
+// receives the remaining args and returns the process exit code.
+var subcommands = map[string]func(args []string, stdout, stderr io.Writer) int{
+	"check":         runCheck,
+	"clean-backups": runCleanBackups,
+	"diff":          runDiff,
+	"env":           runEnv,
+	"export":        runExport,
+	"import":        runImport,
+	"keygen":        runKeygen,
+	"keys":          runKeys,
+	"list":          runList,
+	"log":           runLog,
+	"order":         runOrder,
+	"rewrite":       runRewrite,
+	"scp":           runSCP,
+	"sftp":          runSFTP,
+	"state":         runState,
+}
+
+// resolveConfigPath returns override (from --config) if set, otherwise
+// $SWIFTSSH_CONFIG or the default SSH config path.
+func resolveConfigPath(override string) string {
+	if override != "" {
+		return override
+	}
+	return platform.SSHConfigPath()
+}
+
+// customConfigPath returns configPath, or "" when it is ssh's default config
+// and ssh needs no -F to find it. A $SWIFTSSH_CONFIG path still needs -F.
+func customConfigPath(configPath string) string {
+	if filepath.Clean(configPath) == filepath.Clean(platform.DefaultSSHConfigPath()) {
+		return ""
+	}
+	return configPath
+}
+
 // extractConfigFlag pre-scans args for --config <path> or --config=<path>
 // without calling flag.Parse(), so it works before the SSH passthrough check.
 func extractConfigFlag(args []string) string {
@@ -38,30 +81,66 @@ func main() {
 	rawArgs := os.Args[1:]
 	configOverride := extractConfigFlag(rawArgs) // pre-scan before flag.Parse
 
+	if len(rawArgs) > 0 {
+		// "sssh scp"/"sssh sftp" with a user@host destination or the real
+		// tool's options wrap that tool the way SSH passthrough wraps ssh.
+		if native, ok := toolSubcommandFlags[rawArgs[0]]; ok && looksLikeToolArgs(native, rawArgs[1:]) {
+			runPassthrough(rawArgs[0], rawArgs[1:], configOverride)
+			return
+		}
+		if run, ok := subcommands[rawArgs[0]]; ok {
+			os.Exit(run(rawArgs[1:], os.Stdout, os.Stderr))
+		}
+	}
+
 	// Detect SSH passthrough invocations before flag.Parse() so that
 	// SSH flags like -i, -p, -l don't trigger "flag provided but not defined".
 	// A passthrough call contains at least one argument that is either
 	// user@host syntax or an SSH option flag (-i, -p, -l, etc.).
 	if looksLikeSSHArgs(rawArgs) {
-		runPassthrough(rawArgs, configOverride)
+		runPassthrough("ssh", rawArgs, configOverride)
 		return
 	}
 
-	showVersion := flag.Bool("version", false, "Print version and exit")
-	flag.BoolVar(showVersion, "v", false, "Print version and exit (shorthand)")
-	configFlag := flag.String("config", "", "Path to SSH config file")
-	noFrequent := flag.Bool("no-frequent", false, "Flat alphabetical order (skip frequency sort)")
+	f := defineTUIFlags(flag.CommandLine)
 	flag.Parse()
 
-	if *showVersion {
+	if *f.showVersion {
 		fmt.Printf("sssh %s\n", version)
 		os.Exit(0)
 	}
 
-	configPath := platform.SSHConfigPath()
-	if *configFlag != "" {
-		configPath = *configFlag
+	if *f.connectBy != tui.ConnectByAlias && *f.connectBy != tui.ConnectByHostname {
+		fmt.Fprintf(os.Stderr, "Error: --connect-by must be %q or %q, got %q\n",
+			tui.ConnectByAlias, tui.ConnectByHostname, *f.connectBy)
+		os.Exit(2)
+	}
+	if !validSortMode(*f.sortMode) {
+		fmt.Fprintf(os.Stderr, "Error: --sort must be %q, %q, %q, or %q, got %q\n",
+			state.SortFrequency, state.SortAlpha, state.SortGroup, state.SortRecent, *f.sortMode)
+		os.Exit(2)
+	}
+	if *f.matchMode != tui.MatchFuzzy && *f.matchMode != tui.MatchPrefix {
+		fmt.Fprintf(os.Stderr, "Error: --match must be %q or %q, got %q\n",
+			tui.MatchFuzzy, tui.MatchPrefix, *f.matchMode)
+		os.Exit(2)
 	}
+	var scheme *config.Scheme
+	if *f.scheme != "" {
+		s, err := config.ParseScheme(*f.scheme)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "Error: --scheme: %v\n", err)
+			os.Exit(2)
+		}
+		scheme = &s
+	}
+	if *f.enterAction != tui.EnterActionConnect && *f.enterAction != tui.EnterActionEdit {
+		fmt.Fprintf(os.Stderr, "Error: --enter-action must be %q or %q, got %q\n",
+			tui.EnterActionConnect, tui.EnterActionEdit, *f.enterAction)
+		os.Exit(2)
+	}
+
+	configPath := resolveConfigPath(*f.config)
 
 	hosts, err := config.Parse(configPath)
 	if err != nil {
@@ -69,91 +148,383 @@ func main() {
 		os.Exit(1)
 	}
 
+	if *f.system {
+		systemHosts, err := config.ParseSystem(platform.SystemSSHConfigPath())
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "sssh: warning: could not parse system SSH config: %v\n", err)
+		}
+		hosts = config.MergeSystemHosts(hosts, systemHosts)
+	}
+
+	config.HideMatching(hosts, *f.hide)
+
 	if len(hosts) == 0 {
 		fmt.Printf("No hosts found in %s. Add entries to your SSH config.\n", configPath)
 		os.Exit(0)
 	}
 
+	// With --no-history the state file is never read or written.
 	statePath := platform.StateFilePath()
-	st, err := state.Load(statePath)
-	if err != nil {
-		st = &state.State{Connections: make(map[string]int)}
+	st := &state.State{Connections: make(map[string]int)}
+	if !*f.noHistory {
+		st = loadState(statePath)
 	}
 
-	p := tea.NewProgram(tui.New(hosts, st, statePath, *noFrequent), tea.WithAltScreen())
+	opts := tui.Options{
+		NoFrequent:   *f.noFrequent,
+		Sort:         *f.sortMode,
+		NoHistory:    *f.noHistory,
+		LogPath:      connectionLogPath(),
+		ConfigPath:   customConfigPath(configPath),
+		ConnectBy:    *f.connectBy,
+		EnterAction:  *f.enterAction,
+		Limit:        *f.limit,
+		Preview:      *f.preview,
+		ConfirmEdits: *f.confirmEdits,
+		ClearSearch:  *f.clearSearch,
+		AutoConnect:  *f.autoConnect,
+		NoReverse:    *f.noReverse,
+		Inline:       *f.inline,
+		ShowUses:     *f.uses,
+		Scheme:       scheme,
+		Match:        *f.matchMode,
+	}
+	p := tea.NewProgram(tui.NewWithOptions(hosts, st, statePath, opts), programOptions(*f.inline)...)
 	if _, err := p.Run(); err != nil {
 		fmt.Fprintf(os.Stderr, "Error running TUI: %v\n", err)
 		os.Exit(1)
 	}
 }
 
-// runPassthrough parses SSH-style arguments, auto-saves unknown hosts to
-// the SSH config, then hands off to the system ssh binary.
-func runPassthrough(args []string, configOverride string) {
-	dest, port, user, identity := parseSSHTarget(args)
-	if dest == "" {
+// tuiFlags holds the flags of the TUI itself, "sssh [flags]".
+type tuiFlags struct {
+	showVersion, noFrequent, noHistory, clearSearch, uses, inline *bool
+	noReverse, autoConnect, confirmEdits, system, preview         *bool
+	config, sortMode, connectBy, matchMode, scheme, enterAction   *string
+	limit                                                         *int
+	hide                                                          *stringList
+}
+
+// defineTUIFlags defines the TUI's flags on fs. main uses flag.CommandLine;
+// nativeFlags is derived from a scratch set.
+func defineTUIFlags(fs *flag.FlagSet) tuiFlags {
+	f := tuiFlags{hide: &stringList{}}
+	f.showVersion = fs.Bool("version", false, "Print version and exit")
+	fs.BoolVar(f.showVersion, "v", false, "Print version and exit (shorthand)")
+	f.config = fs.String("config", "", "Path to SSH config file")
+	f.noFrequent = fs.Bool("no-frequent", false, "Flat alphabetical order (skip frequency sort)")
+	f.sortMode = fs.String("sort", state.SortFrequency, "Host order: 'frequency', 'alpha', 'group' (by group, then frequency), or 'recent' (last connected first)")
+	f.noHistory = fs.Bool("no-history", envBool("SWIFTSSH_NO_HISTORY"), "Do not record or persist connection history")
+	f.connectBy = fs.String("connect-by", tui.ConnectByAlias, "Connect using the host 'alias' or 'hostname'")
+	f.clearSearch = fs.Bool("clear-search", false, "Clear the search query when an ssh session ends")
+	f.uses = fs.Bool("uses", false, "Show a USES column with each host's connection count")
+	f.inline = fs.Bool("inline", false, "Draw the list inline instead of on the alternate screen, so it stays in the scrollback")
+	f.noReverse = fs.Bool("no-reverse", false, "Highlight the selected row with high-contrast colors instead of reverse video")
+	f.autoConnect = fs.Bool("auto-connect", false, "Connect when the search narrows to one host and typing pauses (never for prod/production/danger groups)")
+	f.matchMode = fs.String("match", tui.MatchFuzzy, "Search matching: 'fuzzy' (subsequence) or 'prefix' (alias or hostname starts with the query)")
+	f.scheme = fs.String("scheme", "", "Alias naming scheme, e.g. 'svc-env-region'; shows the env part as each matching host's group")
+	f.confirmEdits = fs.Bool("confirm-edits", false, "Show a diff and ask before saving host edits")
+	f.system = fs.Bool("system", false, "Also list read-only hosts from the system-wide ssh_config")
+	f.preview = fs.Bool("preview", false, "Show the ssh command Enter would run for the selected host")
+	f.limit = fs.Int("limit", 0, "Initially show at most N hosts; press + to show N more (0 = all)")
+	f.enterAction = fs.String("enter-action", tui.EnterActionConnect, "What Enter does: 'connect' or 'edit' (Ctrl+E does the other)")
+	fs.Var(f.hide, "hide", "Hide hosts whose alias or hostname matches this glob (repeatable; H reveals them)")
+	return f
+}
+
+// flagArity maps each flag that define puts on a FlagSet to whether it takes
+// a value, so the passthrough checks know sssh's own flags without a copy of
+// the list.
+func flagArity[T any](define func(*flag.FlagSet) T) map[string]bool {
+	fs := flag.NewFlagSet("", flag.ContinueOnError)
+	define(fs)
+	arity := make(map[string]bool)
+	fs.VisitAll(func(f *flag.Flag) {
+		b, isBool := f.Value.(interface{ IsBoolFlag() bool })
+		arity[f.Name] = !isBool || !b.IsBoolFlag()
+	})
+	return arity
+}
+
+// programOptions returns the bubbletea options for the TUI: the alternate
+// screen unless inline is set.
+func programOptions(inline bool) []tea.ProgramOption {
+	if inline {
+		return nil
+	}
+	return []tea.ProgramOption{tea.WithAltScreen()}
+}
+
+// validSortMode reports whether mode is a --sort value OrderHostsBy accepts.
+func validSortMode(mode string) bool {
+	return mode == state.SortFrequency || mode == state.SortAlpha || mode == state.SortGroup || mode == state.SortRecent
+}
+
+// stringList is a flag.Value collecting every occurrence of a repeatable flag.
+type stringList []string
+
+func (l *stringList) String() string { return strings.Join(*l, ",") }
+
+func (l *stringList) Set(v string) error {
+	*l = append(*l, v)
+	return nil
+}
+
+// loadState loads the state file at path, falling back to an empty state if it
+// cannot be read.
+func loadState(path string) *state.State {
+	st, err := state.Load(path)
+	if err != nil {
+		return &state.State{Connections: make(map[string]int)}
+	}
+	return st
+}
+
+// envBool reports whether the environment variable name is set to a true value.
+// strconv.ParseBool's false values, "no" and "off" (in any case) count as
+// false; any other non-empty value counts as true.
+func envBool(name string) bool {
+	v := strings.TrimSpace(os.Getenv(name))
+	switch strings.ToLower(v) {
+	case "", "no", "off":
+		return false
+	}
+	b, err := strconv.ParseBool(v)
+	return err != nil || b
+}
+
+// connectionLogPath returns the connection log path: $SWIFTSSH_CONNECTION_LOG
+// if set, otherwise the platform default.
+func connectionLogPath() string {
+	if p := os.Getenv("SWIFTSSH_CONNECTION_LOG"); p != "" {
+		return p
+	}
+	return platform.ConnectionLogPath()
+}
+
+// recordHostConnection counts a connection to host in the state file at
+// statePath (the platform default if empty) and appends it to the connection
+// log, unless noHistory is set. Failures are ignored.
+func recordHostConnection(statePath string, host config.Host, noHistory bool) {
+	if noHistory {
+		return
+	}
+	if statePath == "" {
+		statePath = platform.StateFilePath()
+	}
+	st := loadState(statePath)
+	state.RecordConnection(st, host.Alias)
+	_ = state.Save(statePath, st)
+	_ = audit.LogConnection(connectionLogPath(), audit.ConnectionLogEntry{
+		Time:         time.Now(),
+		Alias:        host.Alias,
+		Hostname:     host.Hostname,
+		User:         host.User,
+		IdentityFile: host.IdentityFile,
+	})
+}
+
+// runPassthrough parses the arguments of binary (ssh, scp, or sftp),
+// auto-saves an unknown destination host to the SSH config, then hands off
+// to the system binary.
+// With --interactive (removed before calling binary), the synthesized host is
+// shown in a form for review before it is saved. --no-history (also removed)
+// or SWIFTSSH_NO_HISTORY keeps the connection out of the connection log.
+func runPassthrough(binary string, args []string, configOverride string) {
+	args, interactive := stripFlag(args, "--interactive")
+	args, noHistory := stripFlag(args, "--no-history")
+	h, ok := synthesizeHost(binary, args)
+	if !ok {
 		fmt.Fprintln(os.Stderr, "sssh: no destination found in arguments")
 		os.Exit(1)
 	}
 
-	// Separate user from hostname if provided as user@hostname
-	hostname := dest
-	if idx := strings.Index(dest, "@"); idx >= 0 {
-		if user == "" {
-			user = dest[:idx]
+	var confirm func(config.Host) (config.Host, bool, error)
+	if interactive {
+		confirm = tui.ConfirmHost
+	}
+	saved, err := autoSaveHost(resolveConfigPath(configOverride), h, confirm)
+	switch {
+	case errors.Is(err, tui.ErrCancelled):
+		os.Exit(1)
+	case err != nil:
+		fmt.Fprintf(os.Stderr, "sssh: warning: could not save host to config: %v\n", err)
+	case saved.Alias != "":
+		fmt.Fprintf(os.Stderr, "sssh: saved '%s' to SSH config\n", saved.Alias)
+		if interactive {
+			// The form may have changed the user, port or identity
+			h = saved
+			args = retarget(binary, args, h)
 		}
-		hostname = dest[idx+1:]
 	}
 
+	if !noHistory && !envBool("SWIFTSSH_NO_HISTORY") {
+		_ = audit.LogConnection(connectionLogPath(), audit.ConnectionLogEntry{
+			Time:         time.Now(),
+			Alias:        h.Alias,
+			Hostname:     h.Hostname,
+			User:         h.User,
+			IdentityFile: h.IdentityFile,
+		})
+	}
+
+	cmd := exec.Command(binary, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	if err := cmd.Run(); err != nil {
+		os.Exit(1)
+	}
+}
+
+// stripFlag returns args without any occurrence of flag and whether it was present.
+func stripFlag(args []string, flag string) ([]string, bool) {
+	var rest []string
+	found := false
+	for _, a := range args {
+		if a == flag {
+			found = true
+			continue
+		}
+		rest = append(rest, a)
+	}
+	return rest, found
+}
+
+// synthesizeHost builds the config entry passthrough would save for args to
+// binary. The alias is "user-hostname" (or just the hostname) and a relative
+// identity path is made absolute. Returns false if args contain no destination.
+func synthesizeHost(binary string, args []string) (config.Host, bool) {
+	dest, port, user, identity := parseSSHTarget(binary, args)
+	if dest == "" {
+		return config.Host{}, false
+	}
+
+	destUser, hostname, destPort := splitDestination(dest)
+	if user == "" {
+		user = destUser
+	}
+	if port == "" {
+		port = destPort
+	}
 	if port == "" {
 		port = "22"
 	}
 
-	// Auto-append to config if not already known
-	configPath := platform.SSHConfigPath()
-	if configOverride != "" {
-		configPath = configOverride
+	if identity != "" {
+		if abs, err := filepath.Abs(identity); err == nil {
+			identity = abs
+		}
+	}
+	return config.Host{
+		Alias:        config.GenerateAlias(user, hostname),
+		Hostname:     hostname,
+		User:         user,
+		Port:         port,
+		IdentityFile: identity,
+	}, true
+}
+
+// splitDestination splits a passthrough destination, [user@]host or
+// ssh://[user@]host[:port], into its parts. Brackets around an IPv6 literal
+// are dropped, and a port may follow them: "user@[2001:db8::1]:2222" gives
+// hostname "2001:db8::1" and port "2222". An unbracketed address with several
+// colons is taken as a hostname without a port.
+func splitDestination(dest string) (user, hostname, port string) {
+	rest, isURI := strings.CutPrefix(dest, "ssh://")
+	if isURI {
+		rest = strings.TrimSuffix(rest, "/")
+	}
+	if idx := strings.Index(rest, "@"); idx >= 0 {
+		user, rest = rest[:idx], rest[idx+1:]
 	}
-	backupPath := filepath.Join(filepath.Dir(configPath), "config.bak")
+	hostname = rest
+	if end := strings.Index(rest, "]"); strings.HasPrefix(rest, "[") && end > 0 {
+		hostname = rest[1:end]
+		port = strings.TrimPrefix(rest[end+1:], ":")
+	} else if isURI && strings.Count(rest, ":") == 1 {
+		hostname, port, _ = strings.Cut(rest, ":")
+	}
+	return user, hostname, port
+}
+
+// autoSaveHost appends h to the config at configPath unless its hostname is
+// already known. If confirm is non-nil it is shown h first and may edit it or
+// decline saving. Returns the host saved, or a zero Host if nothing was written.
+func autoSaveHost(configPath string, h config.Host, confirm func(config.Host) (config.Host, bool, error)) (config.Host, error) {
 	hosts, _ := config.Parse(configPath)
-	if !config.IsKnownHost(hosts, hostname) {
-		alias := hostname
-		if user != "" {
-			alias = user + "-" + hostname
-		}
-		absIdentity := identity
-		if identity != "" {
-			if abs, err := filepath.Abs(identity); err == nil {
-				absIdentity = abs
-			}
-		}
-		h := config.Host{
-			Alias:        alias,
-			Hostname:     hostname,
-			User:         user,
-			Port:         port,
-			IdentityFile: absIdentity,
-		}
-		if err := config.AppendHost(configPath, backupPath, h); err != nil {
-			fmt.Fprintf(os.Stderr, "sssh: warning: could not save host to config: %v\n", err)
-		} else {
-			fmt.Fprintf(os.Stderr, "sssh: saved '%s' to SSH config\n", alias)
+	if config.IsKnownHost(hosts, h.Hostname) {
+		return config.Host{}, nil
+	}
+	if confirm != nil {
+		edited, ok, err := confirm(h)
+		if err != nil || !ok {
+			return config.Host{}, err
 		}
+		h = edited
+	}
+	if err := config.AppendHost(configPath, config.BackupPath(configPath), h); err != nil {
+		return config.Host{}, err
 	}
+	return h, nil
+}
 
-	// Hand off to ssh with the original arguments unchanged
-	cmd := exec.Command("ssh", args...)
-	cmd.Stdin = os.Stdin
-	cmd.Stdout = os.Stdout
-	cmd.Stderr = os.Stderr
-	if err := cmd.Run(); err != nil {
-		os.Exit(1)
+// retarget rewrites passthrough args to binary so they connect to h: the
+// port, user and identity options are replaced by h's and the destination
+// operand points at h's user and hostname. An scp/sftp path is kept.
+func retarget(binary string, args []string, h config.Host) []string {
+	tool := passthroughTools[binary]
+	target := h.Hostname
+	if tool.hostPath && strings.Contains(target, ":") {
+		target = "[" + target + "]"
+	}
+	if h.User != "" {
+		target = h.User + "@" + target
+	}
+
+	var opts []string
+	if h.Port != "" && h.Port != "22" {
+		opts = append(opts, tool.portOpt, h.Port)
+	}
+	if h.IdentityFile != "" {
+		opts = append(opts, "-i", h.IdentityFile)
+	}
+
+	var rest []string
+	replaced := false
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		switch {
+		case (arg == tool.portOpt || arg == "-i" || tool.userOpt != "" && arg == tool.userOpt) && i+1 < len(args):
+			i++ // replaced by h's values
+			continue
+		case tool.optWithValue[arg] && i+1 < len(args):
+			rest = append(rest, arg, args[i+1])
+			i++
+			continue
+		case !strings.HasPrefix(arg, "-") && !replaced:
+			if !tool.hostPath {
+				arg, replaced = target, true
+			} else if host, ok := splitRemote(arg); ok {
+				arg, replaced = target+arg[len(host):], true
+			} else if !tool.remoteOnly {
+				arg, replaced = target, true
+			}
+		}
+		rest = append(rest, arg)
 	}
+	return append(opts, rest...)
 }
 
+// nativeFlags maps the TUI's flags to whether they take a value.
+// looksLikeSSHArgs skips them with their values, so
+// "--config ~/me@work/config" stays native.
+var nativeFlags = flagArity(defineTUIFlags)
+
 // looksLikeSSHArgs reports whether args appear to be an SSH passthrough
 // invocation rather than sssh-native flags. It returns true when any
 // argument contains "@" (user@host) or is a recognized SSH option flag.
+// sssh's own flags and their values are not considered.
 func looksLikeSSHArgs(args []string) bool {
 	sshFlags := map[string]bool{
 		"-i": true, "-p": true, "-l": true, "-b": true, "-c": true,
@@ -167,7 +538,17 @@ func looksLikeSSHArgs(args []string) bool {
 		"-T": true, "-t": true, "-V": true, "-X": true, "-x": true,
 		"-Y": true, "-y": true,
 	}
-	for _, arg := range args {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if strings.HasPrefix(arg, "-") {
+			name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+			if takesValue, ok := nativeFlags[name]; ok {
+				if takesValue && !hasValue {
+					i++ // the value is the next argument
+				}
+				continue
+			}
+		}
 		if strings.Contains(arg, "@") {
 			return true
 		}
@@ -178,46 +559,115 @@ func looksLikeSSHArgs(args []string) bool {
 	return false
 }
 
-// parseSSHTarget scans SSH-style arguments and extracts the destination,
-// port (-p), user (-l), and identity (-i). The destination is the first
-// positional argument (not preceded by an option that takes a value).
-func parseSSHTarget(args []string) (dest, port, user, identity string) {
-	// SSH options that consume the next argument as their value
-	optWithValue := map[string]bool{
-		"-b": true, "-c": true, "-D": true, "-E": true, "-e": true,
-		"-F": true, "-I": true, "-i": true, "-J": true, "-L": true,
-		"-l": true, "-m": true, "-o": true, "-p": true, "-Q": true,
-		"-R": true, "-S": true, "-w": true, "-W": true,
+// passthroughTool describes the command line of a binary sssh can wrap.
+type passthroughTool struct {
+	optWithValue map[string]bool // options that consume the next argument as their value
+	portOpt      string          // option that sets the port
+	userOpt      string          // option that sets the login user; "" if there is none
+	hostPath     bool            // operands may be [user@]host:path; the path is dropped
+	remoteOnly   bool            // the destination is the first operand with a host: part
+}
+
+// passthroughTools maps each binary passthrough can hand off to its syntax.
+var passthroughTools = map[string]passthroughTool{
+	"ssh": {
+		optWithValue: map[string]bool{
+			"-b": true, "-c": true, "-D": true, "-E": true, "-e": true,
+			"-F": true, "-I": true, "-i": true, "-J": true, "-L": true,
+			"-l": true, "-m": true, "-o": true, "-p": true, "-Q": true,
+			"-R": true, "-S": true, "-w": true, "-W": true,
+		},
+		portOpt: "-p",
+		userOpt: "-l",
+	},
+	"scp": {
+		optWithValue: map[string]bool{
+			"-c": true, "-D": true, "-F": true, "-i": true, "-J": true,
+			"-l": true, "-o": true, "-P": true, "-S": true, "-X": true,
+		},
+		portOpt:    "-P",
+		hostPath:   true,
+		remoteOnly: true,
+	},
+	"sftp": {
+		optWithValue: map[string]bool{
+			"-B": true, "-b": true, "-c": true, "-D": true, "-F": true,
+			"-i": true, "-J": true, "-l": true, "-o": true, "-P": true,
+			"-R": true, "-S": true, "-s": true, "-X": true,
+		},
+		portOpt:  "-P",
+		hostPath: true,
+	},
+}
+
+// toolSubcommandFlags lists, for the subcommands named after a binary
+// passthrough can wrap, the flags the subcommand itself defines and whether
+// each takes a value. Any other option sends the call to the real binary.
+var toolSubcommandFlags = map[string]map[string]bool{
+	"scp":  flagArity(defineSCPFlags),
+	"sftp": flagArity(defineSFTPFlags),
+}
+
+// looksLikeToolArgs reports whether args to "sssh scp" or "sssh sftp" are
+// meant for the real binary rather than the subcommand: an argument contains
+// "@" or is an option not in native, which maps the subcommand's own flags to
+// whether they take a value.
+func looksLikeToolArgs(native map[string]bool, args []string) bool {
+	for i := 0; i < len(args); i++ {
+		arg := args[i]
+		if strings.HasPrefix(arg, "-") {
+			name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+			takesValue, ok := native[name]
+			if !ok {
+				return true
+			}
+			if takesValue && !hasValue {
+				i++ // the value is the next argument
+			}
+			continue
+		}
+		if strings.Contains(arg, "@") {
+			return true
+		}
 	}
+	return false
+}
 
+// parseSSHTarget scans the arguments of binary (ssh, scp, or sftp) and
+// extracts the destination, port (-p, or -P for scp and sftp), user (-l for
+// ssh), and identity (-i). The destination is the first positional argument
+// (not preceded by an option that takes a value) without any ":path"; for scp
+// it is the first one written [user@]host:path, so "scp file user@host:/tmp"
+// yields "user@host".
+func parseSSHTarget(binary string, args []string) (dest, port, user, identity string) {
+	tool := passthroughTools[binary]
 	i := 0
 	for i < len(args) {
 		arg := args[i]
-		switch arg {
-		case "-p":
-			if i+1 < len(args) {
-				port = args[i+1]
-				i += 2
-				continue
-			}
-		case "-l":
-			if i+1 < len(args) {
-				user = args[i+1]
-				i += 2
-				continue
-			}
-		case "-i":
-			if i+1 < len(args) {
-				identity = args[i+1]
-				i += 2
-				continue
-			}
-		default:
-			if optWithValue[arg] && i+1 < len(args) {
-				i += 2 // skip option + value we don't care about
-				continue
+		switch {
+		case arg == tool.portOpt && i+1 < len(args):
+			port = args[i+1]
+			i += 2
+			continue
+		case tool.userOpt != "" && arg == tool.userOpt && i+1 < len(args):
+			user = args[i+1]
+			i += 2
+			continue
+		case arg == "-i" && i+1 < len(args):
+			identity = args[i+1]
+			i += 2
+			continue
+		case tool.optWithValue[arg] && i+1 < len(args):
+			i += 2 // skip option + value we don't care about
+			continue
+		case !strings.HasPrefix(arg, "-") && dest == "":
+			if !tool.hostPath {
+				dest = arg
+				break
 			}
-			if !strings.HasPrefix(arg, "-") && dest == "" {
+			if host, ok := splitRemote(arg); ok {
+				dest = host
+			} else if !tool.remoteOnly {
 				dest = arg
 			}
 		}
@@ -225,3 +675,22 @@ func parseSSHTarget(args []string) (dest, port, user, identity string) {
 	}
 	return
 }
+
+// splitRemote splits an scp/sftp operand written [user@]host:path and returns
+// its [user@]host part, keeping the brackets of an IPv6 literal
+// ("user@[2001:db8::1]:/tmp" gives "user@[2001:db8::1]"). ok is false for a
+// local path: one with no ":" or with a "/" before it.
+func splitRemote(arg string) (host string, ok bool) {
+	if i := strings.Index(arg, "["); i >= 0 && (i == 0 || arg[i-1] == '@') && !strings.Contains(arg[:i], "/") {
+		end := strings.Index(arg[i:], "]")
+		if end < 0 || !strings.HasPrefix(arg[i+end+1:], ":") {
+			return "", false
+		}
+		return arg[:i+end+1], true
+	}
+	host, _, found := strings.Cut(arg, ":")
+	if !found || host == "" || strings.Contains(host, "/") {
+		return "", false
+	}
+	return host, true
+}
@@ -1,6 +1,15 @@
 package main
 
-import "testing"
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/srava/swiftssh/internal/config"
+	"github.com/srava/swiftssh/internal/tui"
+)
 
 func TestExtractConfigFlag(t *testing.T) {
 	tests := []struct {
@@ -21,3 +30,341 @@ func TestExtractConfigFlag(t *testing.T) {
 		}
 	}
 }
+
+func TestLooksLikeSSHArgs(t *testing.T) {
+	tests := []struct {
+		args []string
+		want bool
+	}{
+		{[]string{"user@host"}, true},
+		{[]string{"-p", "2222", "host"}, true},
+		{[]string{"-i", "~/.ssh/id_work", "deploy@10.0.0.5"}, true},
+		{[]string{"--config", "/home/me@corp/.ssh/config"}, false},
+		{[]string{"--config=/home/me@corp/.ssh/config"}, false},
+		{[]string{"-config", "a@b", "--no-frequent"}, false},
+		{[]string{"--hide", "*@legacy*", "--sort", "alpha"}, false},
+		{[]string{"--version"}, false},
+		{[]string{"-v"}, false},
+		{[]string{}, false},
+		{[]string{"--config", "a@b", "user@host"}, true},
+		{[]string{"--config", "a@b", "-p", "2222", "host"}, true},
+		{[]string{"--no-frequent", "root@db"}, true},
+	}
+	for _, tc := range tests {
+		if got := looksLikeSSHArgs(tc.args); got != tc.want {
+			t.Errorf("looksLikeSSHArgs(%q) = %v; want %v", tc.args, got, tc.want)
+		}
+	}
+}
+
+func TestLooksLikeToolArgs(t *testing.T) {
+	tests := []struct {
+		tool string
+		args []string
+		want bool
+	}{
+		{"scp", []string{"localfile", "user@host:/remote"}, true},
+		{"scp", []string{"-P", "2222", "localfile", "host:/remote"}, true},
+		{"scp", []string{"-r", "dist", "web:/srv/"}, false},
+		{"scp", []string{"--config", "/home/me@corp/.ssh/config", "web:a", "."}, false},
+		{"scp", []string{"--state=/tmp/a@b.json", "web:a", "."}, false},
+		{"scp", []string{"--config", "a@b", "file", "deploy@10.0.0.5:"}, true},
+		{"sftp", []string{"web"}, false},
+		{"sftp", []string{"deploy@10.0.0.5"}, true},
+		{"sftp", []string{"-P", "2222", "10.0.0.5"}, true},
+		{"sftp", []string{"-r", "web"}, true},
+		{"sftp", []string{}, false},
+	}
+	for _, tc := range tests {
+		if got := looksLikeToolArgs(toolSubcommandFlags[tc.tool], tc.args); got != tc.want {
+			t.Errorf("looksLikeToolArgs(%s, %q) = %v; want %v", tc.tool, tc.args, got, tc.want)
+		}
+	}
+}
+
+// TestFlagArity verifies that the passthrough checks see every flag the TUI
+// and the scp subcommand define, with the right arity.
+func TestFlagArity(t *testing.T) {
+	for name, want := range map[string]bool{"config": true, "hide": true, "limit": true, "no-history": false, "v": false} {
+		if got, ok := nativeFlags[name]; !ok || got != want {
+			t.Errorf("nativeFlags[%q] = %v, %v; want %v", name, got, ok, want)
+		}
+	}
+	scp := toolSubcommandFlags["scp"]
+	if len(scp) != 4 || !scp["config"] || !scp["state"] || scp["r"] || scp["no-history"] {
+		t.Errorf("scp flags = %v", scp)
+	}
+}
+
+func TestParseSSHTarget(t *testing.T) {
+	tests := []struct {
+		binary                         string
+		args                           []string
+		dest, port, user, identityFile string
+	}{
+		{"ssh", []string{"-p", "2222", "-l", "deploy", "-i", "key", "host"}, "host", "2222", "deploy", "key"},
+		{"ssh", []string{"-o", "BatchMode=yes", "user@host", "uptime"}, "user@host", "", "", ""},
+		{"scp", []string{"localfile", "user@host:/remote"}, "user@host", "", "", ""},
+		{"scp", []string{"-P", "2222", "-i", "key", "host:/var/log/app.log", "."}, "host", "2222", "", "key"},
+		{"scp", []string{"./a:b", "-l", "100", "host:"}, "host", "", "", ""},
+		{"scp", []string{"a", "b"}, "", "", "", ""},
+		{"sftp", []string{"-P", "2222", "deploy@10.0.0.5:/srv"}, "deploy@10.0.0.5", "2222", "", ""},
+		{"sftp", []string{"-l", "100", "host"}, "host", "", "", ""},
+		{"ssh", []string{"-p", "2222", "root@[2001:db8::1]"}, "root@[2001:db8::1]", "2222", "", ""},
+		{"scp", []string{"f", "root@[2001:db8::1]:/tmp/"}, "root@[2001:db8::1]", "", "", ""},
+		{"scp", []string{"[fe80::1]:a", "."}, "[fe80::1]", "", "", ""},
+		{"scp", []string{"[fe80::1]", "."}, "", "", "", ""},
+		{"sftp", []string{"-P", "2200", "[2001:db8::1]"}, "[2001:db8::1]", "2200", "", ""},
+	}
+	for _, tc := range tests {
+		dest, port, user, identity := parseSSHTarget(tc.binary, tc.args)
+		if dest != tc.dest || port != tc.port || user != tc.user || identity != tc.identityFile {
+			t.Errorf("parseSSHTarget(%s, %q) = (%q, %q, %q, %q); want (%q, %q, %q, %q)",
+				tc.binary, tc.args, dest, port, user, identity, tc.dest, tc.port, tc.user, tc.identityFile)
+		}
+	}
+}
+
+func TestEnvBool(t *testing.T) {
+	tests := []struct {
+		value string
+		want  bool
+	}{
+		{"", false},
+		{"1", true},
+		{"true", true},
+		{"yes", true},
+		{"0", false},
+		{"false", false},
+		{"FALSE", false},
+		{"no", false},
+		{"Off", false},
+		{"on", true},
+	}
+	for _, tc := range tests {
+		t.Setenv("SWIFTSSH_TEST_BOOL", tc.value)
+		if got := envBool("SWIFTSSH_TEST_BOOL"); got != tc.want {
+			t.Errorf("envBool with %q = %v; want %v", tc.value, got, tc.want)
+		}
+	}
+}
+
+func TestSynthesizeHost(t *testing.T) {
+	h, ok := synthesizeHost("ssh", []string{"-p", "2222", "deploy@10.0.0.5"})
+	if !ok {
+		t.Fatal("synthesizeHost returned false")
+	}
+	want := config.Host{Alias: "deploy-10.0.0.5", Hostname: "10.0.0.5", User: "deploy", Port: "2222"}
+	if h.Alias != want.Alias || h.Hostname != want.Hostname || h.User != want.User || h.Port != want.Port {
+		t.Errorf("synthesizeHost = %+v; want %+v", h, want)
+	}
+
+	h, ok = synthesizeHost("scp", []string{"-P", "2222", "notes.txt", "deploy@10.0.0.5:/tmp/"})
+	if !ok || h.Alias != want.Alias || h.Hostname != want.Hostname || h.User != want.User || h.Port != want.Port {
+		t.Errorf("synthesizeHost(scp) = %+v, %v; want %+v", h, ok, want)
+	}
+
+	if _, ok := synthesizeHost("ssh", []string{"-v"}); ok {
+		t.Error("synthesizeHost without a destination should return false")
+	}
+}
+
+func TestSynthesizeHost_IPv6(t *testing.T) {
+	tests := []struct {
+		binary string
+		args   []string
+		want   config.Host
+	}{
+		{"ssh", []string{"root@[2001:db8::1]"}, config.Host{Alias: "root-2001-db8--1", Hostname: "2001:db8::1", User: "root", Port: "22"}},
+		{"ssh", []string{"-p", "2222", "root@[2001:db8::1]"}, config.Host{Alias: "root-2001-db8--1", Hostname: "2001:db8::1", User: "root", Port: "2222"}},
+		{"ssh", []string{"ssh://root@[2001:db8::1]:2200"}, config.Host{Alias: "root-2001-db8--1", Hostname: "2001:db8::1", User: "root", Port: "2200"}},
+		{"ssh", []string{"-p", "2222", "ssh://[::1]:2200/"}, config.Host{Alias: "1", Hostname: "::1", Port: "2222"}},
+		{"ssh", []string{"ssh://deploy@web.example.com:2200"}, config.Host{Alias: "deploy-web.example.com", Hostname: "web.example.com", User: "deploy", Port: "2200"}},
+		{"ssh", []string{"fe80::1"}, config.Host{Alias: "fe80--1", Hostname: "fe80::1", Port: "22"}},
+		{"scp", []string{"-P", "2222", "f", "deploy@[2001:db8::1]:/srv/"}, config.Host{Alias: "deploy-2001-db8--1", Hostname: "2001:db8::1", User: "deploy", Port: "2222"}},
+	}
+	for _, tc := range tests {
+		h, ok := synthesizeHost(tc.binary, tc.args)
+		if !ok || h.Alias != tc.want.Alias || h.Hostname != tc.want.Hostname || h.User != tc.want.User || h.Port != tc.want.Port {
+			t.Errorf("synthesizeHost(%s, %q) = %+v, %v; want %+v", tc.binary, tc.args, h, ok, tc.want)
+		}
+	}
+
+	configPath := writeConfig(t, "Host v6\n    Hostname 2001:db8::1\n")
+	h, _ := synthesizeHost("ssh", []string{"root@[2001:db8::1]"})
+	if saved, err := autoSaveHost(configPath, h, nil); err != nil || saved.Alias != "" {
+		t.Errorf("autoSaveHost = %q, %v; want the bracketed address to be known", saved.Alias, err)
+	}
+}
+
+func TestStripFlag(t *testing.T) {
+	rest, found := stripFlag([]string{"--interactive", "user@host", "-v"}, "--interactive")
+	if !found {
+		t.Error("expected --interactive to be found")
+	}
+	if strings.Join(rest, " ") != "user@host -v" {
+		t.Errorf("rest = %v", rest)
+	}
+}
+
+func TestAutoSaveHost_ConfirmEditsHost(t *testing.T) {
+	path := writeConfig(t, "Host existing\n    Hostname 10.0.0.1\n")
+	h, _ := synthesizeHost("ssh", []string{"root@10.0.0.9"})
+
+	confirm := func(h config.Host) (config.Host, bool, error) {
+		h.Alias = "db-primary"
+		h.Groups = []string{"prod"}
+		return h, true, nil
+	}
+	saved, err := autoSaveHost(path, h, confirm)
+	if err != nil {
+		t.Fatalf("autoSaveHost: %v", err)
+	}
+	if saved.Alias != "db-primary" {
+		t.Errorf("alias = %q; want db-primary", saved.Alias)
+	}
+	data, _ := os.ReadFile(path)
+	if !strings.Contains(string(data), "Host db-primary") || !strings.Contains(string(data), "prod") {
+		t.Errorf("edited host not written:\n%s", data)
+	}
+}
+
+func TestAutoSaveHost_CancelledSavesNothing(t *testing.T) {
+	path := writeConfig(t, "Host existing\n    Hostname 10.0.0.1\n")
+	cancel := func(h config.Host) (config.Host, bool, error) { return h, false, tui.ErrCancelled }
+
+	h, _ := synthesizeHost("ssh", []string{"root@10.0.0.9"})
+	if _, err := autoSaveHost(path, h, cancel); !errors.Is(err, tui.ErrCancelled) {
+		t.Errorf("err = %v; want ErrCancelled", err)
+	}
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "10.0.0.9") {
+		t.Errorf("cancelled host was written:\n%s", data)
+	}
+}
+
+// TestRetarget tests that passthrough args are rewritten to the host confirmed
+// in the --interactive form, keeping unrelated options and scp paths.
+func TestRetarget(t *testing.T) {
+	h := config.Host{Hostname: "10.0.0.9", User: "admin", Port: "2222", IdentityFile: "/keys/id"}
+	tests := []struct {
+		binary string
+		args   []string
+		want   string
+	}{
+		{"ssh", []string{"-p", "22", "-L", "80:x:80", "root@10.0.0.9", "uptime"}, "-p 2222 -i /keys/id -L 80:x:80 admin@10.0.0.9 uptime"},
+		{"ssh", []string{"-l", "root", "-i", "old", "10.0.0.9"}, "-p 2222 -i /keys/id admin@10.0.0.9"},
+		{"scp", []string{"-P", "22", "file.txt", "root@10.0.0.9:/tmp/"}, "-P 2222 -i /keys/id file.txt admin@10.0.0.9:/tmp/"},
+		{"sftp", []string{"root@10.0.0.9"}, "-P 2222 -i /keys/id admin@10.0.0.9"},
+	}
+	for _, tt := range tests {
+		got := strings.Join(retarget(tt.binary, tt.args, h), " ")
+		if got != tt.want {
+			t.Errorf("retarget(%s, %v) = %q; want %q", tt.binary, tt.args, got, tt.want)
+		}
+	}
+
+	v6 := config.Host{Hostname: "2001:db8::1", Port: "22"}
+	if got := strings.Join(retarget("scp", []string{"[2001:db8::1]:/tmp/x", "."}, v6), " "); got != "[2001:db8::1]:/tmp/x ." {
+		t.Errorf("IPv6 scp retarget = %q", got)
+	}
+}
+
+func TestAutoSaveHost_DeclinedOrKnown(t *testing.T) {
+	path := writeConfig(t, "Host existing\n    Hostname 10.0.0.1\n")
+	decline := func(h config.Host) (config.Host, bool, error) { return h, false, nil }
+
+	h, _ := synthesizeHost("ssh", []string{"root@10.0.0.9"})
+	if saved, err := autoSaveHost(path, h, decline); err != nil || saved.Alias != "" {
+		t.Errorf("declined save = (%q, %v); want nothing saved", saved.Alias, err)
+	}
+
+	known, _ := synthesizeHost("ssh", []string{"root@10.0.0.1"})
+	if saved, _ := autoSaveHost(path, known, nil); saved.Alias != "" {
+		t.Errorf("known host was saved as %q", saved.Alias)
+	}
+
+	data, _ := os.ReadFile(path)
+	if strings.Contains(string(data), "10.0.0.9") {
+		t.Errorf("declined host was written:\n%s", data)
+	}
+}
+
+// TestAutoSaveHost_RotatesBackupNextToConfig verifies auto-saved hosts are
+// backed up to <config>.bak with the same rotation as edits, even when the
+// config file is not named "config".
+func TestAutoSaveHost_RotatesBackupNextToConfig(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "work_config")
+	if err := os.WriteFile(path, []byte("Host existing\n    Hostname 10.0.0.1\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	for _, dest := range []string{"root@10.0.0.8", "root@10.0.0.9"} {
+		h, _ := synthesizeHost("ssh", []string{dest})
+		if _, err := autoSaveHost(path, h, nil); err != nil {
+			t.Fatalf("autoSaveHost(%s): %v", dest, err)
+		}
+	}
+
+	bak, _ := os.ReadFile(path + ".bak")
+	bak1, _ := os.ReadFile(path + ".bak.1")
+	if !strings.Contains(string(bak), "10.0.0.8") {
+		t.Errorf(".bak should hold the config before the second save, got %q", bak)
+	}
+	if !strings.Contains(string(bak1), "existing") || strings.Contains(string(bak1), "10.0.0.8") {
+		t.Errorf(".bak.1 should hold the original config, got %q", bak1)
+	}
+	if _, err := os.Stat(filepath.Join(filepath.Dir(path), "config.bak")); !os.IsNotExist(err) {
+		t.Errorf("expected no config.bak next to %s", path)
+	}
+}
+
+func TestAutoSaveHost_CaseVariantIsKnown(t *testing.T) {
+	path := writeConfig(t, "Host web\n    Hostname web.example.com\n")
+
+	h, _ := synthesizeHost("ssh", []string{"deploy@Web.Example.COM"})
+	if saved, err := autoSaveHost(path, h, nil); err != nil || saved.Alias != "" {
+		t.Errorf("case variant saved as (%q, %v); want nothing saved", saved.Alias, err)
+	}
+
+	data, _ := os.ReadFile(path)
+	if strings.Count(string(data), "Host ") != 1 {
+		t.Errorf("duplicate host written:\n%s", data)
+	}
+}
+
+func TestCustomConfigPath(t *testing.T) {
+	if got := customConfigPath("/tmp/other"); got != "/tmp/other" {
+		t.Errorf("customConfigPath(/tmp/other) = %q; want /tmp/other", got)
+	}
+	if got := customConfigPath(resolveConfigPath("")); got != "" {
+		t.Errorf("customConfigPath(default) = %q; want empty", got)
+	}
+}
+
+func TestResolveConfigPath_EnvOverride(t *testing.T) {
+	env := filepath.Join(t.TempDir(), "ssh_config")
+	t.Setenv("SWIFTSSH_CONFIG", env)
+
+	if got := resolveConfigPath(""); got != env {
+		t.Errorf("resolveConfigPath() = %q; want $SWIFTSSH_CONFIG %q", got, env)
+	}
+	if got := resolveConfigPath("/tmp/flag"); got != "/tmp/flag" {
+		t.Errorf("--config should win over $SWIFTSSH_CONFIG, got %q", got)
+	}
+	if got := customConfigPath(resolveConfigPath("")); got != env {
+		t.Errorf("ssh needs -F for $SWIFTSSH_CONFIG, got %q", got)
+	}
+}
+
+func TestProgramOptions(t *testing.T) {
+	if got := len(programOptions(false)); got != 1 {
+		t.Errorf("default should use the alternate screen, got %d options", got)
+	}
+	if got := len(programOptions(true)); got != 0 {
+		t.Errorf("--inline should omit the alternate screen, got %d options", got)
+	}
+	if looksLikeSSHArgs([]string{"--inline"}) {
+		t.Error("--inline should be handled as a native flag, not ssh passthrough")
+	}
+}
@@ -0,0 +1,80 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/srava/swiftssh/internal/config"
+	"github.com/srava/swiftssh/internal/platform"
+	"github.com/srava/swiftssh/internal/state"
+)
+
+// runOrder implements "sssh order": it prints hosts in the exact order the TUI
+// would list them. With --explain, each host is shown with the signals used to
+// rank it, which makes sort bugs reproducible from a bug report.
+func runOrder(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("order", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configFlag := fs.String("config", "", "Path to SSH config file")
+	stateFlag := fs.String("state", "", "Path to state file (default: platform state path)")
+	noFrequent := fs.Bool("no-frequent", false, "Flat alphabetical order (skip frequency sort)")
+	sortMode := fs.String("sort", state.SortFrequency, "Host order: 'frequency', 'alpha', 'group', or 'recent'")
+	explain := fs.Bool("explain", false, "Show the ranking signals for each host")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if !validSortMode(*sortMode) {
+		fmt.Fprintf(stderr, "sssh: --sort must be %q, %q, %q, or %q, got %q\n",
+			state.SortFrequency, state.SortAlpha, state.SortGroup, state.SortRecent, *sortMode)
+		return 2
+	}
+	if *noFrequent && (*sortMode == state.SortFrequency || *sortMode == state.SortRecent) {
+		*sortMode = state.SortAlpha
+	}
+
+	hosts, err := config.Parse(resolveConfigPath(*configFlag))
+	if err != nil {
+		fmt.Fprintf(stderr, "sssh: could not parse SSH config: %v\n", err)
+		return 2
+	}
+
+	statePath := *stateFlag
+	if statePath == "" {
+		statePath = platform.StateFilePath()
+	}
+	st := loadState(statePath)
+
+	ranking := st
+	if *noFrequent {
+		ranking = nil
+	}
+	ordered := state.OrderHostsBy(hosts, ranking, *sortMode)
+	if !*explain {
+		for _, h := range ordered {
+			fmt.Fprintln(stdout, h.Alias)
+		}
+		return 0
+	}
+
+	tw := tabwriter.NewWriter(stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "RANK\tALIAS\tCONNECTIONS\tSEGMENT\tSOURCE")
+	for i, h := range ordered {
+		count := st.Connections[h.Alias]
+		segment := "alphabetical"
+		switch {
+		case *sortMode == state.SortGroup && len(h.Groups) > 0:
+			segment = "group " + h.Groups[0]
+		case *sortMode == state.SortGroup:
+			segment = "ungrouped"
+		case *sortMode == state.SortFrequency && count > 0:
+			segment = "frequent"
+		case *sortMode == state.SortRecent && st.LastConnected[h.Alias] > 0:
+			segment = "recent"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%s\t%s:%d\n", i+1, h.Alias, count, segment, h.SourceFile, h.LineStart)
+	}
+	tw.Flush()
+	return 0
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/srava/swiftssh/internal/config"
+	"github.com/srava/swiftssh/internal/state"
+)
+
+func TestRunOrder_ExplainMatchesOrderHosts(t *testing.T) {
+	configPath := writeConfig(t, "Host charlie\n  Hostname c\nHost alpha\n  Hostname a\nHost bravo\n  Hostname b\n")
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	st := &state.State{Connections: map[string]int{"charlie": 4, "bravo": 1}}
+	if err := state.Save(statePath, st); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := runOrder([]string{"--config", configPath, "--state", statePath, "--explain"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr.String())
+	}
+
+	hosts, _ := config.Parse(configPath)
+	want := state.OrderHosts(hosts, st, false)
+
+	lines := strings.Split(strings.TrimSpace(stdout.String()), "\n")
+	if len(lines) != len(want)+1 {
+		t.Fatalf("expected header + %d rows, got:\n%s", len(want), stdout.String())
+	}
+	for i, h := range want {
+		fields := strings.Fields(lines[i+1])
+		if fields[1] != h.Alias {
+			t.Errorf("row %d: expected alias %q, got %q", i+1, h.Alias, fields[1])
+		}
+	}
+	if !strings.Contains(lines[1], "charlie") || !strings.Contains(lines[1], "frequent") {
+		t.Errorf("expected charlie ranked first as frequent, got %q", lines[1])
+	}
+}
+
+func TestRunOrder_PlainListsAliases(t *testing.T) {
+	configPath := writeConfig(t, "Host b\n  Hostname b\nHost a\n  Hostname a\n")
+	var stdout, stderr bytes.Buffer
+	runOrder([]string{"--config", configPath, "--state", filepath.Join(t.TempDir(), "s.json")}, &stdout, &stderr)
+	if got := stdout.String(); got != "a\nb\n" {
+		t.Errorf("expected \"a\\nb\\n\", got %q", got)
+	}
+}
+
+func TestRunOrder_SortGroup(t *testing.T) {
+	configPath := writeConfig(t, "Host solo\n  Hostname s\n# @group Work\nHost web\n  Hostname w\n# @group Home\nHost pi\n  Hostname p\n# @group Work\nHost db\n  Hostname d\n")
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	if err := state.Save(statePath, &state.State{Connections: map[string]int{"web": 3, "solo": 8}}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := runOrder([]string{"--config", configPath, "--state", statePath, "--sort", "group"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr.String())
+	}
+	if got, want := stdout.String(), "pi\nweb\ndb\nsolo\n"; got != want {
+		t.Errorf("expected %q, got %q", want, got)
+	}
+}
+
+func TestRunOrder_RejectsUnknownSort(t *testing.T) {
+	configPath := writeConfig(t, "Host a\n  Hostname a\n")
+	var stdout, stderr bytes.Buffer
+	if code := runOrder([]string{"--config", configPath, "--sort", "random"}, &stdout, &stderr); code != 2 {
+		t.Errorf("expected exit 2, got %d", code)
+	}
+}
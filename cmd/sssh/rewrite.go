@@ -0,0 +1,102 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+
+	"github.com/srava/swiftssh/internal/config"
+)
+
+// rewriteField reads and writes the values of one Host field. Most fields
+// hold a single value; identityfile holds every IdentityFile of the host.
+type rewriteField struct {
+	get func(h config.Host) []string
+	set func(h *config.Host, values []string)
+}
+
+// rewriteFields maps a --field name to accessors for that Host field.
+var rewriteFields = map[string]rewriteField{
+	"hostname": {
+		get: func(h config.Host) []string { return []string{h.Hostname} },
+		set: func(h *config.Host, v []string) { h.Hostname = v[0] },
+	},
+	"user": {
+		get: func(h config.Host) []string { return []string{h.User} },
+		set: func(h *config.Host, v []string) { h.User = v[0] },
+	},
+	"identityfile": {
+		get: config.Host.Identities,
+		set: func(h *config.Host, v []string) { h.IdentityFile, h.IdentityFiles = v[0], v },
+	},
+}
+
+// runRewrite implements "sssh rewrite": a find-and-replace of --from with --to
+// in one field of every matching host, e.g. moving hostnames to a new domain.
+// Each changed file is backed up once and written once. --dry-run prints the
+// changes without writing.
+func runRewrite(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("rewrite", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	configFlag := fs.String("config", "", "Path to SSH config file")
+	field := fs.String("field", "hostname", "Field to rewrite: hostname, user, or identityfile")
+	from := fs.String("from", "", "Text to replace (required)")
+	to := fs.String("to", "", "Replacement text")
+	group := fs.String("group", "", "Only rewrite hosts in this group (case-insensitive)")
+	dryRun := fs.Bool("dry-run", false, "Print the changes without writing them")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	f, ok := rewriteFields[strings.ToLower(*field)]
+	if !ok || *from == "" {
+		fmt.Fprintln(stderr, "sssh rewrite: --from is required and --field must be hostname, user, or identityfile")
+		return 2
+	}
+
+	hosts, err := config.Parse(resolveConfigPath(*configFlag))
+	if err != nil {
+		fmt.Fprintf(stderr, "sssh: could not parse SSH config: %v\n", err)
+		return 2
+	}
+
+	var changed []config.Host
+	for _, h := range hosts {
+		if *group != "" && !h.InGroup(*group) {
+			continue
+		}
+		values := f.get(h)
+		if !slices.ContainsFunc(values, func(v string) bool { return strings.Contains(v, *from) }) {
+			continue
+		}
+		if h.ReadOnly || h.LineStart == 0 {
+			fmt.Fprintf(stderr, "sssh rewrite: skipping %s: cannot be edited\n", h.Alias)
+			continue
+		}
+		updated := make([]string, len(values))
+		for i, value := range values {
+			updated[i] = strings.ReplaceAll(value, *from, *to)
+			if updated[i] != value {
+				fmt.Fprintf(stdout, "%s: %s -> %s\n", h.Alias, value, updated[i])
+			}
+		}
+		f.set(&h, updated)
+		changed = append(changed, h)
+	}
+
+	if len(changed) == 0 {
+		fmt.Fprintln(stdout, "No hosts matched.")
+		return 0
+	}
+	if *dryRun {
+		fmt.Fprintf(stdout, "Dry run: %d host(s) would change.\n", len(changed))
+		return 0
+	}
+	if _, err := config.ReplaceHostBlocks(changed); err != nil {
+		fmt.Fprintf(stderr, "sssh rewrite: %v\n", err)
+		return 1
+	}
+	fmt.Fprintf(stdout, "Rewrote %d host(s).\n", len(changed))
+	return 0
+}
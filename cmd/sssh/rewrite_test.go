@@ -0,0 +1,94 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/srava/swiftssh/internal/config"
+)
+
+const rewriteConfig = "# @group Work\nHost api\n    Hostname api.old.example.com\n\nHost db\n    Hostname db.old.example.com\n    Port 5432\n\nHost pi\n    Hostname 192.168.1.2\n"
+
+func TestRunRewrite_DryRunWritesNothing(t *testing.T) {
+	path := writeConfig(t, rewriteConfig)
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"--config", path, "--from", "old.example.com", "--to", "new.example.com", "--dry-run"}
+	if code := runRewrite(args, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr.String())
+	}
+	if !strings.Contains(stdout.String(), "api: api.old.example.com -> api.new.example.com") {
+		t.Errorf("expected preview line, got:\n%s", stdout.String())
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != rewriteConfig {
+		t.Errorf("dry run modified the config:\n%s", data)
+	}
+	if _, err := os.Stat(path + ".bak"); !os.IsNotExist(err) {
+		t.Error("dry run should not write a backup")
+	}
+}
+
+func TestRunRewrite_RewritesEveryMatch(t *testing.T) {
+	path := writeConfig(t, rewriteConfig)
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"--config", path, "--field", "hostname", "--from", "old.example.com", "--to", "new.example.com"}
+	if code := runRewrite(args, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr.String())
+	}
+
+	hosts, err := config.Parse(path)
+	if err != nil {
+		t.Fatalf("Parse: %v", err)
+	}
+	want := map[string]string{"api": "api.new.example.com", "db": "db.new.example.com", "pi": "192.168.1.2"}
+	for _, h := range hosts {
+		if h.Hostname != want[h.Alias] {
+			t.Errorf("%s: Hostname = %q; want %q", h.Alias, h.Hostname, want[h.Alias])
+		}
+	}
+	if hosts[1].Port != "5432" {
+		t.Errorf("db lost its Port: %q", hosts[1].Port)
+	}
+}
+
+func TestRunRewrite_GroupFilter(t *testing.T) {
+	path := writeConfig(t, rewriteConfig)
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"--config", path, "--from", "old", "--to", "new", "--group", "work"}
+	if code := runRewrite(args, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr.String())
+	}
+	hosts, _ := config.Parse(path)
+	if hosts[0].Hostname != "api.new.example.com" || hosts[1].Hostname != "db.old.example.com" {
+		t.Errorf("group filter not honored: %q, %q", hosts[0].Hostname, hosts[1].Hostname)
+	}
+}
+
+func TestRunRewrite_EveryIdentityFile(t *testing.T) {
+	path := writeConfig(t, "Host api\n    Hostname api.example.com\n    IdentityFile ~/old/id_ed25519\n    IdentityFile ~/old/id_rsa\n")
+
+	var stdout, stderr bytes.Buffer
+	args := []string{"--config", path, "--field", "identityfile", "--from", "~/old/", "--to", "~/keys/"}
+	if code := runRewrite(args, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr.String())
+	}
+	hosts, _ := config.Parse(path)
+	if got := strings.Join(hosts[0].Identities(), ","); got != "~/keys/id_ed25519,~/keys/id_rsa" {
+		t.Errorf("identities = %s; want both keys rewritten", got)
+	}
+	if !strings.Contains(stdout.String(), "api: ~/old/id_rsa -> ~/keys/id_rsa") {
+		t.Errorf("expected a line for the second key, got:\n%s", stdout.String())
+	}
+}
+
+func TestRunRewrite_Usage(t *testing.T) {
+	var stdout, stderr bytes.Buffer
+	if code := runRewrite([]string{"--field", "port", "--from", "x"}, &stdout, &stderr); code != 2 {
+		t.Errorf("expected exit 2 for an unsupported field, got %d", code)
+	}
+}
@@ -0,0 +1,94 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/srava/swiftssh/internal/config"
+	"github.com/srava/swiftssh/internal/ssh"
+)
+
+// scpRun runs scp on the terminal; tests replace it.
+var scpRun ssh.AttachedRunner = ssh.RunAttached
+
+// runSCP implements "sssh scp <alias>:<remote> <local>" and the reverse: it
+// copies a file to or from a host in the config with scp, using the host's
+// hostname, user, port, and identity, and records the transfer as a
+// connection unless --no-history or SWIFTSSH_NO_HISTORY is set. Exit codes: 0 done, 1 scp failed, 2 usage or parse error.
+func runSCP(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("scp", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	f := defineSCPFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	const usage = "usage: sssh scp [-r] [--config <path>] [--state <path>] [--no-history] <alias>:<remote> <local> | <local> <alias>:<remote>"
+	if fs.NArg() != 2 {
+		fmt.Fprintln(stderr, usage)
+		return 2
+	}
+	src, dst := fs.Arg(0), fs.Arg(1)
+
+	configPath := resolveConfigPath(*f.config)
+	hosts, err := config.Parse(configPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "sssh: could not parse SSH config: %v\n", err)
+		return 2
+	}
+	srcHost, srcRemote := remoteHost(hosts, src)
+	dstHost, dstRemote := remoteHost(hosts, dst)
+	if srcRemote == dstRemote {
+		fmt.Fprintln(stderr, "sssh scp: exactly one of the paths must be <alias>:<path> for a host in the config")
+		fmt.Fprintln(stderr, usage)
+		return 2
+	}
+	host := srcHost
+	if dstRemote {
+		host = dstHost
+	}
+
+	recordHostConnection(*f.state, host, *f.noHistory)
+
+	scpArgs := ssh.BuildSCPArgs(host, "", src, dst)
+	if *f.recursive {
+		scpArgs = append([]string{"-r"}, scpArgs...)
+	}
+	scpArgs = ssh.WithConfigFile(customConfigPath(configPath), scpArgs)
+	if err := scpRun("scp", scpArgs...); err != nil {
+		fmt.Fprintf(stderr, "sssh scp: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// scpFlags holds the flags of "sssh scp".
+type scpFlags struct {
+	config, state        *string
+	noHistory, recursive *bool
+}
+
+// defineSCPFlags defines the flags of "sssh scp" on fs. Any other option
+// makes main hand the call to the real scp.
+func defineSCPFlags(fs *flag.FlagSet) scpFlags {
+	return scpFlags{
+		config:    fs.String("config", "", "Path to SSH config file"),
+		state:     fs.String("state", "", "Path to the state file (default: the platform state path)"),
+		recursive: fs.Bool("r", false, "Copy directories recursively"),
+		noHistory: fs.Bool("no-history", envBool("SWIFTSSH_NO_HISTORY"), "Do not record the connection"),
+	}
+}
+
+// remoteHost reports whether arg is written "<alias>:path" for a host in
+// hosts, and returns that host. The longest matching alias wins, so an alias
+// that itself contains a colon (as older IPv6 entries do) is still found.
+func remoteHost(hosts []config.Host, arg string) (config.Host, bool) {
+	var found config.Host
+	for _, h := range hosts {
+		if h.Alias != "" && strings.HasPrefix(arg, h.Alias+":") && len(h.Alias) > len(found.Alias) {
+			found = h
+		}
+	}
+	return found, found.Alias != ""
+}
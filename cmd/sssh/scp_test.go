@@ -0,0 +1,87 @@
+package main
+
+import (
+	"bytes"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/srava/swiftssh/internal/state"
+)
+
+// fakeSCP replaces the scp runner for one test and records its arguments.
+func fakeSCP(t *testing.T) *[]string {
+	t.Helper()
+	var ran []string
+	old := scpRun
+	scpRun = func(name string, args ...string) error {
+		ran = append(ran, name+" "+strings.Join(args, " "))
+		return nil
+	}
+	t.Cleanup(func() { scpRun = old })
+	return &ran
+}
+
+func TestRunSCP_BothDirections(t *testing.T) {
+	configPath := writeConfig(t, "Host web\n    Hostname web.example.com\n    User deploy\n    Port 2222\n")
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	t.Setenv("SWIFTSSH_NO_HISTORY", "")
+	t.Setenv("SWIFTSSH_CONNECTION_LOG", filepath.Join(t.TempDir(), "connections.log"))
+	ran := fakeSCP(t)
+
+	var stdout, stderr bytes.Buffer
+	if code := runSCP([]string{"--config", configPath, "--state", statePath, "web:/var/log/app.log", "."}, &stdout, &stderr); code != 0 {
+		t.Fatalf("download: exit %d: %s", code, stderr.String())
+	}
+	if code := runSCP([]string{"--config", configPath, "--state", statePath, "-r", "dist", "web:/srv/"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("upload: exit %d: %s", code, stderr.String())
+	}
+
+	want := []string{
+		"scp -F " + configPath + " -P 2222 deploy@web.example.com:/var/log/app.log .",
+		"scp -F " + configPath + " -r -P 2222 dist deploy@web.example.com:/srv/",
+	}
+	if strings.Join(*ran, "\n") != strings.Join(want, "\n") {
+		t.Errorf("ran:\n%s\nwant:\n%s", strings.Join(*ran, "\n"), strings.Join(want, "\n"))
+	}
+	st, _ := state.Load(statePath)
+	if st.Connections["web"] != 2 {
+		t.Errorf("expected 2 recorded transfers, got %v", st.Connections)
+	}
+}
+
+func TestRunSCP_AliasWithColons(t *testing.T) {
+	configPath := writeConfig(t, "Host root-fe80::1\n    Hostname fe80::1\n    User root\n")
+	t.Setenv("SWIFTSSH_NO_HISTORY", "1")
+	ran := fakeSCP(t)
+
+	var stdout, stderr bytes.Buffer
+	if code := runSCP([]string{"--config", configPath, "root-fe80::1:/tmp/x", "."}, &stdout, &stderr); code != 0 {
+		t.Fatalf("exit %d: %s", code, stderr.String())
+	}
+	want := "scp -F " + configPath + " root@[fe80::1]:/tmp/x ."
+	if len(*ran) != 1 || (*ran)[0] != want {
+		t.Errorf("ran %v; want %q", *ran, want)
+	}
+}
+
+func TestRunSCP_NeedsExactlyOneRemote(t *testing.T) {
+	configPath := writeConfig(t, "Host web\n    Hostname web.example.com\n\nHost db\n    Hostname db.example.com\n")
+	t.Setenv("SWIFTSSH_NO_HISTORY", "1")
+	ran := fakeSCP(t)
+
+	for _, args := range [][]string{
+		{"a", "b"},
+		{"web:a", "db:b"},
+		{"nope:a", "b"},
+		{"web:a"},
+	} {
+		var stdout, stderr bytes.Buffer
+		if code := runSCP(append([]string{"--config", configPath}, args...), &stdout, &stderr); code != 2 {
+			t.Errorf("%v: exit %d; want 2", args, code)
+		}
+	}
+	if len(*ran) != 0 {
+		t.Errorf("scp should not run, ran %v", *ran)
+	}
+}
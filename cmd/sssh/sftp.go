@@ -0,0 +1,68 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+
+	"github.com/srava/swiftssh/internal/config"
+	"github.com/srava/swiftssh/internal/ssh"
+)
+
+// sftpRun runs sftp on the terminal; tests replace it.
+var sftpRun ssh.AttachedRunner = ssh.RunAttached
+
+// runSFTP implements "sssh sftp <alias>": it opens an sftp session to a host
+// from the config with the host's user, port, and identity, and records the
+// connection like the TUI does unless --no-history or SWIFTSSH_NO_HISTORY is
+// set. Exit codes:
+// 0 done, 1 sftp failed, 2 usage or parse error.
+func runSFTP(args []string, stdout, stderr io.Writer) int {
+	fs := flag.NewFlagSet("sftp", flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	f := defineSFTPFlags(fs)
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() != 1 {
+		fmt.Fprintln(stderr, "usage: sssh sftp [--config <path>] [--state <path>] [--no-history] <alias>")
+		return 2
+	}
+
+	configPath := resolveConfigPath(*f.config)
+	hosts, err := config.Parse(configPath)
+	if err != nil {
+		fmt.Fprintf(stderr, "sssh: could not parse SSH config: %v\n", err)
+		return 2
+	}
+	host, ok := findHost(hosts, fs.Arg(0))
+	if !ok {
+		fmt.Fprintf(stderr, "sssh sftp: no host named %q\n", fs.Arg(0))
+		return 2
+	}
+
+	recordHostConnection(*f.state, host, *f.noHistory)
+
+	sftpArgs := ssh.WithConfigFile(customConfigPath(configPath), ssh.BuildSFTPArgs(host, ""))
+	if err := sftpRun("sftp", sftpArgs...); err != nil {
+		fmt.Fprintf(stderr, "sssh sftp: %v\n", err)
+		return 1
+	}
+	return 0
+}
+
+// sftpFlags holds the flags of "sssh sftp".
+type sftpFlags struct {
+	config, state *string
+	noHistory     *bool
+}
+
+// defineSFTPFlags defines the flags of "sssh sftp" on fs. Any other option
+// makes main hand the call to the real sftp.
+func defineSFTPFlags(fs *flag.FlagSet) sftpFlags {
+	return sftpFlags{
+		config:    fs.String("config", "", "Path to SSH config file"),
+		state:     fs.String("state", "", "Path to the state file (default: the platform state path)"),
+		noHistory: fs.Bool("no-history", envBool("SWIFTSSH_NO_HISTORY"), "Do not record the connection"),
+	}
+}
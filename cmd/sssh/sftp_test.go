@@ -0,0 +1,91 @@
+package main
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/srava/swiftssh/internal/state"
+)
+
+// fakeSFTP replaces the sftp runner for one test and records its arguments.
+func fakeSFTP(t *testing.T, err error) *[]string {
+	t.Helper()
+	var ran []string
+	old := sftpRun
+	sftpRun = func(name string, args ...string) error {
+		ran = append(ran, name+" "+strings.Join(args, " "))
+		return err
+	}
+	t.Cleanup(func() { sftpRun = old })
+	return &ran
+}
+
+func TestRunSFTP_UsesHostParamsAndRecords(t *testing.T) {
+	configPath := writeConfig(t, "Host web\n    Hostname 10.0.0.5\n    User deploy\n    Port 2222\n    IdentityFile /keys/web\n")
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	t.Setenv("SWIFTSSH_NO_HISTORY", "")
+	t.Setenv("SWIFTSSH_CONNECTION_LOG", filepath.Join(t.TempDir(), "connections.log"))
+	ran := fakeSFTP(t, nil)
+
+	var stdout, stderr bytes.Buffer
+	if code := runSFTP([]string{"--config", configPath, "--state", statePath, "web"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr.String())
+	}
+
+	want := "sftp -F " + configPath + " -i /keys/web -P 2222 deploy@web"
+	if len(*ran) != 1 || (*ran)[0] != want {
+		t.Errorf("ran %q; want %q", *ran, want)
+	}
+	st, err := state.Load(statePath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if st.Connections["web"] != 1 {
+		t.Errorf("connection not recorded: %v", st.Connections)
+	}
+}
+
+func TestRunSFTP_NoHistoryFlag(t *testing.T) {
+	configPath := writeConfig(t, "Host web\n    Hostname 10.0.0.5\n")
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	logPath := filepath.Join(t.TempDir(), "connections.log")
+	t.Setenv("SWIFTSSH_NO_HISTORY", "")
+	t.Setenv("SWIFTSSH_CONNECTION_LOG", logPath)
+	fakeSFTP(t, nil)
+
+	if looksLikeToolArgs(toolSubcommandFlags["sftp"], []string{"--no-history", "web"}) {
+		t.Error("--no-history should stay with the sftp subcommand")
+	}
+	var stdout, stderr bytes.Buffer
+	if code := runSFTP([]string{"--config", configPath, "--state", statePath, "--no-history", "web"}, &stdout, &stderr); code != 0 {
+		t.Fatalf("expected exit 0, got %d: %s", code, stderr.String())
+	}
+	for _, p := range []string{statePath, logPath} {
+		if _, err := os.Stat(p); !os.IsNotExist(err) {
+			t.Errorf("%s written despite --no-history", filepath.Base(p))
+		}
+	}
+}
+
+func TestRunSFTP_Errors(t *testing.T) {
+	configPath := writeConfig(t, "Host web\n    Hostname 10.0.0.5\n")
+	t.Setenv("SWIFTSSH_NO_HISTORY", "1")
+
+	var stdout, stderr bytes.Buffer
+	fakeSFTP(t, nil)
+	if code := runSFTP([]string{"--config", configPath}, &stdout, &stderr); code != 2 {
+		t.Errorf("missing alias: exit %d; want 2", code)
+	}
+	if code := runSFTP([]string{"--config", configPath, "nope"}, &stdout, &stderr); code != 2 {
+		t.Errorf("unknown alias: exit %d; want 2", code)
+	}
+
+	fakeSFTP(t, errors.New("exit status 255"))
+	if code := runSFTP([]string{"--config", configPath, "web"}, &stdout, &stderr); code != 1 {
+		t.Errorf("failed sftp: exit %d; want 1", code)
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/srava/swiftssh/internal/platform"
+	"github.com/srava/swiftssh/internal/state"
+)
+
+// runState implements "sssh state export" and "sssh state import <file>" for
+// carrying connection history between machines.
+func runState(args []string, stdout, stderr io.Writer) int {
+	if len(args) == 0 {
+		fmt.Fprintln(stderr, "usage: sssh state export | sssh state import <file>")
+		return 2
+	}
+
+	fs := flag.NewFlagSet("state "+args[0], flag.ContinueOnError)
+	fs.SetOutput(stderr)
+	stateFlag := fs.String("state", "", "Path to state file (default: platform state path)")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+	statePath := *stateFlag
+	if statePath == "" {
+		statePath = platform.StateFilePath()
+	}
+
+	switch args[0] {
+	case "export":
+		data, err := json.MarshalIndent(loadState(statePath), "", "  ")
+		if err != nil {
+			fmt.Fprintf(stderr, "sssh: export failed: %v\n", err)
+			return 1
+		}
+		fmt.Fprintln(stdout, string(data))
+		return 0
+
+	case "import":
+		if fs.NArg() != 1 {
+			fmt.Fprintln(stderr, "usage: sssh state import [--state <path>] <file>")
+			return 2
+		}
+		data, err := os.ReadFile(fs.Arg(0))
+		if err != nil {
+			fmt.Fprintf(stderr, "sssh: import failed: %v\n", err)
+			return 1
+		}
+		imported := &state.State{}
+		if err := json.Unmarshal(data, imported); err != nil {
+			fmt.Fprintf(stderr, "sssh: import failed: %s is not a state backup: %v\n", fs.Arg(0), err)
+			return 1
+		}
+
+		st := loadState(statePath)
+		state.Merge(st, imported)
+		if err := state.Save(statePath, st); err != nil {
+			fmt.Fprintf(stderr, "sssh: import failed: %v\n", err)
+			return 1
+		}
+		fmt.Fprintf(stdout, "Imported %d hosts into %s\n", len(imported.Connections), statePath)
+		return 0
+	}
+
+	fmt.Fprintf(stderr, "sssh: unknown state command %q (want export or import)\n", args[0])
+	return 2
+}
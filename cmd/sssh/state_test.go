@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/srava/swiftssh/internal/state"
+)
+
+func TestRunState_ExportImportRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	oldPath := filepath.Join(dir, "old.json")
+	newPath := filepath.Join(dir, "new.json")
+	if err := state.Save(oldPath, &state.State{Connections: map[string]int{"prod": 3, "pi": 1}}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+	if err := state.Save(newPath, &state.State{Connections: map[string]int{"prod": 2}}); err != nil {
+		t.Fatalf("Save failed: %v", err)
+	}
+
+	var stdout, stderr bytes.Buffer
+	if code := runState([]string{"export", "--state", oldPath}, &stdout, &stderr); code != 0 {
+		t.Fatalf("export: expected exit 0, got %d: %s", code, stderr.String())
+	}
+	backup := filepath.Join(dir, "backup.json")
+	if err := os.WriteFile(backup, stdout.Bytes(), 0600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+
+	stdout.Reset()
+	if code := runState([]string{"import", "--state", newPath, backup}, &stdout, &stderr); code != 0 {
+		t.Fatalf("import: expected exit 0, got %d: %s", code, stderr.String())
+	}
+
+	merged, err := state.Load(newPath)
+	if err != nil {
+		t.Fatalf("Load failed: %v", err)
+	}
+	if merged.Connections["prod"] != 5 || merged.Connections["pi"] != 1 {
+		t.Errorf("expected prod=5 pi=1 after import, got %v", merged.Connections)
+	}
+}
+
+func TestRunState_ImportRejectsInvalidBackup(t *testing.T) {
+	dir := t.TempDir()
+	bad := filepath.Join(dir, "bad.json")
+	if err := os.WriteFile(bad, []byte("not json"), 0600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	var stdout, stderr bytes.Buffer
+	if code := runState([]string{"import", "--state", filepath.Join(dir, "s.json"), bad}, &stdout, &stderr); code != 1 {
+		t.Errorf("expected exit 1 for invalid backup, got %d", code)
+	}
+	if code := runState(nil, &stdout, &stderr); code != 2 {
+		t.Errorf("expected exit 2 without a state command, got %d", code)
+	}
+}
@@ -0,0 +1,72 @@
+// Package audit records an append-only log of ssh connections launched by
+// SwiftSSH.
+package audit
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/srava/swiftssh/internal/platform"
+)
+
+// ConnectionLogEntry is one line of the connection log.
+type ConnectionLogEntry struct {
+	Time         time.Time `json:"time"`
+	Alias        string    `json:"alias,omitempty"`
+	Hostname     string    `json:"hostname,omitempty"`
+	User         string    `json:"user,omitempty"`
+	IdentityFile string    `json:"identity_file,omitempty"`
+}
+
+// LogConnection appends entry as a single JSON line to the log at path,
+// creating the file and its parent directory if needed.
+func LogConnection(path string, entry ConnectionLogEntry) error {
+	if err := platform.EnsureDir(filepath.Dir(path)); err != nil {
+		return err
+	}
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(path, os.O_APPEND|os.O_WRONLY|os.O_CREATE, 0600)
+	if err != nil {
+		return err
+	}
+	if _, err := f.Write(append(data, '\n')); err != nil {
+		f.Close()
+		return err
+	}
+	return f.Close()
+}
+
+// ReadLog returns the entries in the log at path, oldest first. Lines that
+// are not valid entries are skipped. A missing log yields no entries.
+func ReadLog(path string) ([]ConnectionLogEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	defer f.Close()
+
+	var entries []ConnectionLogEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var e ConnectionLogEntry
+		if err := json.Unmarshal(scanner.Bytes(), &e); err != nil {
+			continue
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading connection log: %w", err)
+	}
+	return entries, nil
+}
@@ -0,0 +1,59 @@
+package audit
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/srava/swiftssh/internal/testutil"
+)
+
+func TestLogConnection_RoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "nested", "connections.log")
+	when := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	entries := []ConnectionLogEntry{
+		{Time: when, Alias: "prod", Hostname: "10.0.0.1", User: "deploy", IdentityFile: "/keys/id"},
+		{Time: when.Add(time.Minute), Alias: "pi", Hostname: "10.0.0.5"},
+	}
+	for _, e := range entries {
+		testutil.AssertNoError(t, LogConnection(path, e), "LogConnection")
+	}
+
+	got, err := ReadLog(path)
+	testutil.AssertNoError(t, err, "ReadLog")
+	if len(got) != 2 {
+		t.Fatalf("expected 2 entries, got %d", len(got))
+	}
+	testutil.AssertStringEqual(t, got[0].Alias, "prod", "first alias")
+	testutil.AssertStringEqual(t, got[0].IdentityFile, "/keys/id", "first identity")
+	testutil.AssertTrue(t, got[1].Time.Equal(when.Add(time.Minute)), "second timestamp")
+}
+
+func TestReadLog_SkipsGarbageAndMissingFile(t *testing.T) {
+	dir := t.TempDir()
+	entries, err := ReadLog(filepath.Join(dir, "missing.log"))
+	testutil.AssertNoError(t, err, "missing log should not error")
+	testutil.AssertEqual(t, len(entries), 0, "missing log entries")
+
+	path := filepath.Join(dir, "connections.log")
+	content := "not json\n{\"time\":\"2024-05-01T12:00:00Z\",\"alias\":\"prod\"}\n"
+	if err := os.WriteFile(path, []byte(content), 0600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	entries, err = ReadLog(path)
+	testutil.AssertNoError(t, err, "ReadLog")
+	if len(entries) != 1 || entries[0].Alias != "prod" {
+		t.Errorf("expected only the valid entry, got %v", entries)
+	}
+}
+
+func TestLogConnection_UnwritablePath(t *testing.T) {
+	blocker := filepath.Join(t.TempDir(), "file")
+	if err := os.WriteFile(blocker, nil, 0600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	err := LogConnection(filepath.Join(blocker, "connections.log"), ConnectionLogEntry{Alias: "prod"})
+	testutil.AssertError(t, err, "logging beneath a regular file should fail")
+}
@@ -0,0 +1,110 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"strings"
+)
+
+// anonymizer hands out numbered placeholders, the same one every time a
+// value repeats, so relationships between hosts stay visible.
+type anonymizer struct {
+	seen   map[string]string
+	counts map[string]int
+}
+
+func (a *anonymizer) placeholder(kind, value string) string {
+	if value == "" {
+		return ""
+	}
+	key := kind + "\x00" + value
+	if p, ok := a.seen[key]; ok {
+		return p
+	}
+	a.counts[kind]++
+	p := fmt.Sprintf("%s%d", kind, a.counts[kind])
+	a.seen[key] = p
+	return p
+}
+
+// hostname replaces a hostname with "hostN", or "ipN" for an IP address.
+func (a *anonymizer) hostname(value string) string {
+	if net.ParseIP(strings.Trim(value, "[]")) != nil {
+		return a.placeholder("ip", value)
+	}
+	return a.placeholder("host", value)
+}
+
+// Anonymize returns copies of hosts with identifying values replaced by
+// numbered placeholders for sharing in bug reports: aliases become aliasN,
+// hostnames hostN (ipN for addresses), users userN, identity files
+// ~/.ssh/keyN, and source files fileN. A repeated value always gets the same
+// placeholder within one call, and ProxyJump targets are mapped the same way,
+// so a jump host keeps its link to its own entry. Ports, groups, weights, and
+// line numbers are kept; unmodeled directives keep only their keyword. hosts
+// is not modified.
+func Anonymize(hosts []Host) []Host {
+	a := &anonymizer{seen: make(map[string]string), counts: make(map[string]int)}
+	out := make([]Host, len(hosts))
+	for i, h := range hosts {
+		h.Alias = a.placeholder("alias", h.Alias)
+		h.Hostname = a.hostname(h.Hostname)
+		h.User = a.placeholder("user", h.User)
+		if ids := h.Identities(); ids != nil {
+			keys := make([]string, len(ids))
+			for j, id := range ids {
+				keys[j] = "~/.ssh/" + a.placeholder("key", id)
+			}
+			h.IdentityFile, h.IdentityFiles = keys[0], keys
+		}
+		h.SourceFile = a.placeholder("file", h.SourceFile)
+		h.Groups = append([]string(nil), h.Groups...)
+		h.AfterLocal = ""
+		h.Inherited = nil
+		if h.ExtraLines != nil {
+			extra := make([]string, len(h.ExtraLines))
+			for j, line := range h.ExtraLines {
+				keyword, _ := parseHostLine(line)
+				extra[j] = keyword + " <redacted>"
+			}
+			h.ExtraLines = extra
+		}
+		out[i] = h
+	}
+
+	// ProxyJump is mapped last so a jump host named by its alias resolves to
+	// the placeholder of its own entry, whichever order they appear in.
+	for i := range out {
+		if out[i].ProxyJump == "" {
+			continue
+		}
+		hops := strings.Split(out[i].ProxyJump, ",")
+		for j, hop := range hops {
+			hops[j] = a.jumpHop(hop)
+		}
+		out[i].ProxyJump = strings.Join(hops, ",")
+	}
+	return out
+}
+
+// jumpHop anonymizes one "[user@]host[:port]" ProxyJump hop. A host that is a
+// known alias maps to that alias's placeholder.
+func (a *anonymizer) jumpHop(hop string) string {
+	user, host := "", hop
+	if at := strings.LastIndex(hop, "@"); at >= 0 {
+		user, host = hop[:at], hop[at+1:]
+	}
+	port := ""
+	if h, p, err := net.SplitHostPort(host); err == nil {
+		host, port = h, ":"+p
+	}
+	if p, ok := a.seen["alias\x00"+host]; ok {
+		host = p
+	} else {
+		host = a.hostname(host)
+	}
+	if user != "" {
+		host = a.placeholder("user", user) + "@" + host
+	}
+	return host + port
+}
@@ -0,0 +1,50 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/srava/swiftssh/internal/testutil"
+)
+
+func anonymizeFixture() []Host {
+	return []Host{
+		{Alias: "bastion", Hostname: "bastion.corp.example", User: "alice", Port: "22", Groups: []string{"Infra"},
+			SourceFile: "/home/alice/.ssh/config", LineStart: 1},
+		{Alias: "db", Hostname: "10.0.0.21", User: "alice", Port: "5022", Groups: []string{"Work", "DB"},
+			IdentityFile: "/home/alice/.ssh/id_db", ProxyJump: "bastion", SourceFile: "/home/alice/.ssh/config", LineStart: 5,
+			ExtraLines: []string{"ProxyCommand nc secret.example 22"}},
+		{Alias: "web", Hostname: "10.0.0.21", User: "deploy", Port: "22", ProxyJump: "alice@bastion.corp.example:2222",
+			SourceFile: "/home/alice/.ssh/conf.d/web", LineStart: 1, Weight: 3},
+	}
+}
+
+func TestAnonymize_ReplacesIdentifyingValues(t *testing.T) {
+	hosts := anonymizeFixture()
+	got := Anonymize(hosts)
+
+	testutil.AssertHostsEqual(t, got, []Host{
+		{Alias: "alias1", Hostname: "host1", User: "user1", Port: "22", Groups: []string{"Infra"},
+			SourceFile: "file1", LineStart: 1},
+		{Alias: "alias2", Hostname: "ip1", User: "user1", Port: "5022", Groups: []string{"Work", "DB"},
+			IdentityFile: "~/.ssh/key1", ProxyJump: "alias1", SourceFile: "file1", LineStart: 5,
+			ExtraLines: []string{"ProxyCommand <redacted>"}},
+		{Alias: "alias3", Hostname: "ip1", User: "user2", Port: "22", ProxyJump: "user1@host1:2222",
+			SourceFile: "file2", LineStart: 1, Weight: 3},
+	}, "anonymized hosts")
+
+	// The input is untouched.
+	testutil.AssertEqual(t, hosts[1].Hostname, "10.0.0.21", "original hostname")
+	testutil.AssertEqual(t, hosts[1].ExtraLines[0], "ProxyCommand nc secret.example 22", "original extra line")
+}
+
+func TestAnonymize_Deterministic(t *testing.T) {
+	first := FormatHosts(Anonymize(anonymizeFixture()))
+	second := FormatHosts(Anonymize(anonymizeFixture()))
+	testutil.AssertEqual(t, first, second, "anonymized output")
+	for _, secret := range []string{"alice", "bastion", "10.0.0.21", "example", "id_db"} {
+		if strings.Contains(first, secret) {
+			t.Errorf("anonymized output leaks %q:\n%s", secret, first)
+		}
+	}
+}
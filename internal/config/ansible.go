@@ -0,0 +1,171 @@
+package config
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ansibleVars maps inventory host variables to the Host field they set. The
+// ansible_ssh_* spellings are the pre-2.0 names, still common in old
+// inventories.
+var ansibleVars = map[string]func(h *Host, v string){
+	"ansible_host":                 func(h *Host, v string) { h.Hostname = v },
+	"ansible_ssh_host":             func(h *Host, v string) { h.Hostname = v },
+	"ansible_user":                 func(h *Host, v string) { h.User = v },
+	"ansible_ssh_user":             func(h *Host, v string) { h.User = v },
+	"ansible_port":                 func(h *Host, v string) { h.Port = v },
+	"ansible_ssh_port":             func(h *Host, v string) { h.Port = v },
+	"ansible_ssh_private_key_file": func(h *Host, v string) { h.IdentityFile = v },
+	"ansible_private_key_file":     func(h *Host, v string) { h.IdentityFile = v },
+}
+
+// ansibleRange matches a numeric host range such as "web[01:03]".
+var ansibleRange = regexp.MustCompile(`\[([0-9]+):([0-9]+)\]`)
+
+// ParseAnsibleInventory reads an INI-style Ansible inventory and returns its
+// hosts in order of first appearance. Each inventory name becomes the alias;
+// ansible_host, ansible_user, ansible_port, and ansible_ssh_private_key_file
+// fill the other fields (Hostname defaults to the name, Port to "22"). The
+// groups a host is listed under, including parents reached through
+// [group:children], become its Groups; "all" and "ungrouped" are left out.
+// [group:vars] sections are ignored. Numeric ranges like web[01:03] are
+// expanded.
+func ParseAnsibleInventory(path string) ([]Host, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	var (
+		hosts    []*Host
+		byName   = make(map[string]*Host)
+		members  = make(map[string][]*Host)  // group -> hosts listed directly under it
+		children = make(map[string][]string) // group -> child groups
+		groups   []string                    // section names in file order
+		seen     = make(map[string]bool)
+		section  = "ungrouped"
+		kind     = "hosts"
+		lineNum  int
+	)
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		lineNum++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || line[0] == '#' || line[0] == ';' {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			if !strings.HasSuffix(line, "]") {
+				return nil, fmt.Errorf("%s:%d: malformed section %q", path, lineNum, line)
+			}
+			section, kind, _ = strings.Cut(line[1:len(line)-1], ":")
+			if kind == "" {
+				kind = "hosts"
+			}
+			if !seen[section] {
+				seen[section] = true
+				groups = append(groups, section)
+			}
+			continue
+		}
+
+		fields := strings.Fields(line)
+		switch kind {
+		case "children":
+			children[section] = append(children[section], fields[0])
+			continue
+		case "hosts":
+		default: // vars and anything unknown
+			continue
+		}
+
+		names, err := expandAnsibleRange(fields[0])
+		if err != nil {
+			return nil, fmt.Errorf("%s:%d: %w", path, lineNum, err)
+		}
+		for _, name := range names {
+			h, ok := byName[name]
+			if !ok {
+				h = &Host{Alias: name}
+				byName[name] = h
+				hosts = append(hosts, h)
+			}
+			for _, kv := range fields[1:] {
+				k, v, ok := strings.Cut(kv, "=")
+				if set := ansibleVars[k]; ok && set != nil {
+					set(h, strings.Trim(v, `"'`))
+				}
+			}
+			members[section] = append(members[section], h)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading inventory: %w", err)
+	}
+
+	// Walk each group's children so a host in [web] also gets the groups
+	// that list web as a child, however deeply nested.
+	var addGroup func(group, tag string, seen map[string]bool)
+	addGroup = func(group, tag string, seen map[string]bool) {
+		if seen[group] {
+			return
+		}
+		seen[group] = true
+		for _, h := range members[group] {
+			if !h.InGroup(tag) {
+				h.Groups = append(h.Groups, tag)
+			}
+		}
+		for _, child := range children[group] {
+			addGroup(child, tag, seen)
+		}
+	}
+	for _, group := range groups {
+		if group == "all" || group == "ungrouped" {
+			continue
+		}
+		addGroup(group, group, make(map[string]bool))
+	}
+
+	out := make([]Host, len(hosts))
+	for i, h := range hosts {
+		if h.Hostname == "" {
+			h.Hostname = h.Alias
+		}
+		if h.Port == "" {
+			h.Port = "22"
+		}
+		out[i] = *h
+	}
+	return out, nil
+}
+
+// expandAnsibleRange expands the first numeric range in name, keeping the
+// zero padding of its start ("db[08:10]" is db08, db09, db10).
+func expandAnsibleRange(name string) ([]string, error) {
+	loc := ansibleRange.FindStringSubmatchIndex(name)
+	if loc == nil {
+		return []string{name}, nil
+	}
+	startStr := name[loc[2]:loc[3]]
+	start, _ := strconv.Atoi(startStr)
+	end, _ := strconv.Atoi(name[loc[4]:loc[5]])
+	if end < start {
+		return nil, fmt.Errorf("host range %q ends before it starts", name)
+	}
+	width := 0
+	if len(startStr) > 1 && startStr[0] == '0' {
+		width = len(startStr)
+	}
+	var names []string
+	for n := start; n <= end; n++ {
+		names = append(names, fmt.Sprintf("%s%0*d%s", name[:loc[0]], width, n, name[loc[1]:]))
+	}
+	return names, nil
+}
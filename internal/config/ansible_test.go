@@ -0,0 +1,56 @@
+package config
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/srava/swiftssh/internal/testutil"
+)
+
+const testInventory = `# team inventory
+bastion ansible_host=203.0.113.10
+
+[web]
+web1 ansible_host=10.0.0.11 ansible_user=deploy
+web2 ansible_host=10.0.0.12 ansible_user=deploy ansible_port=2222
+
+[db]
+db[01:02].internal ansible_ssh_private_key_file="~/.ssh/id_db"
+web1
+
+[db:vars]
+ansible_user=postgres
+
+[prod:children]
+web
+db
+
+[everything:children]
+prod
+`
+
+func TestParseAnsibleInventory(t *testing.T) {
+	path := writeTempConfigAt(t, t.TempDir(), "inventory.ini", testInventory)
+	hosts, err := ParseAnsibleInventory(path)
+	testutil.AssertNoError(t, err, "ParseAnsibleInventory should not error")
+
+	testutil.AssertHostsEqual(t, hosts, []Host{
+		{Alias: "bastion", Hostname: "203.0.113.10", Port: "22"},
+		{Alias: "web1", Hostname: "10.0.0.11", User: "deploy", Port: "22", Groups: []string{"web", "db", "prod", "everything"}},
+		{Alias: "web2", Hostname: "10.0.0.12", User: "deploy", Port: "2222", Groups: []string{"web", "prod", "everything"}},
+		{Alias: "db01.internal", Hostname: "db01.internal", Port: "22", IdentityFile: "~/.ssh/id_db", Groups: []string{"db", "prod", "everything"}},
+		{Alias: "db02.internal", Hostname: "db02.internal", Port: "22", IdentityFile: "~/.ssh/id_db", Groups: []string{"db", "prod", "everything"}},
+	}, "inventory hosts")
+}
+
+func TestParseAnsibleInventory_Errors(t *testing.T) {
+	dir := t.TempDir()
+	if _, err := ParseAnsibleInventory(filepath.Join(dir, "missing.ini")); err == nil {
+		t.Error("missing inventory: want error")
+	}
+	bad := writeTempConfigAt(t, dir, "bad.ini", "[web\nweb1\n")
+	if _, err := ParseAnsibleInventory(bad); err == nil || !strings.Contains(err.Error(), "bad.ini:1") {
+		t.Errorf("malformed section: err = %v; want error naming bad.ini:1", err)
+	}
+}
@@ -0,0 +1,86 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Diagnostic is a single finding about a host produced by an audit.
+type Diagnostic struct {
+	Alias      string // host alias the finding applies to
+	SourceFile string // config file containing the host
+	Line       int    // 1-based line of the Host directive; 0 if untracked
+	Message    string // human-readable description of the problem
+}
+
+// String formats the diagnostic as "file:line: alias: message".
+func (d Diagnostic) String() string {
+	return fmt.Sprintf("%s:%d: %s: %s", d.SourceFile, d.Line, d.Alias, d.Message)
+}
+
+// weakAlgorithms lists known-weak algorithm names per crypto directive
+// (lowercased keyword → algorithm set).
+var weakAlgorithms = map[string]map[string]bool{
+	"ciphers": {
+		"3des-cbc": true, "aes128-cbc": true, "aes192-cbc": true, "aes256-cbc": true,
+		"blowfish-cbc": true, "cast128-cbc": true, "arcfour": true, "arcfour128": true,
+		"arcfour256": true, "rijndael-cbc@lysator.liu.se": true,
+	},
+	"kexalgorithms": {
+		"diffie-hellman-group1-sha1": true, "diffie-hellman-group14-sha1": true,
+		"diffie-hellman-group-exchange-sha1": true,
+	},
+	"macs": {
+		"hmac-md5": true, "hmac-md5-96": true, "hmac-sha1-96": true, "hmac-ripemd160": true,
+		"hmac-md5-etm@openssh.com": true, "hmac-md5-96-etm@openssh.com": true,
+		"hmac-sha1-96-etm@openssh.com": true,
+	},
+}
+
+// diagKey identifies a finding within a block. The hosts split from one
+// multi-pattern Host line share SourceFile and LineStart.
+type diagKey struct {
+	file    string
+	line    int
+	message string
+}
+
+// AuditAlgorithms flags Ciphers, KexAlgorithms, and MACs directives that enable
+// known-weak algorithms. Entries removed with a leading "-" are not flagged.
+// Pass the hosts from ParseWithWildcards so "Host *" blocks are checked too.
+// A block shared by several aliases is reported once, under the first.
+func AuditAlgorithms(hosts []Host) []Diagnostic {
+	var diags []Diagnostic
+	seen := make(map[diagKey]bool)
+	for _, h := range hosts {
+		for _, line := range h.ExtraLines {
+			keyword, value := parseHostLine(line)
+			weak := weakAlgorithms[strings.ToLower(keyword)]
+			if weak == nil || strings.HasPrefix(value, "-") {
+				continue
+			}
+			value = strings.TrimLeft(value, "+^")
+			for _, alg := range strings.Split(value, ",") {
+				alg = strings.TrimSpace(alg)
+				if !weak[strings.ToLower(alg)] {
+					continue
+				}
+				d := Diagnostic{
+					Alias:      h.Alias,
+					SourceFile: h.SourceFile,
+					Line:       h.LineStart,
+					Message:    fmt.Sprintf("%s enables weak algorithm %q", keyword, alg),
+				}
+				if d.Line != 0 {
+					key := diagKey{d.SourceFile, d.Line, d.Message}
+					if seen[key] {
+						continue
+					}
+					seen[key] = true
+				}
+				diags = append(diags, d)
+			}
+		}
+	}
+	return diags
+}
@@ -0,0 +1,80 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAuditAlgorithms_FlagsWeakAlgorithms(t *testing.T) {
+	hosts := []Host{
+		{
+			Alias:      "legacy",
+			SourceFile: "/tmp/config",
+			LineStart:  3,
+			ExtraLines: []string{
+				"Ciphers aes128-cbc,aes256-ctr",
+				"KexAlgorithms +diffie-hellman-group1-sha1",
+				"MACs hmac-md5",
+			},
+		},
+	}
+
+	diags := AuditAlgorithms(hosts)
+	if len(diags) != 3 {
+		t.Fatalf("expected 3 diagnostics, got %d: %v", len(diags), diags)
+	}
+	for i, want := range []string{"aes128-cbc", "diffie-hellman-group1-sha1", "hmac-md5"} {
+		if !strings.Contains(diags[i].Message, want) {
+			t.Errorf("diags[%d]: expected message to mention %q, got %q", i, want, diags[i].Message)
+		}
+		if diags[i].Alias != "legacy" || diags[i].Line != 3 {
+			t.Errorf("diags[%d]: expected alias=legacy line=3, got %s line=%d", i, diags[i].Alias, diags[i].Line)
+		}
+	}
+}
+
+func TestAuditAlgorithms_IgnoresStrongAndRemovedAlgorithms(t *testing.T) {
+	hosts := []Host{
+		{
+			Alias: "modern",
+			ExtraLines: []string{
+				"Ciphers chacha20-poly1305@openssh.com,aes256-gcm@openssh.com",
+				"KexAlgorithms -diffie-hellman-group1-sha1",
+				"ServerAliveInterval 60",
+			},
+		},
+		{Alias: "plain"},
+	}
+
+	if diags := AuditAlgorithms(hosts); len(diags) != 0 {
+		t.Errorf("expected no diagnostics, got %v", diags)
+	}
+}
+
+// TestAuditAlgorithms_WildcardAndSharedBlocks verifies a weak cipher under
+// "Host *" is reported, and a block shared by several aliases is reported once.
+func TestAuditAlgorithms_WildcardAndSharedBlocks(t *testing.T) {
+	path := writeTempConfig(t, "Host a b\n    Hostname ab.example.com\n    MACs hmac-md5\n\nHost *\n    Ciphers aes128-cbc\n")
+	hosts, err := ParseWithWildcards(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	diags := AuditAlgorithms(hosts)
+	if len(diags) != 2 {
+		t.Fatalf("expected 2 diagnostics, got %d: %v", len(diags), diags)
+	}
+	if diags[0].Alias != "a" || diags[0].Line != 1 || !strings.Contains(diags[0].Message, "hmac-md5") {
+		t.Errorf("diags[0] = %v; want hmac-md5 once, under a at line 1", diags[0])
+	}
+	if diags[1].Alias != "*" || diags[1].Line != 5 || !strings.Contains(diags[1].Message, "aes128-cbc") {
+		t.Errorf("diags[1] = %v; want aes128-cbc under * at line 5", diags[1])
+	}
+}
+
+func TestDiagnostic_String(t *testing.T) {
+	d := Diagnostic{Alias: "dev", SourceFile: "/tmp/config", Line: 4, Message: "bad"}
+	if got, want := d.String(), "/tmp/config:4: dev: bad"; got != want {
+		t.Errorf("String() = %q; want %q", got, want)
+	}
+}
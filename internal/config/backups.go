@@ -0,0 +1,64 @@
+package config
+
+import (
+	"io/fs"
+	"path/filepath"
+	"regexp"
+)
+
+// Backup is a backup file written by SwiftSSH next to a config file.
+type Backup struct {
+	Path string `json:"path"`
+	Size int64  `json:"size"`
+}
+
+// backupSuffix matches the names writeBackup produces: ".bak" and its older
+// generations ".bak.1", ".bak.2", ...
+var backupSuffix = regexp.MustCompile(`\.bak(\.[0-9]+)?$`)
+
+// FindBackups walks the directory holding configPath and returns the backups
+// SwiftSSH may have written there, in path order. A file counts only if its
+// name is a config file's path plus a backup suffix, where the config files
+// are configPath, the SourceFile of each host, and "config" in configPath's
+// directory (where earlier versions backed up new hosts). The config files
+// themselves are never returned. Unreadable subdirectories are skipped.
+func FindBackups(configPath string, hosts []Host) ([]Backup, error) {
+	configPath, err := filepath.Abs(configPath)
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Dir(configPath)
+	live := map[string]bool{
+		configPath:                   true,
+		filepath.Join(dir, "config"): true,
+	}
+	for _, h := range hosts {
+		if abs, err := filepath.Abs(h.SourceFile); err == nil && h.SourceFile != "" {
+			live[abs] = true
+		}
+	}
+
+	var backups []Backup
+	err = filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path != dir && d != nil && d.IsDir() {
+				return fs.SkipDir
+			}
+			return err
+		}
+		if !d.Type().IsRegular() || live[path] {
+			return nil
+		}
+		loc := backupSuffix.FindStringIndex(path)
+		if loc == nil || !live[path[:loc[0]]] {
+			return nil
+		}
+		info, err := d.Info()
+		if err != nil {
+			return nil
+		}
+		backups = append(backups, Backup{Path: path, Size: info.Size()})
+		return nil
+	})
+	return backups, err
+}
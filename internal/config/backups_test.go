@@ -0,0 +1,57 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/srava/swiftssh/internal/testutil"
+)
+
+func TestFindBackups_SelectsOnlyConfigBackups(t *testing.T) {
+	dir := t.TempDir()
+	configPath := writeTempConfigAt(t, dir, "config", "Include conf.d/*\n\nHost main\n    Hostname main.example.com\n")
+	writeTempConfigAt(t, dir, "conf.d/work", "Host work\n    Hostname work.example.com\n")
+
+	for _, name := range []string{
+		"config.bak", "config.bak.1", "config.bak.2", "conf.d/work.bak", "conf.d/work.bak.1",
+		// Not ours: no matching config file, or not a backup name.
+		"id_ed25519.bak", "conf.d/gone.bak", "config.bak.old", "config.backup", "known_hosts.bak.1",
+	} {
+		writeTempConfigAt(t, dir, name, "x")
+	}
+
+	hosts, err := Parse(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	backups, err := FindBackups(configPath, hosts)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var got []string
+	for _, b := range backups {
+		rel, _ := filepath.Rel(dir, b.Path)
+		got = append(got, filepath.ToSlash(rel))
+		testutil.AssertEqual(t, b.Size, int64(1), b.Path+" size")
+	}
+	testutil.AssertSliceEqual(t, got, []string{
+		"conf.d/work.bak", "conf.d/work.bak.1", "config.bak", "config.bak.1", "config.bak.2",
+	}, "backups")
+}
+
+func TestFindBackups_NeverReturnsLiveConfig(t *testing.T) {
+	dir := t.TempDir()
+	// An included file that happens to have a backup-like name.
+	configPath := writeTempConfigAt(t, dir, "config", "Include old.bak\n")
+	writeTempConfigAt(t, dir, "old.bak", "Host old\n    Hostname old.example.com\n")
+
+	hosts, _ := Parse(configPath)
+	backups, err := FindBackups(configPath, hosts)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(backups) != 0 {
+		t.Errorf("FindBackups = %v; want none", backups)
+	}
+}
@@ -0,0 +1,123 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// GenerateAlias returns the alias SwiftSSH gives a host it adds on its own:
+// "user-hostname", or just the hostname when user is empty. The colons of an
+// IPv6 address become dashes, since "alias:path" is how scp names a remote
+// file; leading dashes are dropped so the alias never reads as an option
+// ("root@fe80::1" gives "root-fe80--1", "::1" gives "1").
+func GenerateAlias(user, hostname string) string {
+	hostname = strings.TrimLeft(strings.ReplaceAll(hostname, ":", "-"), "-")
+	if user == "" {
+		return hostname
+	}
+	return user + "-" + hostname
+}
+
+// UniqueAlias returns alias, or alias with the first free "-2", "-3", ...
+// suffix if one of hosts already uses it.
+func UniqueAlias(hosts []Host, alias string) string {
+	taken := make(map[string]bool, len(hosts))
+	for _, h := range hosts {
+		taken[h.Alias] = true
+	}
+	candidate := alias
+	for n := 2; taken[candidate]; n++ {
+		candidate = alias + "-" + strconv.Itoa(n)
+	}
+	return candidate
+}
+
+// ParseConnectionString parses a pasted destination of the form
+// "[user@]host[:port][/key]" into a new Host with a generated alias. An IPv6
+// address with a port must be bracketed ("[fe80::1]:2222"); an unbracketed
+// address with several colons is taken as a host without a port. The key hint
+// after the first "/" is taken as written when it starts with "~", as an
+// absolute path when it contains further slashes, and as a file in ~/.ssh
+// otherwise ("host/id_work" uses ~/.ssh/id_work).
+func ParseConnectionString(s string) (Host, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return Host{}, fmt.Errorf("empty destination")
+	}
+	if strings.ContainsAny(s, " \t") {
+		return Host{}, fmt.Errorf("%q: unexpected whitespace", s)
+	}
+
+	var user, identity string
+	if at := strings.LastIndex(s, "@"); at >= 0 && !strings.Contains(s[:at], "/") {
+		user, s = s[:at], s[at+1:]
+		if user == "" {
+			return Host{}, fmt.Errorf("empty user before @")
+		}
+	}
+
+	hostPort := s
+	if strings.HasPrefix(s, "[") {
+		end := strings.Index(s, "]")
+		if end < 0 {
+			return Host{}, fmt.Errorf("%q: missing ] after IPv6 address", s)
+		}
+		if slash := strings.Index(s[end:], "/"); slash >= 0 {
+			hostPort, identity = s[:end+slash], s[end+slash+1:]
+		}
+	} else if slash := strings.Index(s, "/"); slash >= 0 {
+		hostPort, identity = s[:slash], s[slash+1:]
+	}
+
+	hostname, port, err := splitHostPort(hostPort)
+	if err != nil {
+		return Host{}, err
+	}
+	return Host{
+		Alias:        GenerateAlias(user, hostname),
+		Hostname:     hostname,
+		User:         user,
+		Port:         port,
+		IdentityFile: identityHint(identity),
+	}, nil
+}
+
+// splitHostPort splits "host", "host:port", "[v6]", "[v6]:port", or a bare
+// IPv6 address into a hostname and port ("22" when absent).
+func splitHostPort(s string) (host, port string, err error) {
+	port = "22"
+	switch {
+	case strings.HasPrefix(s, "["):
+		end := strings.Index(s, "]")
+		host, rest := s[1:end], s[end+1:]
+		if rest != "" {
+			if !strings.HasPrefix(rest, ":") {
+				return "", "", fmt.Errorf("%q: unexpected %q after IPv6 address", s, rest)
+			}
+			port = rest[1:]
+		}
+		s = host
+	case strings.Count(s, ":") == 1:
+		s, port, _ = strings.Cut(s, ":")
+	}
+	if s == "" {
+		return "", "", fmt.Errorf("missing hostname")
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return "", "", fmt.Errorf("port %q must be a number from 1 to 65535", port)
+	}
+	return s, port, nil
+}
+
+// identityHint turns the key hint after "/" into an IdentityFile value.
+func identityHint(hint string) string {
+	switch {
+	case hint == "", strings.HasPrefix(hint, "~"):
+		return hint
+	case strings.Contains(hint, "/"):
+		return "/" + hint
+	default:
+		return "~/.ssh/" + hint
+	}
+}
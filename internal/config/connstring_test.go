@@ -0,0 +1,63 @@
+package config
+
+import "testing"
+
+func TestParseConnectionString(t *testing.T) {
+	tests := []struct {
+		in   string
+		want Host
+	}{
+		{"deploy@10.0.0.5:2222", Host{Alias: "deploy-10.0.0.5", Hostname: "10.0.0.5", User: "deploy", Port: "2222"}},
+		{"deploy@web.example.com", Host{Alias: "deploy-web.example.com", Hostname: "web.example.com", User: "deploy", Port: "22"}},
+		{"web.example.com:2200", Host{Alias: "web.example.com", Hostname: "web.example.com", Port: "2200"}},
+		{"  bastion  ", Host{Alias: "bastion", Hostname: "bastion", Port: "22"}},
+		{"root@[fe80::1]:2222", Host{Alias: "root-fe80--1", Hostname: "fe80::1", User: "root", Port: "2222"}},
+		{"[::1]", Host{Alias: "1", Hostname: "::1", Port: "22"}},
+		{"fe80::1", Host{Alias: "fe80--1", Hostname: "fe80::1", Port: "22"}},
+		{"deploy@10.0.0.5:2222/id_work", Host{Alias: "deploy-10.0.0.5", Hostname: "10.0.0.5", User: "deploy", Port: "2222", IdentityFile: "~/.ssh/id_work"}},
+		{"deploy@10.0.0.5/~/keys/id_ed25519", Host{Alias: "deploy-10.0.0.5", Hostname: "10.0.0.5", User: "deploy", Port: "22", IdentityFile: "~/keys/id_ed25519"}},
+		{"host/home/me/.ssh/id_rsa", Host{Alias: "host", Hostname: "host", Port: "22", IdentityFile: "/home/me/.ssh/id_rsa"}},
+		{"[::1]:22/id_local", Host{Alias: "1", Hostname: "::1", Port: "22", IdentityFile: "~/.ssh/id_local"}},
+	}
+	for _, tc := range tests {
+		got, err := ParseConnectionString(tc.in)
+		if err != nil {
+			t.Errorf("ParseConnectionString(%q): unexpected error %v", tc.in, err)
+			continue
+		}
+		if got.Alias != tc.want.Alias || got.Hostname != tc.want.Hostname || got.User != tc.want.User ||
+			got.Port != tc.want.Port || got.IdentityFile != tc.want.IdentityFile {
+			t.Errorf("ParseConnectionString(%q) = %+v; want %+v", tc.in, got, tc.want)
+		}
+	}
+}
+
+func TestParseConnectionString_Malformed(t *testing.T) {
+	for _, in := range []string{
+		"",
+		"   ",
+		"@host",
+		"deploy@",
+		"host:",
+		"host:ssh",
+		"host:70000",
+		":22",
+		"[::1",
+		"[::1]x",
+		"[]:22",
+		"deploy@host name",
+	} {
+		if h, err := ParseConnectionString(in); err == nil {
+			t.Errorf("ParseConnectionString(%q) = %+v; want error", in, h)
+		}
+	}
+}
+
+func TestUniqueAlias(t *testing.T) {
+	hosts := []Host{{Alias: "web"}, {Alias: "web-2"}, {Alias: "db"}}
+	for alias, want := range map[string]string{"web": "web-3", "db": "db-2", "api": "api"} {
+		if got := UniqueAlias(hosts, alias); got != want {
+			t.Errorf("UniqueAlias(%q) = %q; want %q", alias, got, want)
+		}
+	}
+}
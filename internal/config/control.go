@@ -0,0 +1,30 @@
+package config
+
+import "strings"
+
+// ControlSettings holds a host's connection sharing directives. They are kept
+// in ExtraLines, so rewrites preserve them as written.
+type ControlSettings struct {
+	Master  string // ControlMaster: yes, no, auto, ask, autoask
+	Path    string // ControlPath, unexpanded
+	Persist string // ControlPersist: yes, no, or a timeout
+}
+
+// Control returns h's ControlMaster, ControlPath, and ControlPersist values.
+func (h Host) Control() ControlSettings {
+	var c ControlSettings
+	c.Master, _ = h.Directive("ControlMaster")
+	c.Path, _ = h.Directive("ControlPath")
+	c.Persist, _ = h.Directive("ControlPersist")
+	return c
+}
+
+// ControlSocket returns the path of h's control socket with its tokens
+// expanded, or "" if h has no ControlPath or it is "none".
+func (h Host) ControlSocket() (string, error) {
+	path := strings.Trim(h.Control().Path, `"`)
+	if path == "" || strings.EqualFold(path, "none") {
+		return "", nil
+	}
+	return ExpandTokens(path, h)
+}
@@ -0,0 +1,75 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/srava/swiftssh/internal/testutil"
+)
+
+func TestParse_ControlDirectives(t *testing.T) {
+	path := writeTempConfig(t, "Host web\n    Hostname web.example.com\n    User deploy\n"+
+		"    ControlMaster auto\n    ControlPath /tmp/cm-%r@%h:%p\n    ControlPersist 10m\n")
+	hosts, err := Parse(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	c := hosts[0].Control()
+	testutil.AssertEqual(t, c.Master, "auto", "ControlMaster")
+	testutil.AssertEqual(t, c.Path, "/tmp/cm-%r@%h:%p", "ControlPath")
+	testutil.AssertEqual(t, c.Persist, "10m", "ControlPersist")
+
+	sock, err := hosts[0].ControlSocket()
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.AssertEqual(t, sock, "/tmp/cm-deploy@web.example.com:22", "ControlSocket")
+}
+
+func TestControlDirectives_RoundTrip(t *testing.T) {
+	content := "Host web\n    Hostname web.example.com\n    ControlMaster auto\n    ControlPath ~/.ssh/cm-%C\n    ControlPersist yes\n"
+	path := writeHostConfig(t, content)
+	hosts, err := Parse(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := hosts[0]
+	h.User = "deploy"
+	if _, err := ReplaceHostBlock(h); err != nil {
+		t.Fatal(err)
+	}
+	data, _ := os.ReadFile(path)
+	for _, line := range []string{"ControlMaster auto", "ControlPath ~/.ssh/cm-%C", "ControlPersist yes"} {
+		if !strings.Contains(string(data), line) {
+			t.Errorf("rewritten config lost %q:\n%s", line, data)
+		}
+	}
+}
+
+func TestControlSocket_NoneOrUnset(t *testing.T) {
+	for _, h := range []Host{
+		{Alias: "a"},
+		{Alias: "b", ExtraLines: []string{"ControlPath none"}},
+	} {
+		sock, err := h.ControlSocket()
+		if err != nil || sock != "" {
+			t.Errorf("%s: ControlSocket = %q, %v; want empty", h.Alias, sock, err)
+		}
+	}
+}
+
+func TestExpandTokens_ConnectionHash(t *testing.T) {
+	h := Host{Alias: "web", Hostname: "web.example.com", User: "deploy", Port: "2222"}
+	got, err := ExpandTokens("%C", h)
+	if err != nil {
+		t.Fatal(err)
+	}
+	again, _ := ExpandTokens("%C", h)
+	other, _ := ExpandTokens("%C", Host{Alias: "db", Hostname: "db.example.com", User: "deploy", Port: "2222"})
+	if len(got) != 40 || got != again || got == other {
+		t.Errorf("%%C = %q (again %q, other host %q); want a stable 40-char hash per host", got, again, other)
+	}
+}
@@ -0,0 +1,41 @@
+package config
+
+import "strings"
+
+// BlockDiff returns a line diff turning old into new: unchanged lines are
+// prefixed with "  ", removed lines with "- " and added lines with "+ ".
+// It uses a longest-common-subsequence alignment, so the result is minimal.
+func BlockDiff(old, new []string) string {
+	// lcs[i][j] is the LCS length of old[i:] and new[j:].
+	lcs := make([][]int, len(old)+1)
+	for i := range lcs {
+		lcs[i] = make([]int, len(new)+1)
+	}
+	for i := len(old) - 1; i >= 0; i-- {
+		for j := len(new) - 1; j >= 0; j-- {
+			if old[i] == new[j] {
+				lcs[i][j] = lcs[i+1][j+1] + 1
+			} else {
+				lcs[i][j] = max(lcs[i+1][j], lcs[i][j+1])
+			}
+		}
+	}
+
+	var out []string
+	i, j := 0, 0
+	for i < len(old) || j < len(new) {
+		switch {
+		case i < len(old) && j < len(new) && old[i] == new[j]:
+			out = append(out, "  "+old[i])
+			i++
+			j++
+		case i < len(old) && (j == len(new) || lcs[i+1][j] >= lcs[i][j+1]):
+			out = append(out, "- "+old[i])
+			i++
+		default:
+			out = append(out, "+ "+new[j])
+			j++
+		}
+	}
+	return strings.Join(out, "\n")
+}
@@ -0,0 +1,63 @@
+package config
+
+import (
+	"os"
+	"testing"
+
+	"github.com/srava/swiftssh/internal/testutil"
+)
+
+func TestBlockDiff(t *testing.T) {
+	cases := []struct {
+		name     string
+		old, new []string
+		want     string
+	}{
+		{
+			name: "add field",
+			old:  []string{"Host dev", "    Hostname dev.example.com"},
+			new:  []string{"Host dev", "    Hostname dev.example.com", "    User alice"},
+			want: "  Host dev\n      Hostname dev.example.com\n+     User alice",
+		},
+		{
+			name: "remove field",
+			old:  []string{"Host dev", "    Hostname dev.example.com", "    Port 2222"},
+			new:  []string{"Host dev", "    Hostname dev.example.com"},
+			want: "  Host dev\n      Hostname dev.example.com\n-     Port 2222",
+		},
+		{
+			name: "rename",
+			old:  []string{"Host dev", "    Hostname dev.example.com"},
+			new:  []string{"Host staging", "    Hostname dev.example.com"},
+			want: "- Host dev\n+ Host staging\n      Hostname dev.example.com",
+		},
+		{
+			name: "identical",
+			old:  []string{"Host dev"},
+			new:  []string{"Host dev"},
+			want: "  Host dev",
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			testutil.AssertStringEqual(t, BlockDiff(tc.old, tc.new), tc.want, "BlockDiff")
+		})
+	}
+}
+
+func TestPreviewReplace_DoesNotWrite(t *testing.T) {
+	content := "Host dev\n    Hostname old.example.com\n\nHost other\n    Hostname other.example.com\n"
+	path := writeHostConfig(t, content)
+	hosts, err := Parse(path)
+	testutil.AssertNoError(t, err, "Parse")
+
+	h := hosts[0]
+	h.Hostname = "new.example.com"
+	oldLines, newLines, err := PreviewReplace(h)
+	testutil.AssertNoError(t, err, "PreviewReplace")
+	testutil.AssertSliceEqual(t, oldLines, []string{"Host dev", "    Hostname old.example.com"}, "old block")
+	testutil.AssertSliceEqual(t, newLines, []string{"Host dev", "    Hostname new.example.com"}, "new block")
+
+	after, _ := os.ReadFile(path)
+	testutil.AssertStringEqual(t, string(after), content, "file must be unchanged")
+}
@@ -0,0 +1,90 @@
+package config
+
+import "strings"
+
+// groupDefaultTag starts a group-defaults magic comment:
+//
+//	# @group-default Work User=deploy IdentityFile=~/.ssh/work
+//
+// Hosts tagged with the group inherit these values for fields they leave unset.
+const groupDefaultTag = "@group-default"
+
+// groupDefaultKeys maps the lower-cased keys a group default may set to the
+// canonical keyword used in Host.Inherited.
+var groupDefaultKeys = map[string]string{
+	"user":         "User",
+	"identityfile": "IdentityFile",
+}
+
+// parseGroupDefault parses a "# @group-default <group> Key=Value ..." line.
+// The group name is every word before the first Key=Value pair, so it may
+// contain spaces. Unsupported keys are ignored. ok is false if line is not a
+// group-defaults comment or names no group.
+func parseGroupDefault(line string) (group string, settings map[string]string, ok bool) {
+	rest, ok := parseTag(line, groupDefaultTag)
+	if !ok {
+		return "", nil, false
+	}
+
+	var name []string
+	settings = make(map[string]string)
+	for _, word := range strings.Fields(rest) {
+		k, v, isPair := strings.Cut(word, "=")
+		if !isPair {
+			if len(settings) == 0 {
+				name = append(name, word)
+			}
+			continue
+		}
+		if key, known := groupDefaultKeys[strings.ToLower(k)]; known && v != "" {
+			settings[key] = strings.Trim(v, `"`)
+		}
+	}
+	if len(name) == 0 {
+		return "", nil, false
+	}
+	return strings.Join(name, " "), settings, true
+}
+
+// applyGroupDefaults fills unset User and IdentityFile fields of each host from
+// the defaults of its groups (looked up case-insensitively; the first group
+// that sets a field wins) and records what was filled in Host.Inherited.
+func applyGroupDefaults(hosts []Host, defaults map[string]map[string]string) {
+	if len(defaults) == 0 {
+		return
+	}
+	for i := range hosts {
+		h := &hosts[i]
+		for _, g := range h.Groups {
+			for key, value := range defaults[strings.ToLower(g)] {
+				field := h.inheritableField(key)
+				if field == nil || *field != "" {
+					continue
+				}
+				*field = value
+				if h.Inherited == nil {
+					h.Inherited = make(map[string]string)
+				}
+				h.Inherited[key] = value
+			}
+		}
+	}
+}
+
+// inheritableField returns a pointer to the field a group default key sets.
+func (h *Host) inheritableField(key string) *string {
+	switch key {
+	case "User":
+		return &h.User
+	case "IdentityFile":
+		return &h.IdentityFile
+	}
+	return nil
+}
+
+// isInherited reports whether value is exactly what h inherited for key from
+// a group default, so it need not be written into the host's own block.
+func (h Host) isInherited(key, value string) bool {
+	v, ok := h.Inherited[key]
+	return ok && v == value
+}
@@ -0,0 +1,83 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/srava/swiftssh/internal/testutil"
+)
+
+const groupDefaultsConfig = `# @group-default Work User=deploy IdentityFile=~/.ssh/work
+# @group Work
+Host api
+    Hostname api.corp
+
+# @group Work
+Host db
+    Hostname db.corp
+    User postgres
+
+# @group Personal
+Host pi
+    Hostname 192.168.1.2
+`
+
+func TestParse_GroupDefaultsInherited(t *testing.T) {
+	hosts, err := Parse(writeTempConfig(t, groupDefaultsConfig))
+	testutil.AssertNoError(t, err, "Parse should not error")
+	if len(hosts) != 3 {
+		t.Fatalf("expected 3 hosts, got %d", len(hosts))
+	}
+
+	api, db, pi := hosts[0], hosts[1], hosts[2]
+	testutil.AssertStringEqual(t, api.User, "deploy", "api should inherit User")
+	testutil.AssertStringEqual(t, api.IdentityFile, "~/.ssh/work", "api should inherit IdentityFile")
+	testutil.AssertStringEqual(t, api.Inherited["User"], "deploy", "api User should be marked inherited")
+	testutil.AssertSliceEqual(t, api.Groups, []string{"Work"}, "group-default line must not become a group")
+
+	testutil.AssertStringEqual(t, db.User, "postgres", "db keeps its own User")
+	_, inherited := db.Inherited["User"]
+	testutil.AssertFalse(t, inherited, "db User should not be marked inherited")
+
+	testutil.AssertEmpty(t, pi.User, "Personal hosts inherit nothing")
+}
+
+func TestParseGroupDefault(t *testing.T) {
+	group, settings, ok := parseGroupDefault("# @group-default Dev Team user=alice Port=2222")
+	testutil.AssertTrue(t, ok, "should parse")
+	testutil.AssertStringEqual(t, group, "Dev Team", "multi-word group name")
+	testutil.AssertStringEqual(t, settings["User"], "alice", "keys are case-insensitive")
+	testutil.AssertEqual(t, len(settings), 1, "unsupported keys are ignored")
+
+	_, _, ok = parseGroupDefault("# @group Work")
+	testutil.AssertFalse(t, ok, "@group is not a group default")
+}
+
+func TestReplaceHostBlock_InheritedFieldsNotWritten(t *testing.T) {
+	path := writeTempConfig(t, groupDefaultsConfig)
+	hosts, err := Parse(path)
+	testutil.AssertNoError(t, err, "Parse should not error")
+
+	api := hosts[0]
+	api.Hostname = "api2.corp"
+	if _, err := ReplaceHostBlock(api); err != nil {
+		t.Fatalf("ReplaceHostBlock: %v", err)
+	}
+	data, _ := os.ReadFile(path)
+	text := string(data)
+	block := text[strings.Index(text, "Host api"):strings.Index(text, "Host db")]
+	testutil.AssertContains(t, block, "Hostname api2.corp", "edit should be written")
+	testutil.AssertNotContains(t, block, "User deploy", "inherited User must not be written")
+	testutil.AssertNotContains(t, block, "IdentityFile", "inherited IdentityFile must not be written")
+
+	// Overriding the inherited value writes it.
+	hosts, _ = Parse(path)
+	api = hosts[0]
+	api.User = "root"
+	if _, err := ReplaceHostBlock(api); err != nil {
+		t.Fatalf("ReplaceHostBlock: %v", err)
+	}
+	data, _ = os.ReadFile(path)
+	testutil.AssertContains(t, string(data), "User root", "overridden User should be written")
+}
@@ -0,0 +1,25 @@
+package config
+
+import "path"
+
+// HideMatching marks Hidden every host whose alias or hostname matches one of
+// the glob patterns (path.Match syntax, e.g. "*.k8s.local"). Malformed
+// patterns match nothing.
+func HideMatching(hosts []Host, patterns []string) {
+	for i := range hosts {
+		for _, p := range patterns {
+			if globMatch(p, hosts[i].Alias) || globMatch(p, hosts[i].Hostname) {
+				hosts[i].Hidden = true
+				break
+			}
+		}
+	}
+}
+
+func globMatch(pattern, name string) bool {
+	if name == "" {
+		return false
+	}
+	ok, err := path.Match(pattern, name)
+	return err == nil && ok
+}
@@ -0,0 +1,29 @@
+package config
+
+import (
+	"testing"
+
+	"github.com/srava/swiftssh/internal/testutil"
+)
+
+func TestHideMatching_AliasAndHostname(t *testing.T) {
+	hosts := []Host{
+		{Alias: "web", Hostname: "web.example.com"},
+		{Alias: "node1", Hostname: "node1.k8s.local"},
+		{Alias: "gen-worker", Hostname: "10.0.0.5"},
+		{Alias: "db", Hostname: "db.example.com"},
+	}
+
+	HideMatching(hosts, []string{"*.k8s.local", "gen-*"})
+
+	testutil.AssertFalse(t, hosts[0].Hidden, "web should stay visible")
+	testutil.AssertTrue(t, hosts[1].Hidden, "hostname glob should hide node1")
+	testutil.AssertTrue(t, hosts[2].Hidden, "alias glob should hide gen-worker")
+	testutil.AssertFalse(t, hosts[3].Hidden, "db should stay visible")
+}
+
+func TestHideMatching_MalformedPatternMatchesNothing(t *testing.T) {
+	hosts := []Host{{Alias: "web", Hostname: "web"}}
+	HideMatching(hosts, []string{"[web"})
+	testutil.AssertFalse(t, hosts[0].Hidden, "malformed pattern should not hide")
+}
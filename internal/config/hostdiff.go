@@ -0,0 +1,112 @@
+package config
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// HostDiff is the structural difference between two sets of hosts, as
+// computed by DiffHosts.
+type HostDiff struct {
+	Added   []Host       // hosts only in the new set
+	Removed []Host       // hosts only in the old set
+	Changed []HostChange // hosts in both whose settings differ
+}
+
+// HostChange is one host present in both sets with different settings.
+type HostChange struct {
+	Old, New Host
+	Fields   []string // lower-case names of the changed fields, e.g. "hostname"
+}
+
+// Empty reports whether the two sets were structurally identical.
+func (d HostDiff) Empty() bool {
+	return len(d.Added) == 0 && len(d.Removed) == 0 && len(d.Changed) == 0
+}
+
+// DiffHosts compares old and new by alias. Source files and line numbers are
+// not compared, so a config can be diffed against a copy kept elsewhere; an
+// alias defined more than once is paired by occurrence. Added and Changed
+// follow new's order, Removed follows old's.
+func DiffHosts(old, new []Host) HostDiff {
+	oldByAlias := make(map[string][]Host)
+	for _, h := range old {
+		oldByAlias[h.Alias] = append(oldByAlias[h.Alias], h)
+	}
+
+	var d HostDiff
+	for _, h := range new {
+		prev := oldByAlias[h.Alias]
+		if len(prev) == 0 {
+			d.Added = append(d.Added, h)
+			continue
+		}
+		oldHost := prev[0]
+		oldByAlias[h.Alias] = prev[1:]
+		if fields := changedFields(oldHost, h); len(fields) > 0 {
+			d.Changed = append(d.Changed, HostChange{Old: oldHost, New: h, Fields: fields})
+		}
+	}
+
+	// Whatever was not paired off is gone; walk old to keep its order.
+	left := make(map[string]int)
+	for alias, hs := range oldByAlias {
+		left[alias] = len(hs)
+	}
+	for i := len(old) - 1; i >= 0; i-- {
+		if left[old[i].Alias] > 0 {
+			left[old[i].Alias]--
+			d.Removed = append(d.Removed, old[i])
+		}
+	}
+	slices.Reverse(d.Removed)
+	return d
+}
+
+// changedFields lists the settings that differ between a and b. Group order
+// and case are ignored, as they are for matching.
+func changedFields(a, b Host) []string {
+	var fields []string
+	pairs := []struct {
+		name     string
+		old, new string
+	}{
+		{"hostname", a.Hostname, b.Hostname},
+		{"user", a.User, b.User},
+		{"port", a.Port, b.Port},
+		{"identityfile", strings.Join(a.Identities(), ","), strings.Join(b.Identities(), ",")},
+		{"proxyjump", a.ProxyJump, b.ProxyJump},
+		{"after-local", a.AfterLocal, b.AfterLocal},
+		{"weight", strconv.Itoa(a.Weight), strconv.Itoa(b.Weight)},
+	}
+	for _, p := range pairs {
+		if p.old != p.new {
+			fields = append(fields, p.name)
+		}
+	}
+	if !sameGroups(a.Groups, b.Groups) {
+		fields = append(fields, "groups")
+	}
+	if !slices.Equal(a.ExtraLines, b.ExtraLines) {
+		fields = append(fields, "options")
+	}
+	return fields
+}
+
+// sameGroups reports whether a and b name the same groups, ignoring order and
+// case.
+func sameGroups(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	norm := func(groups []string) []string {
+		out := make([]string, len(groups))
+		for i, g := range groups {
+			out[i] = strings.ToLower(g)
+		}
+		slices.Sort(out)
+		return out
+	}
+	return slices.Equal(norm(a), norm(b))
+}
@@ -0,0 +1,87 @@
+package config
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/srava/swiftssh/internal/testutil"
+)
+
+func aliasesOf(hosts []Host) []string {
+	var out []string
+	for _, h := range hosts {
+		out = append(out, h.Alias)
+	}
+	return out
+}
+
+func TestDiffHosts_AddedAndRemoved(t *testing.T) {
+	old := []Host{{Alias: "web", Hostname: "w"}, {Alias: "legacy", Hostname: "l"}, {Alias: "db", Hostname: "d"}}
+	new := []Host{{Alias: "web", Hostname: "w"}, {Alias: "cache", Hostname: "c"}, {Alias: "db", Hostname: "d"}}
+
+	d := DiffHosts(old, new)
+	testutil.AssertSliceEqual(t, aliasesOf(d.Added), []string{"cache"}, "added")
+	testutil.AssertSliceEqual(t, aliasesOf(d.Removed), []string{"legacy"}, "removed")
+	testutil.AssertEqual(t, len(d.Changed), 0, "changed count")
+	testutil.AssertTrue(t, !d.Empty(), "diff should not be empty")
+}
+
+func TestDiffHosts_FieldChanges(t *testing.T) {
+	base := Host{Alias: "prod", Hostname: "p1", User: "deploy", Port: "22", Groups: []string{"Work", "Prod"}, Weight: 1}
+
+	tests := []struct {
+		name   string
+		modify func(h *Host)
+		want   []string
+	}{
+		{"hostname", func(h *Host) { h.Hostname = "p2" }, []string{"hostname"}},
+		{"user and port", func(h *Host) { h.User = "root"; h.Port = "2222" }, []string{"user", "port"}},
+		{"group added", func(h *Host) { h.Groups = []string{"Work", "Prod", "EU"} }, []string{"groups"}},
+		{"group renamed", func(h *Host) { h.Groups = []string{"Work", "Staging"} }, []string{"groups"}},
+		{"group order and case ignored", func(h *Host) { h.Groups = []string{"prod", "work"} }, nil},
+		{"unmodeled directive", func(h *Host) { h.ExtraLines = []string{"ForwardAgent yes"} }, []string{"options"}},
+		{"location ignored", func(h *Host) { h.SourceFile = "/elsewhere/config"; h.LineStart = 40 }, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			changed := base
+			changed.Groups = append([]string(nil), base.Groups...)
+			tt.modify(&changed)
+
+			d := DiffHosts([]Host{base}, []Host{changed})
+			if tt.want == nil {
+				testutil.AssertTrue(t, d.Empty(), "expected no differences")
+				return
+			}
+			if len(d.Changed) != 1 {
+				t.Fatalf("expected one changed host, got %+v", d)
+			}
+			testutil.AssertSliceEqual(t, d.Changed[0].Fields, tt.want, "changed fields")
+		})
+	}
+}
+
+func TestDiffHosts_DuplicateAliasPairedInOrder(t *testing.T) {
+	old := []Host{{Alias: "dup", Hostname: "a"}, {Alias: "dup", Hostname: "b"}}
+	new := []Host{{Alias: "dup", Hostname: "a"}}
+
+	d := DiffHosts(old, new)
+	if len(d.Removed) != 1 || d.Removed[0].Hostname != "b" {
+		t.Errorf("expected the second dup to be removed, got %+v", d.Removed)
+	}
+	testutil.AssertEqual(t, len(d.Changed), 0, "changed count")
+}
+
+func TestDiffHosts_ParsedConfigs(t *testing.T) {
+	oldPath := writeTempConfigAt(t, t.TempDir(), "config", "# @group Work\nHost prod\n  Hostname p1\n\nHost old\n  Hostname o\n")
+	newPath := writeTempConfigAt(t, t.TempDir(), "config", "# @group Work, Prod\nHost prod\n  Hostname p2\n\nHost new\n  Hostname n\n")
+	oldHosts, _ := Parse(oldPath)
+	newHosts, _ := Parse(newPath)
+
+	d := DiffHosts(oldHosts, newHosts)
+	testutil.AssertSliceEqual(t, aliasesOf(d.Added), []string{"new"}, "added")
+	testutil.AssertSliceEqual(t, aliasesOf(d.Removed), []string{"old"}, "removed")
+	if len(d.Changed) != 1 || strings.Join(d.Changed[0].Fields, ",") != "hostname,groups" {
+		t.Errorf("expected prod hostname and groups changed, got %+v", d.Changed)
+	}
+}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"runtime"
+)
+
+// IdentityPermsOK reports whether the host's IdentityFile is private enough for
+// ssh to accept it, i.e. it grants no permissions to group or others.
+// Hosts without an IdentityFile, and all hosts on Windows, report true.
+// An error is returned if the file cannot be stat'ed.
+func (h Host) IdentityPermsOK() (bool, error) {
+	if h.IdentityFile == "" || runtime.GOOS == "windows" {
+		return true, nil
+	}
+	path, err := ExpandIdentityPath(h.IdentityFile)
+	if err != nil {
+		return false, err
+	}
+	info, err := os.Stat(path)
+	if err != nil {
+		return false, err
+	}
+	return info.Mode().Perm()&0077 == 0, nil
+}
+
+// AuditIdentityPerms reports hosts whose IdentityFile is missing or readable
+// by group/others, which ssh rejects with "UNPROTECTED PRIVATE KEY FILE".
+func AuditIdentityPerms(hosts []Host) []Diagnostic {
+	var diags []Diagnostic
+	for _, h := range hosts {
+		ok, err := h.IdentityPermsOK()
+		var msg string
+		switch {
+		case err != nil:
+			msg = fmt.Sprintf("IdentityFile %q cannot be read: %v", h.IdentityFile, err)
+		case !ok:
+			msg = fmt.Sprintf("IdentityFile %q is accessible by group/others (run chmod 600)", h.IdentityFile)
+		default:
+			continue
+		}
+		diags = append(diags, Diagnostic{
+			Alias:      h.Alias,
+			SourceFile: h.SourceFile,
+			Line:       h.LineStart,
+			Message:    msg,
+		})
+	}
+	return diags
+}
+
+// ExpandIdentityPath expands a leading "~" and the "%d" (home directory) and
+// "%%" tokens ssh accepts in IdentityFile. Other tokens are left as they are.
+func ExpandIdentityPath(path string) (string, error) {
+	return expandPercent(path, func(c byte) (string, error) {
+		if c == 'd' || c == '%' {
+			return expandToken(c, Host{})
+		}
+		return "%" + string(c), nil
+	})
+}
+
+// KeyStatus describes one distinct IdentityFile referenced by the config.
+type KeyStatus struct {
+	Path     string   `json:"path"`            // IdentityFile as written in the config
+	Expanded string   `json:"expanded"`        // Path after ~ and %d expansion
+	Hosts    []string `json:"hosts"`           // Aliases referencing the key, in config order
+	Exists   bool     `json:"exists"`          // Whether Expanded could be stat'ed
+	PermsOK  bool     `json:"perms_ok"`        // Group/others have no access (always true on Windows)
+	Error    string   `json:"error,omitempty"` // Why the key could not be checked, if it could not
+}
+
+// CheckIdentityFiles returns one KeyStatus per distinct IdentityFile across
+// hosts, in order of first reference. Every key of a host with several
+// IdentityFile lines is included. Hosts without an IdentityFile are skipped.
+func CheckIdentityFiles(hosts []Host) []KeyStatus {
+	var keys []KeyStatus
+	index := make(map[string]int)
+	for _, h := range hosts {
+		for _, id := range h.Identities() {
+			if i, ok := index[id]; ok {
+				keys[i].Hosts = append(keys[i].Hosts, h.Alias)
+				continue
+			}
+			index[id] = len(keys)
+			keys = append(keys, checkIdentityFile(id, h.Alias))
+		}
+	}
+	return keys
+}
+
+// checkIdentityFile stats path and reports whether it exists and is private.
+func checkIdentityFile(path, alias string) KeyStatus {
+	ks := KeyStatus{Path: path, Hosts: []string{alias}}
+	expanded, err := ExpandIdentityPath(path)
+	if err != nil {
+		ks.Error = err.Error()
+		return ks
+	}
+	ks.Expanded = expanded
+	info, err := os.Stat(expanded)
+	if err != nil {
+		if !errors.Is(err, fs.ErrNotExist) {
+			ks.Error = err.Error()
+		}
+		return ks
+	}
+	ks.Exists = true
+	ks.PermsOK = runtime.GOOS == "windows" || info.Mode().Perm()&0077 == 0
+	return ks
+}
@@ -0,0 +1,126 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+// writeKey creates a fake private key file with the given permissions.
+func writeKey(t *testing.T, perm os.FileMode) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "id_test")
+	if err := os.WriteFile(path, []byte("private key"), 0600); err != nil {
+		t.Fatalf("failed to write key: %v", err)
+	}
+	if err := os.Chmod(path, perm); err != nil {
+		t.Fatalf("failed to chmod key: %v", err)
+	}
+	return path
+}
+
+func TestIdentityPermsOK(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permission bits are not enforced on Windows")
+	}
+
+	t.Run("0600 is accepted", func(t *testing.T) {
+		ok, err := Host{IdentityFile: writeKey(t, 0600)}.IdentityPermsOK()
+		if err != nil || !ok {
+			t.Errorf("expected ok=true err=nil, got ok=%v err=%v", ok, err)
+		}
+	})
+
+	t.Run("0644 is rejected", func(t *testing.T) {
+		ok, err := Host{IdentityFile: writeKey(t, 0644)}.IdentityPermsOK()
+		if err != nil || ok {
+			t.Errorf("expected ok=false err=nil, got ok=%v err=%v", ok, err)
+		}
+	})
+
+	t.Run("0640 group-readable is rejected", func(t *testing.T) {
+		ok, _ := Host{IdentityFile: writeKey(t, 0640)}.IdentityPermsOK()
+		if ok {
+			t.Error("expected group-readable key to be rejected")
+		}
+	})
+
+	t.Run("no IdentityFile is ok", func(t *testing.T) {
+		ok, err := Host{}.IdentityPermsOK()
+		if err != nil || !ok {
+			t.Errorf("expected ok=true err=nil, got ok=%v err=%v", ok, err)
+		}
+	})
+
+	t.Run("missing file errors", func(t *testing.T) {
+		_, err := Host{IdentityFile: filepath.Join(t.TempDir(), "missing")}.IdentityPermsOK()
+		if err == nil {
+			t.Error("expected error for missing key")
+		}
+	})
+}
+
+func TestAuditIdentityPerms(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permission bits are not enforced on Windows")
+	}
+	hosts := []Host{
+		{Alias: "good", IdentityFile: writeKey(t, 0600)},
+		{Alias: "open", IdentityFile: writeKey(t, 0644)},
+		{Alias: "nokey"},
+	}
+
+	diags := AuditIdentityPerms(hosts)
+	if len(diags) != 1 || diags[0].Alias != "open" {
+		t.Errorf("expected one diagnostic for 'open', got %v", diags)
+	}
+}
+
+func TestExpandIdentityPath(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	cases := map[string]string{
+		"~/.ssh/id_ed25519": filepath.Join(home, ".ssh/id_ed25519"),
+		"%d/.ssh/id_rsa":    home + "/.ssh/id_rsa",
+		"/keys/100%%/id":    "/keys/100%/id",
+		"/keys/%h/id":       "/keys/%h/id",
+		"/etc/ssh/host_key": "/etc/ssh/host_key",
+	}
+	for in, want := range cases {
+		got, err := ExpandIdentityPath(in)
+		if err != nil || got != want {
+			t.Errorf("ExpandIdentityPath(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+}
+
+func TestCheckIdentityFiles(t *testing.T) {
+	good := writeKey(t, 0600)
+	loose := writeKey(t, 0644)
+	missing := filepath.Join(t.TempDir(), "id_missing")
+	hosts := []Host{
+		{Alias: "a", IdentityFile: good},
+		{Alias: "b", IdentityFile: missing},
+		{Alias: "c"},
+		{Alias: "d", IdentityFile: good},
+		{Alias: "e", IdentityFile: loose, IdentityFiles: []string{loose, good}},
+	}
+
+	keys := CheckIdentityFiles(hosts)
+	if len(keys) != 3 {
+		t.Fatalf("expected 3 distinct keys, got %d: %+v", len(keys), keys)
+	}
+	if keys[0].Path != good || !keys[0].Exists || !keys[0].PermsOK || strings.Join(keys[0].Hosts, ",") != "a,d,e" {
+		t.Errorf("good key: %+v", keys[0])
+	}
+	if keys[1].Path != missing || keys[1].Exists || keys[1].Error != "" {
+		t.Errorf("missing key: %+v", keys[1])
+	}
+	if !keys[2].Exists || (runtime.GOOS != "windows" && keys[2].PermsOK) {
+		t.Errorf("loose key: %+v", keys[2])
+	}
+}
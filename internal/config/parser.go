@@ -2,52 +2,200 @@ package config
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"slices"
+	"strconv"
 	"strings"
 )
 
+// utf8BOM is the byte order mark some Windows editors write at the start of a file.
+const utf8BOM = "\ufeff"
+
+// bytesPerHostEstimate is a rough size of one host block, used to preallocate
+// the hosts slice from the file size.
+const bytesPerHostEstimate = 96
+
 // Parse reads the SSH config file at configPath and returns all hosts.
 // It handles Include directives with glob expansion and circular include detection.
 func Parse(configPath string) ([]Host, error) {
-	visited := make(map[string]bool)
-	return parseFile(configPath, visited)
+	var hosts []Host
+	if info, err := os.Stat(configPath); err == nil {
+		hosts = make([]Host, 0, info.Size()/bytesPerHostEstimate+1)
+	}
+	p := &parser{visited: make(map[string]bool)}
+	p.emit = func(h Host) error {
+		hosts = append(hosts, h)
+		return nil
+	}
+	if err := p.run(configPath); err != nil {
+		return nil, err
+	}
+	applyGroupDefaults(hosts, p.groupDefaults)
+	return hosts, nil
 }
 
-// parseFile is the recursive parser that handles a single config file.
-func parseFile(path string, visited map[string]bool) ([]Host, error) {
-	// Open file
-	file, err := os.Open(path)
-	if err != nil {
-		return nil, fmt.Errorf("failed to open config: %w", err)
+// ParseWithWildcards parses configPath like Parse but also returns "Host *"
+// blocks, as hosts with Alias "*". Audits need them because settings placed
+// there apply to every host.
+func ParseWithWildcards(configPath string) ([]Host, error) {
+	var hosts []Host
+	p := &parser{visited: make(map[string]bool), wildcards: true}
+	p.emit = func(h Host) error {
+		hosts = append(hosts, h)
+		return nil
 	}
-	defer file.Close()
+	if err := p.run(configPath); err != nil {
+		return nil, err
+	}
+	applyGroupDefaults(hosts, p.groupDefaults)
+	return hosts, nil
+}
+
+// ParseStream parses configPath like Parse but calls fn with each host as its
+// block ends instead of collecting them, so very large configs need not be
+// held in memory. Hosts arrive in the same order Parse returns them. Because
+// nothing is buffered, a "# @group-default" only applies to hosts that come
+// after it. If fn returns an error, parsing stops and that error is returned.
+func ParseStream(configPath string, fn func(Host) error) error {
+	p := &parser{visited: make(map[string]bool)}
+	p.emit = func(h Host) error {
+		hosts := []Host{h}
+		applyGroupDefaults(hosts, p.groupDefaults)
+		return fn(hosts[0])
+	}
+	return p.run(configPath)
+}
+
+// stopError wraps an error returned by a ParseStream callback so it is not
+// mistaken for an include failure, which only warns.
+type stopError struct{ err error }
 
+func (e stopError) Error() string { return e.err.Error() }
+
+// run parses configPath and its includes, passing each host to p.emit.
+func (p *parser) run(configPath string) error {
 	// Get absolute cleaned path for circular detection
-	absPath, err := filepath.Abs(path)
+	absPath, err := filepath.Abs(configPath)
 	if err != nil {
-		absPath = path // fallback if Abs fails
+		absPath = filepath.Clean(configPath) // fallback if Abs fails
+	}
+	err = p.parseFile(configPath, absPath)
+	var stop stopError
+	if errors.As(err, &stop) {
+		return stop.err
+	}
+	return err
+}
+
+// parser holds state shared across one Parse call and its includes.
+type parser struct {
+	visited map[string]bool
+	// emit receives each host as its block is finalized.
+	emit func(Host) error
+	// wildcards makes finish emit "*" patterns instead of skipping them.
+	wildcards bool
+	// groupDefaults collects "# @group-default" settings by lower-cased group
+	// name from every file; they apply once parsing is done, so order in the
+	// file does not matter.
+	groupDefaults map[string]map[string]string
+	// bufs holds scanner buffers free for reuse. Includes are parsed while
+	// the including file's scanner is still live, so each nesting level
+	// needs its own buffer.
+	bufs [][]byte
+}
+
+// addGroupDefault records settings for group; later values for a key win.
+func (p *parser) addGroupDefault(group string, settings map[string]string) {
+	if p.groupDefaults == nil {
+		p.groupDefaults = make(map[string]map[string]string)
+	}
+	key := strings.ToLower(group)
+	if p.groupDefaults[key] == nil {
+		p.groupDefaults[key] = make(map[string]string)
+	}
+	for k, v := range settings {
+		p.groupDefaults[key][k] = v
 	}
-	absPath = filepath.Clean(absPath)
+}
+
+func (p *parser) getBuf() []byte {
+	if n := len(p.bufs); n > 0 {
+		buf := p.bufs[n-1]
+		p.bufs = p.bufs[:n-1]
+		return buf
+	}
+	return make([]byte, 0, 4096)
+}
+
+func (p *parser) putBuf(buf []byte) {
+	p.bufs = append(p.bufs, buf[:0])
+}
+
+// finish emits h unless it is nil, defaulting its port to 22. A Host line
+// with several patterns ("Host web1 web2") yields one host per pattern, all
+// sharing the block's settings and LineStart; "*" patterns are skipped unless
+// p.wildcards is set.
+func (p *parser) finish(h *Host) error {
+	if h == nil {
+		return nil
+	}
+	if h.Port == "" {
+		h.Port = "22"
+	}
+	for _, alias := range strings.Fields(h.Alias) {
+		if alias == "*" && !p.wildcards {
+			continue
+		}
+		split := *h
+		split.Alias = alias
+		// Each host gets its own slices so later edits to one don't leak.
+		split.Groups = slices.Clone(h.Groups)
+		split.ExtraLines = slices.Clone(h.ExtraLines)
+		split.IdentityFiles = slices.Clone(h.IdentityFiles)
+		if err := p.emit(split); err != nil {
+			return stopError{err}
+		}
+	}
+	return nil
+}
 
+// parseFile is the recursive parser that handles a single config file.
+// absPath is the absolute, cleaned form of path, used for circular detection.
+func (p *parser) parseFile(path, absPath string) error {
 	// Check for circular include
-	if visited[absPath] {
-		return nil, nil // silently skip already visited files
+	if p.visited[absPath] {
+		return nil // silently skip already visited files
 	}
-	visited[absPath] = true
 
-	var hosts []Host
+	// Open file
+	file, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("failed to open config: %w", err)
+	}
+	defer file.Close()
+	p.visited[absPath] = true
+
 	var current *Host
 	var prevLine string
 	var lineNum int
 
+	buf := p.getBuf()
+	defer func() { p.putBuf(buf) }()
 	scanner := bufio.NewScanner(file)
-	configDir := filepath.Dir(path)
+	scanner.Buffer(buf, bufio.MaxScanTokenSize)
+	// Resolve relative includes against the including file's absolute directory
+	// so results don't depend on the process working directory.
+	configDir := filepath.Dir(absPath)
 
 	for scanner.Scan() {
 		line := scanner.Text()
 		lineNum++
+		if lineNum == 1 {
+			line = strings.TrimPrefix(line, utf8BOM)
+		}
 
 		// Find first whitespace to split keyword and value
 		trimmed := strings.TrimSpace(line)
@@ -55,31 +203,41 @@ func parseFile(path string, visited map[string]bool) ([]Host, error) {
 		// Handle empty lines and all comment lines (including magic comments).
 		// Magic comments set prevLine so the next Host directive can pick up groups.
 		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			// A "# @group" comment directly under the Host line belongs to
+			// that host; it must not also tag a Host line that follows.
+			if current != nil && lineNum == current.LineStart+1 {
+				if groups := parseMagicComment(line); groups != nil {
+					current.Groups = mergeGroups(current.Groups, groups)
+					prevLine = ""
+					continue
+				}
+			}
+			if group, settings, ok := parseGroupDefault(trimmed); ok {
+				p.addGroupDefault(group, settings)
+			}
+			if cmd, ok := parseAfterLocal(trimmed); ok && current != nil {
+				current.AfterLocal = cmd
+			}
+			if weight, ok := parseWeight(trimmed); ok && current != nil {
+				current.Weight = weight
+			}
 			prevLine = line
 			continue
 		}
 
-		// Parse keyword and value
-		idx := strings.IndexAny(trimmed, " \t")
-		if idx == -1 {
+		keyword, value, ok := splitDirective(trimmed)
+		if !ok {
 			// keyword only, no value
 			prevLine = line
 			continue
 		}
 
-		keyword := trimmed[:idx]
-		value := strings.TrimSpace(trimmed[idx+1:])
-
 		// Handle directives
 		switch strings.ToLower(keyword) {
 		case "host":
 			// Finalize previous host if exists and not wildcard
-			if current != nil && current.Alias != "*" {
-				// Set default port if not specified
-				if current.Port == "" {
-					current.Port = "22"
-				}
-				hosts = append(hosts, *current)
+			if err := p.finish(current); err != nil {
+				return err
 			}
 			// Start new host block
 			current = &Host{
@@ -87,6 +245,7 @@ func parseFile(path string, visited map[string]bool) ([]Host, error) {
 				SourceFile: path,
 				Groups:     parseMagicComment(prevLine),
 				LineStart:  lineNum,
+				Weight:     1,
 			}
 
 		case "hostname":
@@ -104,20 +263,35 @@ func parseFile(path string, visited map[string]bool) ([]Host, error) {
 				current.Port = value
 			}
 
+		case "proxyjump":
+			if current != nil {
+				current.ProxyJump = value
+			}
+
 		case "identityfile":
 			if current != nil {
-				current.IdentityFile = strings.Trim(value, `"`)
+				// ssh offers every IdentityFile in order, so keep them all.
+				path := strings.Trim(value, `"`)
+				current.IdentityFiles = append(current.IdentityFiles, path)
+				if current.IdentityFile == "" {
+					current.IdentityFile = path
+				}
+			}
+
+		case "match":
+			// A Match block ends the host; its directives apply conditionally
+			// and must not be attributed to (or rewritten with) the host.
+			if err := p.finish(current); err != nil {
+				return err
 			}
+			current = nil
 
 		case "include":
 			// Finalize current host if any before processing global directive
-			if current != nil && current.Alias != "*" {
-				if current.Port == "" {
-					current.Port = "22"
-				}
-				hosts = append(hosts, *current)
-				current = nil
+			if err := p.finish(current); err != nil {
+				return err
 			}
+			current = nil
 
 			// Process include directive
 			expanded, err := expandTilde(value)
@@ -132,8 +306,13 @@ func parseFile(path string, visited map[string]bool) ([]Host, error) {
 				expanded = filepath.Join(configDir, expanded)
 			}
 
-			// Glob expansion
-			matches, err := filepath.Glob(expanded)
+			// Glob expansion; "**" needs a directory walk, which plain
+			// patterns skip.
+			glob := filepath.Glob
+			if strings.Contains(expanded, "**") {
+				glob = globStar
+			}
+			matches, err := glob(expanded)
 			if err != nil {
 				fmt.Fprintf(os.Stderr, "sssh: warning: include %q: glob error: %v\n", value, err)
 				prevLine = line
@@ -148,44 +327,94 @@ func parseFile(path string, visited map[string]bool) ([]Host, error) {
 
 			// Recursively parse each matched file
 			for _, match := range matches {
-				// Get absolute cleaned path
-				absMatch, cleanErr := filepath.Abs(match)
-				if cleanErr != nil {
-					absMatch = match
-				}
-				absMatch = filepath.Clean(absMatch)
+				// expanded is absolute, so matches already are; they only
+				// need cleaning to compare against visited.
+				absMatch := filepath.Clean(match)
 
 				// Check if already visited (avoid infinite recursion)
-				if visited[absMatch] {
+				if p.visited[absMatch] {
 					continue
 				}
 
 				// Recursively parse
-				includedHosts, parseErr := parseFile(match, visited)
-				if parseErr != nil {
+				if parseErr := p.parseFile(match, absMatch); parseErr != nil {
+					var stop stopError
+					if errors.As(parseErr, &stop) {
+						return parseErr
+					}
 					fmt.Fprintf(os.Stderr, "sssh: warning: include %q: %v\n", match, parseErr)
-					continue
 				}
-				hosts = append(hosts, includedHosts...)
+			}
+
+		default:
+			// Preserve directives we don't model so rewrites don't drop them.
+			if current != nil {
+				current.ExtraLines = append(current.ExtraLines, trimmed)
 			}
 		}
 
 		prevLine = line
 	}
 
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("error reading config: %w", err)
+	}
+
 	// Finalize last open host block
-	if current != nil && current.Alias != "*" {
-		if current.Port == "" {
-			current.Port = "22"
-		}
-		hosts = append(hosts, *current)
+	return p.finish(current)
+}
+
+// splitDirective splits a trimmed config line into its keyword and value. As
+// in OpenSSH, the keyword ends at whitespace or "=", and one "=" may separate
+// it from the value with or without spaces: "Port 22", "Port=22", "Port = 22".
+// ok is false when there is no separator, i.e. a bare keyword.
+// A trailing inline comment is stripped from the value.
+func splitDirective(trimmed string) (keyword, value string, ok bool) {
+	idx := strings.IndexAny(trimmed, " \t=")
+	if idx == -1 {
+		return trimmed, "", false
 	}
+	keyword = trimmed[:idx]
+	rest := strings.TrimLeft(trimmed[idx:], " \t")
+	rest = strings.TrimPrefix(rest, "=")
+	return keyword, stripInlineComment(strings.TrimSpace(rest)), true
+}
 
-	if err := scanner.Err(); err != nil {
-		return nil, fmt.Errorf("error reading config: %w", err)
+// stripInlineComment drops a trailing "# ..." comment from a directive value.
+// Like OpenSSH, "#" only starts a comment at the beginning of a word and is
+// literal inside double quotes, so "Hostname a#b" and "ProxyCommand \"x # y\""
+// keep their "#".
+func stripInlineComment(value string) string {
+	if i := inlineCommentIndex(value); i != -1 {
+		return strings.TrimRight(value[:i], " \t")
 	}
+	return value
+}
 
-	return hosts, nil
+// inlineCommentIndex returns the index of the "#" starting s's inline comment,
+// or -1 if it has none.
+func inlineCommentIndex(s string) int {
+	inQuote := false
+	for i := 0; i < len(s); i++ {
+		switch c := s[i]; {
+		case c == '"':
+			inQuote = !inQuote
+		case c == '#' && !inQuote && (i == 0 || s[i-1] == ' ' || s[i-1] == '\t'):
+			return i
+		}
+	}
+	return -1
+}
+
+// mergeGroups appends the groups in extra that groups does not already have,
+// compared case-insensitively.
+func mergeGroups(groups, extra []string) []string {
+	for _, g := range extra {
+		if !slices.ContainsFunc(groups, func(have string) bool { return strings.EqualFold(have, g) }) {
+			groups = append(groups, g)
+		}
+	}
+	return groups
 }
 
 // parseMagicComment extracts groups from a magic comment line.
@@ -198,7 +427,7 @@ func parseMagicComment(line string) []string {
 	}
 
 	rest := strings.TrimSpace(trimmed[1:])
-	if !strings.HasPrefix(rest, "@group") {
+	if !strings.HasPrefix(rest, "@group") || strings.HasPrefix(rest, groupDefaultTag) {
 		return nil
 	}
 
@@ -225,6 +454,113 @@ func parseMagicComment(line string) []string {
 	return groups
 }
 
+// afterLocalTag starts a magic comment inside a host block naming a local
+// command to run after connecting: once ssh returns successfully, or once a
+// Ctrl+O window has opened: "# @after-local open http://localhost:8080".
+const afterLocalTag = "@after-local"
+
+// parseAfterLocal returns the command from an "# @after-local <cmd>" line.
+func parseAfterLocal(line string) (string, bool) {
+	cmd, ok := parseTag(line, afterLocalTag)
+	return cmd, ok && cmd != ""
+}
+
+// weightTag starts a magic comment inside a host block that multiplies its
+// connection count when ordering by frequency: "# @weight 3".
+const weightTag = "@weight"
+
+// parseWeight returns N from an "# @weight N" line. N must be a positive
+// integer; anything else is ignored.
+func parseWeight(line string) (int, bool) {
+	value, ok := parseTag(line, weightTag)
+	if !ok {
+		return 0, false
+	}
+	n, err := strconv.Atoi(value)
+	if err != nil || n < 1 {
+		return 0, false
+	}
+	return n, true
+}
+
+// parseTag returns the text after tag in a "# <tag> ..." magic comment, with
+// surrounding whitespace removed. ok is false unless line is a comment whose
+// first word is exactly tag.
+func parseTag(line, tag string) (rest string, ok bool) {
+	trimmed := strings.TrimSpace(line)
+	if !strings.HasPrefix(trimmed, "#") {
+		return "", false
+	}
+	rest, ok = strings.CutPrefix(strings.TrimSpace(trimmed[1:]), tag)
+	if !ok || (rest != "" && rest[0] != ' ' && rest[0] != '\t') {
+		return "", false
+	}
+	return strings.TrimSpace(rest), true
+}
+
+// maxGlobStarDepth caps how many directories deep "**" in an Include
+// pattern descends.
+const maxGlobStarDepth = 8
+
+// globStar expands an absolute pattern containing "**", which matches zero
+// or more directories: "conf/**/*.conf" matches conf/a.conf and
+// conf/x/y/b.conf. Symlinked directories are followed, but each directory is
+// visited once, so a symlink cycle cannot loop. Only the first "**" is
+// special, and only as a whole path element; otherwise it acts like "*".
+// Matches are sorted.
+func globStar(pattern string) ([]string, error) {
+	idx := strings.Index(pattern, "**")
+	end := idx + 2
+	if (idx > 0 && !os.IsPathSeparator(pattern[idx-1])) || (end < len(pattern) && !os.IsPathSeparator(pattern[end])) {
+		return filepath.Glob(pattern)
+	}
+	root := filepath.Clean(pattern[:idx])
+	rest := strings.TrimLeft(pattern[end:], `/\`)
+	if rest == "" {
+		rest = "*"
+	}
+	// Check the pattern once up front, as filepath.Glob does.
+	if _, err := filepath.Match(rest, ""); err != nil {
+		return nil, err
+	}
+
+	seen := make(map[string]bool)
+	var matches []string
+	var walk func(dir string, depth int)
+	walk = func(dir string, depth int) {
+		real, err := filepath.EvalSymlinks(dir)
+		if err != nil || seen[real] {
+			return
+		}
+		seen[real] = true
+
+		found, _ := filepath.Glob(filepath.Join(dir, rest))
+		matches = append(matches, found...)
+
+		if depth == maxGlobStarDepth {
+			return
+		}
+		entries, err := os.ReadDir(dir)
+		if err != nil {
+			return
+		}
+		for _, e := range entries {
+			sub := filepath.Join(dir, e.Name())
+			if e.IsDir() {
+				walk(sub, depth+1)
+			} else if e.Type()&os.ModeSymlink != 0 {
+				if info, err := os.Stat(sub); err == nil && info.IsDir() {
+					walk(sub, depth+1)
+				}
+			}
+		}
+	}
+	walk(root, 0)
+
+	slices.Sort(matches)
+	return slices.Compact(matches), nil
+}
+
 // expandTilde expands ~ to home directory.
 func expandTilde(path string) (string, error) {
 	if strings.HasPrefix(path, "~/") || strings.HasPrefix(path, `~\`) {
@@ -1,12 +1,15 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"os"
 	"path/filepath"
+	"reflect"
 	"strings"
 	"testing"
 	"time"
+	"unicode"
 
 	"github.com/srava/swiftssh/internal/testutil"
 )
@@ -344,6 +347,142 @@ User john
 	testutil.AssertStringEqual(t, hosts[0].Alias, "myserver", "Only myserver should be in results")
 }
 
+// TestParse_MultiAliasHostLine verifies that a Host line with several patterns
+// yields one host per pattern sharing the block's settings.
+func TestParse_MultiAliasHostLine(t *testing.T) {
+	content := `# @group Web
+Host web1 web2  web3 *
+  Hostname lb.example.com
+  User deploy
+  Port 2222
+  IdentityFile ~/.ssh/id_web
+  IdentityFile ~/.ssh/id_old
+`
+	configPath := writeTempConfig(t, content)
+	hosts, err := Parse(configPath)
+	testutil.AssertNoError(t, err, "Parse should not error")
+	if len(hosts) != 3 {
+		t.Fatalf("expected 3 hosts, got %d: %+v", len(hosts), hosts)
+	}
+
+	for i, want := range []string{"web1", "web2", "web3"} {
+		h := hosts[i]
+		testutil.AssertStringEqual(t, h.Alias, want, "alias")
+		testutil.AssertStringEqual(t, h.Hostname, "lb.example.com", want+" hostname")
+		testutil.AssertStringEqual(t, h.User, "deploy", want+" user")
+		testutil.AssertStringEqual(t, h.Port, "2222", want+" port")
+		testutil.AssertSliceEqual(t, h.Groups, []string{"Web"}, want+" groups")
+		testutil.AssertEqual(t, h.LineStart, 2, want+" LineStart")
+	}
+
+	// Each host owns its slices; changing one must not show through another.
+	hosts[0].IdentityFiles[1] = "~/.ssh/id_changed"
+	testutil.AssertStringEqual(t, hosts[1].IdentityFiles[1], "~/.ssh/id_old", "web2 IdentityFiles after editing web1")
+}
+
+// TestParse_EqualsSeparator verifies the "keyword=value" form OpenSSH accepts,
+// with or without spaces around "=", alongside the usual space form.
+func TestParse_EqualsSeparator(t *testing.T) {
+	content := `Host=dev
+  Hostname=example.com
+  User = deploy
+  Port= 2222
+  IdentityFile ="~/.ssh/id_dev"
+
+Host prod
+  Hostname prod.example.com
+  ForwardAgent=yes
+`
+	configPath := writeTempConfig(t, content)
+	hosts, err := Parse(configPath)
+	testutil.AssertNoError(t, err, "Parse should not error")
+	if len(hosts) != 2 {
+		t.Fatalf("expected 2 hosts, got %d: %+v", len(hosts), hosts)
+	}
+
+	dev := hosts[0]
+	testutil.AssertStringEqual(t, dev.Alias, "dev", "alias from Host=dev")
+	testutil.AssertStringEqual(t, dev.Hostname, "example.com", "Hostname=")
+	testutil.AssertStringEqual(t, dev.User, "deploy", "User = ")
+	testutil.AssertStringEqual(t, dev.Port, "2222", "Port= ")
+	testutil.AssertStringEqual(t, dev.IdentityFile, "~/.ssh/id_dev", "IdentityFile =")
+
+	prod := hosts[1]
+	testutil.AssertStringEqual(t, prod.Hostname, "prod.example.com", "space form still parses")
+	if v, ok := prod.Directive("ForwardAgent"); !ok || v != "yes" {
+		t.Errorf("Directive(ForwardAgent) = %q, %v; want \"yes\", true", v, ok)
+	}
+}
+
+// TestParse_InlineComments verifies that a " #" comment after a value is
+// dropped, while a "#" inside a word or inside double quotes is kept.
+func TestParse_InlineComments(t *testing.T) {
+	content := `# @group Work
+Host prod  # production box
+  Hostname prod.example.com	# primary
+  Port 2222 # non-standard
+  User=deploy #deploy user
+  ProxyCommand "nc -X 5 -x proxy # 1 %h %p"
+
+Host tagged#1
+  Hostname a#b.example.com
+`
+	configPath := writeTempConfig(t, content)
+	hosts, err := Parse(configPath)
+	testutil.AssertNoError(t, err, "Parse should not error")
+	if len(hosts) != 2 {
+		t.Fatalf("expected 2 hosts, got %d: %+v", len(hosts), hosts)
+	}
+
+	prod := hosts[0]
+	testutil.AssertStringEqual(t, prod.Alias, "prod", "alias without comment")
+	testutil.AssertStringEqual(t, prod.Hostname, "prod.example.com", "Hostname without comment")
+	testutil.AssertStringEqual(t, prod.Port, "2222", "Port without comment")
+	testutil.AssertStringEqual(t, prod.User, "deploy", "User= without comment")
+	testutil.AssertStringEqual(t, strings.Join(prod.Groups, ","), "Work", "groups")
+	if v, ok := prod.Directive("ProxyCommand"); !ok || v != `"nc -X 5 -x proxy # 1 %h %p"` {
+		t.Errorf("Directive(ProxyCommand) = %q, %v; want quoted # kept", v, ok)
+	}
+
+	// "#" inside a word is not a comment.
+	testutil.AssertStringEqual(t, hosts[1].Alias, "tagged#1", "# mid-word")
+	testutil.AssertStringEqual(t, hosts[1].Hostname, "a#b.example.com", "# mid-word")
+}
+
+// TestParse_MagicCommentAfterHostLine verifies a "# @group" comment on the
+// first line inside a block tags that host, merged with one before it.
+func TestParse_MagicCommentAfterHostLine(t *testing.T) {
+	content := `Host web
+# @group Work, Frontend
+  Hostname web.example.com
+
+# @group Work
+Host db
+  # @group work, Databases
+  Hostname db.example.com
+
+Host bare
+# @group Lab
+Host next
+  Hostname next.example.com
+`
+	configPath := writeTempConfig(t, content)
+	hosts, err := Parse(configPath)
+	testutil.AssertNoError(t, err, "Parse should not error")
+	if len(hosts) != 4 {
+		t.Fatalf("expected 4 hosts, got %d", len(hosts))
+	}
+
+	testutil.AssertSliceEqual(t, hosts[0].Groups, []string{"Work", "Frontend"}, "after-Host groups")
+	testutil.AssertStringEqual(t, hosts[0].Hostname, "web.example.com", "Hostname after the comment")
+	testutil.AssertSliceEqual(t, hosts[1].Groups, []string{"Work", "Databases"}, "before and after merged")
+	// A comment right under a Host line belongs to that host, not the next.
+	testutil.AssertSliceEqual(t, hosts[2].Groups, []string{"Lab"}, "bare host groups")
+	if len(hosts[3].Groups) != 0 {
+		t.Errorf("expected next to have no groups, got %v", hosts[3].Groups)
+	}
+}
+
 // TestParse_LineStart verifies that LineStart is correctly tracked for each host block.
 func TestParse_LineStart(t *testing.T) {
 	t.Run("single host at line 1", func(t *testing.T) {
@@ -553,6 +692,26 @@ func TestParse_LargeConfig_CompletesIn1s(t *testing.T) {
 	}
 }
 
+// TestParse_MultipleIdentityFiles verifies that every IdentityFile line is
+// kept in order, each unquoted, with the first one in IdentityFile.
+func TestParse_MultipleIdentityFiles(t *testing.T) {
+	content := "Host multi\n    Hostname multi.example.com\n    IdentityFile ~/.ssh/id_ed25519\n    IdentityFile \"/keys/legacy rsa\"\n    IdentityFile ~/.ssh/id_ecdsa\n"
+	hosts, err := Parse(writeTempConfig(t, content))
+	testutil.AssertNoError(t, err, "Parse should succeed")
+	if len(hosts) != 1 {
+		t.Fatalf("expected 1 host, got %d", len(hosts))
+	}
+
+	want := []string{"~/.ssh/id_ed25519", "/keys/legacy rsa", "~/.ssh/id_ecdsa"}
+	if !reflect.DeepEqual(hosts[0].IdentityFiles, want) {
+		t.Errorf("IdentityFiles = %q; want %q", hosts[0].IdentityFiles, want)
+	}
+	testutil.AssertStringEqual(t, hosts[0].IdentityFile, want[0], "IdentityFile is the first key")
+	if !reflect.DeepEqual(hosts[0].Identities(), want) {
+		t.Errorf("Identities() = %q; want %q", hosts[0].Identities(), want)
+	}
+}
+
 // TestParse_IdentityFileStripsQuotes verifies that a quoted IdentityFile value is stored
 // without surrounding quotes, so buildHostBlock doesn't double-quote it on save.
 func TestParse_IdentityFileStripsQuotes(t *testing.T) {
@@ -586,3 +745,504 @@ func TestParse_IdentityFileStripsQuotes(t *testing.T) {
 		}
 	})
 }
+
+// TestParse_ProxyJump verifies ProxyJump is parsed into its own field rather
+// than ExtraLines, and stays with its host.
+func TestParse_ProxyJump(t *testing.T) {
+	content := "Host internal\n    Hostname 10.1.0.5\n    ProxyJump bastion\n\nHost bastion\n    Hostname bastion.example.com\n"
+	hosts, err := Parse(writeTempConfig(t, content))
+	testutil.AssertNoError(t, err, "Parse should not error")
+	if len(hosts) != 2 {
+		t.Fatalf("expected 2 hosts, got %d", len(hosts))
+	}
+	testutil.AssertStringEqual(t, hosts[0].ProxyJump, "bastion", "internal ProxyJump")
+	testutil.AssertEqual(t, len(hosts[0].ExtraLines), 0, "ProxyJump should not fall through to ExtraLines")
+	testutil.AssertStringEqual(t, hosts[1].ProxyJump, "", "bastion ProxyJump")
+}
+
+// TestParse_MatchEndsHost verifies that a Match line ends the host before it,
+// so the Match block's directives are not kept in the host's ExtraLines.
+func TestParse_MatchEndsHost(t *testing.T) {
+	content := `Host web
+    Hostname 10.0.0.5
+    Compression yes
+
+Match host *.corp
+    ForwardAgent yes
+    User corp
+`
+	hosts, err := Parse(writeTempConfig(t, content))
+	testutil.AssertNoError(t, err, "Parse should not error")
+	if len(hosts) != 1 {
+		t.Fatalf("expected 1 host, got %d", len(hosts))
+	}
+	testutil.AssertSliceEqual(t, hosts[0].ExtraLines, []string{"Compression yes"}, "web ExtraLines")
+	testutil.AssertStringEqual(t, hosts[0].User, "", "User from the Match block")
+}
+
+// TestParse_UnmodeledDirectivesCaptured verifies that directives SwiftSSH does not
+// model are kept verbatim in ExtraLines, in file order.
+func TestParse_UnmodeledDirectivesCaptured(t *testing.T) {
+	content := `Host legacy
+    Hostname 10.0.0.9
+    Ciphers aes128-cbc
+    KexAlgorithms diffie-hellman-group1-sha1
+    MACs hmac-sha1
+
+Host modern
+    Hostname modern.example.com
+`
+	hosts, err := Parse(writeTempConfig(t, content))
+	testutil.AssertNoError(t, err, "Parse should not error")
+	if len(hosts) != 2 {
+		t.Fatalf("expected 2 hosts, got %d", len(hosts))
+	}
+
+	testutil.AssertSliceEqual(t, hosts[0].ExtraLines, []string{
+		"Ciphers aes128-cbc",
+		"KexAlgorithms diffie-hellman-group1-sha1",
+		"MACs hmac-sha1",
+	}, "legacy ExtraLines")
+	if len(hosts[1].ExtraLines) != 0 {
+		t.Errorf("expected no ExtraLines for modern, got %v", hosts[1].ExtraLines)
+	}
+
+	v, ok := hosts[0].Directive("ciphers")
+	testutil.AssertTrue(t, ok, "Directive should find Ciphers case-insensitively")
+	testutil.AssertStringEqual(t, v, "aes128-cbc", "Ciphers value")
+}
+
+func TestParse_BooleanDirectivesInOptions(t *testing.T) {
+	content := `Host dev
+    Hostname dev.example.com
+    ForwardAgent yes
+    Compression=yes
+    forwardagent no
+`
+	hosts, err := Parse(writeTempConfig(t, content))
+	testutil.AssertNoError(t, err, "Parse should not error")
+	if len(hosts) != 1 {
+		t.Fatalf("expected 1 host, got %d", len(hosts))
+	}
+
+	opts := hosts[0].Options()
+	testutil.AssertStringEqual(t, opts["forwardagent"], "yes", "first ForwardAgent wins")
+	testutil.AssertStringEqual(t, opts["compression"], "yes", "Compression=")
+	if len(opts) != 2 {
+		t.Errorf("Options() = %v; want 2 entries", opts)
+	}
+}
+
+// TestParse_BOMAndBlankFiles verifies a leading UTF-8 BOM does not hide the
+// first Host line and that whitespace-only files yield no hosts.
+func TestParse_BOMAndBlankFiles(t *testing.T) {
+	t.Run("BOM prefix", func(t *testing.T) {
+		hosts, err := Parse(writeTempConfig(t, "\ufeffHost first\n    Hostname first.example.com\n\nHost second\n    Hostname second.example.com\n"))
+		testutil.AssertNoError(t, err, "Parse should not error")
+		if len(hosts) != 2 {
+			t.Fatalf("expected 2 hosts, got %d", len(hosts))
+		}
+		testutil.AssertStringEqual(t, hosts[0].Alias, "first", "first alias")
+		testutil.AssertStringEqual(t, hosts[0].Hostname, "first.example.com", "first hostname")
+	})
+
+	t.Run("whitespace only", func(t *testing.T) {
+		hosts, err := Parse(writeTempConfig(t, "   \n\n\t\n  \n"))
+		testutil.AssertNoError(t, err, "Parse should not error")
+		if len(hosts) != 0 {
+			t.Errorf("expected no hosts, got %d", len(hosts))
+		}
+	})
+}
+
+// TestParse_BatchModeAndLogLevel verifies that BatchMode and LogLevel are
+// readable through Directive so callers can see what probes override.
+func TestParse_BatchModeAndLogLevel(t *testing.T) {
+	hosts, err := Parse(writeTempConfig(t, "Host chatty\n    Hostname 10.0.0.3\n    BatchMode no\n    LogLevel DEBUG3\n"))
+	testutil.AssertNoError(t, err, "Parse should not error")
+
+	v, ok := hosts[0].Directive("BatchMode")
+	testutil.AssertTrue(t, ok, "BatchMode should be captured")
+	testutil.AssertStringEqual(t, v, "no", "BatchMode value")
+
+	v, ok = hosts[0].Directive("loglevel")
+	testutil.AssertTrue(t, ok, "LogLevel should be captured")
+	testutil.AssertStringEqual(t, v, "DEBUG3", "LogLevel value")
+}
+
+// TestParse_RelativeMainPathWithRelativeInclude verifies that a relative main
+// config path with a relative Include resolves against the including file's
+// directory, not the process working directory.
+func TestParse_RelativeMainPathWithRelativeInclude(t *testing.T) {
+	tempDir := t.TempDir()
+	writeTempConfigAt(t, tempDir, "project/myconfig", "Host main\nHostname main.example.com\n\nInclude conf/x.conf\n")
+	includedPath := writeTempConfigAt(t, tempDir, "project/conf/x.conf", "Host included\nHostname included.example.com\n")
+
+	// A decoy at the same relative path under the working directory must not be used.
+	writeTempConfigAt(t, tempDir, "conf/x.conf", "Host decoy\nHostname decoy.example.com\n")
+
+	wd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Getwd failed: %v", err)
+	}
+	t.Cleanup(func() { _ = os.Chdir(wd) })
+
+	for _, tc := range []struct{ cwd, path string }{
+		{tempDir, filepath.Join("project", "myconfig")},
+		{filepath.Join(tempDir, "project"), "myconfig"},
+		{filepath.Join(tempDir, "project", "conf"), filepath.Join("..", "myconfig")},
+	} {
+		if err := os.Chdir(tc.cwd); err != nil {
+			t.Fatalf("Chdir failed: %v", err)
+		}
+		hosts, err := Parse(tc.path)
+		testutil.AssertNoError(t, err, "Parse should not error")
+		if len(hosts) != 2 {
+			t.Fatalf("cwd=%s path=%s: expected 2 hosts, got %d", tc.cwd, tc.path, len(hosts))
+		}
+		testutil.AssertStringEqual(t, hosts[1].Alias, "included", "included host alias")
+		testutil.AssertStringEqual(t, hosts[1].SourceFile, includedPath, "included host SourceFile should be absolute")
+	}
+}
+
+// TestParse_TabAndMixedSeparators verifies keyword/value splitting with multiple
+// tabs and mixed space/tab runs.
+func TestParse_TabAndMixedSeparators(t *testing.T) {
+	content := "Host\t\tmyhost\n" +
+		"\tHostname\t\texample.com\n" +
+		"  User \t alice\n" +
+		"\tPort\t \t2222\n"
+	hosts, err := Parse(writeTempConfig(t, content))
+	testutil.AssertNoError(t, err, "Parse should not error")
+	if len(hosts) != 1 {
+		t.Fatalf("expected 1 host, got %d", len(hosts))
+	}
+	testutil.AssertStringEqual(t, hosts[0].Alias, "myhost", "Alias")
+	testutil.AssertStringEqual(t, hosts[0].Hostname, "example.com", "Hostname")
+	testutil.AssertStringEqual(t, hosts[0].User, "alice", "User")
+	testutil.AssertStringEqual(t, hosts[0].Port, "2222", "Port")
+}
+
+// TestParse_KeywordCaseInsensitive verifies every modeled directive is matched
+// regardless of keyword casing, as OpenSSH does.
+func TestParse_KeywordCaseInsensitive(t *testing.T) {
+	casings := []struct {
+		name string
+		fix  func(string) string
+	}{
+		{"canonical", func(k string) string { return k }},
+		{"lower", strings.ToLower},
+		{"upper", strings.ToUpper},
+		{"alternating", func(k string) string {
+			runes := []rune(k)
+			for i := range runes {
+				if i%2 == 0 {
+					runes[i] = unicode.ToLower(runes[i])
+				} else {
+					runes[i] = unicode.ToUpper(runes[i])
+				}
+			}
+			return string(runes)
+		}},
+	}
+
+	for _, c := range casings {
+		t.Run(c.name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeTempConfigAt(t, dir, "extra.conf", c.fix("Host")+" included\n    "+c.fix("HostName")+" inc.example.com\n")
+			main := c.fix("Host") + " dev\n" +
+				"    " + c.fix("HostName") + " dev.example.com\n" +
+				"    " + c.fix("User") + " alice\n" +
+				"    " + c.fix("Port") + " 2222\n" +
+				"    " + c.fix("IdentityFile") + " /keys/id_dev\n" +
+				"    " + c.fix("ProxyJump") + " bastion\n" +
+				"\n" + c.fix("Include") + " extra.conf\n"
+			hosts, err := Parse(writeTempConfigAt(t, dir, "config", main))
+			testutil.AssertNoError(t, err, "Parse should not error")
+			if len(hosts) != 2 {
+				t.Fatalf("expected 2 hosts, got %d: %+v", len(hosts), hosts)
+			}
+
+			dev := hosts[0]
+			testutil.AssertStringEqual(t, dev.Alias, "dev", "alias")
+			testutil.AssertStringEqual(t, dev.Hostname, "dev.example.com", "hostname")
+			testutil.AssertStringEqual(t, dev.User, "alice", "user")
+			testutil.AssertStringEqual(t, dev.Port, "2222", "port")
+			testutil.AssertStringEqual(t, dev.IdentityFile, "/keys/id_dev", "identity file")
+			testutil.AssertStringEqual(t, dev.ProxyJump, "bastion", "proxy jump")
+			testutil.AssertEqual(t, len(dev.ExtraLines), 0, "no directive should fall through to ExtraLines")
+
+			testutil.AssertStringEqual(t, hosts[1].Alias, "included", "included alias")
+			testutil.AssertStringEqual(t, hosts[1].Hostname, "inc.example.com", "included hostname")
+		})
+	}
+}
+
+// writeManyIncludes creates a config in dir that includes files conf.d/NN.conf,
+// each holding perFile hosts. Hosts are named fNN-hMM.
+func writeManyIncludes(tb testing.TB, dir string, files, perFile int) string {
+	tb.Helper()
+	if err := os.MkdirAll(filepath.Join(dir, "conf.d"), 0755); err != nil {
+		tb.Fatalf("failed to create conf.d: %v", err)
+	}
+	for f := 0; f < files; f++ {
+		var b strings.Builder
+		for h := 0; h < perFile; h++ {
+			fmt.Fprintf(&b, "# @group file%02d\nHost f%02d-h%02d\n    Hostname 10.%d.%d.1\n    User deploy\n    ServerAliveInterval 30\n\n", f, f, h, f, h)
+		}
+		path := filepath.Join(dir, "conf.d", fmt.Sprintf("%02d.conf", f))
+		if err := os.WriteFile(path, []byte(b.String()), 0644); err != nil {
+			tb.Fatalf("failed to write include: %v", err)
+		}
+	}
+	main := filepath.Join(dir, "config")
+	content := "Host top\n    Hostname 10.255.0.1\n\nInclude conf.d/*.conf\n"
+	if err := os.WriteFile(main, []byte(content), 0644); err != nil {
+		tb.Fatalf("failed to write config: %v", err)
+	}
+	return main
+}
+
+// TestParse_ManyIncludesOrderAndFields verifies hosts from many included files
+// come back in glob order with every field populated, matching what the
+// benchmark below measures.
+func TestParse_ManyIncludesOrderAndFields(t *testing.T) {
+	dir := t.TempDir()
+	main := writeManyIncludes(t, dir, 12, 3)
+
+	hosts, err := Parse(main)
+	testutil.AssertNoError(t, err, "Parse should not error")
+	if len(hosts) != 1+12*3 {
+		t.Fatalf("expected %d hosts, got %d", 1+12*3, len(hosts))
+	}
+	if hosts[0].Alias != "top" {
+		t.Errorf("hosts[0] = %q; want top", hosts[0].Alias)
+	}
+
+	i := 1
+	for f := 0; f < 12; f++ {
+		src := filepath.Join(dir, "conf.d", fmt.Sprintf("%02d.conf", f))
+		for h := 0; h < 3; h++ {
+			got := hosts[i]
+			want := Host{
+				Alias:      fmt.Sprintf("f%02d-h%02d", f, h),
+				Hostname:   fmt.Sprintf("10.%d.%d.1", f, h),
+				User:       "deploy",
+				Port:       "22",
+				Groups:     []string{fmt.Sprintf("file%02d", f)},
+				SourceFile: src,
+				LineStart:  h*6 + 2,
+				ExtraLines: []string{"ServerAliveInterval 30"},
+				Weight:     1,
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("hosts[%d] = %+v; want %+v", i, got, want)
+			}
+			i++
+		}
+	}
+}
+
+// BenchmarkParse_ManyIncludes measures Parse on a config that includes 50 files.
+func BenchmarkParse_ManyIncludes(b *testing.B) {
+	main := writeManyIncludes(b, b.TempDir(), 50, 10)
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Parse(main); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// TestParse_IncludeGlobStar verifies that "**" in an Include pattern matches
+// files at the root of the walk and in nested directories.
+func TestParse_IncludeGlobStar(t *testing.T) {
+	dir := t.TempDir()
+	main := writeTempConfigAt(t, dir, "config", "Host top\n    Hostname top.example.com\n\nInclude conf/**/*.conf\n")
+	writeTempConfigAt(t, dir, "conf/root.conf", "Host root\n    Hostname root.example.com\n")
+	writeTempConfigAt(t, dir, "conf/a/one.conf", "Host one\n    Hostname one.example.com\n")
+	writeTempConfigAt(t, dir, "conf/a/b/c/deep.conf", "Host deep\n    Hostname deep.example.com\n")
+	writeTempConfigAt(t, dir, "conf/a/skip.txt", "Host skipped\n    Hostname skipped.example.com\n")
+
+	hosts, err := Parse(main)
+	testutil.AssertNoError(t, err, "Parse should not error")
+
+	var got []string
+	for _, h := range hosts {
+		got = append(got, h.Alias)
+	}
+	testutil.AssertEqual(t, strings.Join(got, ","), "top,deep,one,root", "hosts in sorted path order")
+}
+
+// TestParse_IncludeGlobStarSymlinkCycle verifies that a symlink pointing back
+// up the tree does not make the "**" walk loop or include a file twice.
+func TestParse_IncludeGlobStarSymlinkCycle(t *testing.T) {
+	dir := t.TempDir()
+	main := writeTempConfigAt(t, dir, "config", "Include conf/**/*.conf\n")
+	writeTempConfigAt(t, dir, "conf/sub/web.conf", "Host web\n    Hostname web.example.com\n")
+	if err := os.Symlink(filepath.Join(dir, "conf"), filepath.Join(dir, "conf", "sub", "loop")); err != nil {
+		t.Skipf("symlinks unavailable: %v", err)
+	}
+
+	done := make(chan struct{})
+	var hosts []Host
+	var err error
+	go func() {
+		hosts, err = Parse(main)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("Parse did not finish; symlink cycle not guarded")
+	}
+
+	testutil.AssertNoError(t, err, "Parse should not error")
+	testutil.AssertEqual(t, len(hosts), 1, "web included exactly once")
+}
+
+// TestGlobStar_DepthCap verifies that "**" stops descending after
+// maxGlobStarDepth directories.
+func TestGlobStar_DepthCap(t *testing.T) {
+	dir := t.TempDir()
+	shallow := filepath.Join(dir, strings.Repeat("d/", maxGlobStarDepth), "ok.conf")
+	deep := filepath.Join(dir, strings.Repeat("d/", maxGlobStarDepth+1), "too-deep.conf")
+	for _, p := range []string{shallow, deep} {
+		if err := os.MkdirAll(filepath.Dir(p), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(p, nil, 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	matches, err := globStar(filepath.Join(dir, "**", "*.conf"))
+	testutil.AssertNoError(t, err, "globStar should not error")
+	testutil.AssertEqual(t, len(matches), 1, "only the file within the depth cap")
+	testutil.AssertEqual(t, matches[0], shallow, "shallow file matched")
+}
+
+// BenchmarkParse_GlobStarInclude measures a "**" include over a tree of
+// nested directories, to compare against the flat glob in
+// BenchmarkParse_ManyIncludes.
+func BenchmarkParse_GlobStarInclude(b *testing.B) {
+	dir := b.TempDir()
+	for f := 0; f < 50; f++ {
+		sub := filepath.Join(dir, "conf", fmt.Sprintf("g%d", f%5), fmt.Sprintf("s%d", f%3))
+		if err := os.MkdirAll(sub, 0755); err != nil {
+			b.Fatal(err)
+		}
+		var sb strings.Builder
+		for h := 0; h < 10; h++ {
+			fmt.Fprintf(&sb, "Host f%02d-h%02d\n    Hostname 10.%d.%d.1\n\n", f, h, f, h)
+		}
+		if err := os.WriteFile(filepath.Join(sub, fmt.Sprintf("%02d.conf", f)), []byte(sb.String()), 0644); err != nil {
+			b.Fatal(err)
+		}
+	}
+	main := filepath.Join(dir, "config")
+	if err := os.WriteFile(main, []byte("Include conf/**/*.conf\n"), 0644); err != nil {
+		b.Fatal(err)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := Parse(main); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// TestParse_AfterLocal verifies that "# @after-local" inside a block sets
+// AfterLocal on that host only.
+func TestParse_AfterLocal(t *testing.T) {
+	content := "# @after-local ignored before any host\n" +
+		"Host tunnel\n    Hostname bastion\n    LocalForward 8080 localhost:80\n    # @after-local open http://localhost:8080\n\n" +
+		"Host plain\n    Hostname plain\n    #@after-local\n"
+	hosts, err := Parse(writeTempConfig(t, content))
+	testutil.AssertNoError(t, err, "Parse should not error")
+	if len(hosts) != 2 {
+		t.Fatalf("expected 2 hosts, got %d", len(hosts))
+	}
+	testutil.AssertStringEqual(t, hosts[0].AfterLocal, "open http://localhost:8080", "tunnel AfterLocal")
+	testutil.AssertEmpty(t, hosts[1].AfterLocal, "empty @after-local is ignored")
+}
+
+// TestParse_Weight verifies "# @weight N" inside a block sets Weight, and that
+// hosts without one (or with an invalid value) default to 1.
+func TestParse_Weight(t *testing.T) {
+	content := "Host prod\n    Hostname prod.example.com\n    # @weight 3\n\n" +
+		"Host dev\n    Hostname dev\n\n" +
+		"Host bad\n    Hostname bad\n    # @weight zero\n    # @weight 0\n"
+	path := writeTempConfig(t, content)
+
+	hosts, err := Parse(path)
+	testutil.AssertNoError(t, err, "Parse should not error")
+	testutil.AssertEqual(t, len(hosts), 3, "host count")
+	testutil.AssertEqual(t, hosts[0].Weight, 3, "prod weight")
+	testutil.AssertEqual(t, hosts[1].Weight, 1, "dev default weight")
+	testutil.AssertEqual(t, hosts[2].Weight, 1, "invalid weights are ignored")
+}
+
+// TestParseStream_MatchesParse verifies ParseStream delivers the same hosts,
+// in the same order, as Parse, including hosts from included files.
+func TestParseStream_MatchesParse(t *testing.T) {
+	main := writeManyIncludes(t, t.TempDir(), 4, 3)
+
+	want, err := Parse(main)
+	testutil.AssertNoError(t, err, "Parse should not error")
+
+	var got []Host
+	err = ParseStream(main, func(h Host) error {
+		got = append(got, h)
+		return nil
+	})
+	testutil.AssertNoError(t, err, "ParseStream should not error")
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("ParseStream hosts differ from Parse:\ngot:  %+v\nwant: %+v", got, want)
+	}
+}
+
+// TestParseStream_StopsOnCallbackError verifies an error from the callback
+// halts parsing, even inside an included file, and is returned unchanged.
+func TestParseStream_StopsOnCallbackError(t *testing.T) {
+	main := writeManyIncludes(t, t.TempDir(), 4, 3)
+	errStop := errors.New("enough")
+
+	calls := 0
+	err := ParseStream(main, func(h Host) error {
+		calls++
+		if calls == 3 {
+			return errStop
+		}
+		return nil
+	})
+	if !errors.Is(err, errStop) {
+		t.Errorf("expected callback error, got %v", err)
+	}
+	testutil.AssertEqual(t, calls, 3, "callback calls before stopping")
+}
+
+// TestParseTag verifies that a magic comment's tag must be a whole word and
+// that the text after it is returned trimmed.
+func TestParseTag(t *testing.T) {
+	tests := []struct {
+		line, tag, want string
+		ok              bool
+	}{
+		{"    # @weight 3 ", weightTag, "3", true},
+		{"#@after-local\topen http://localhost", afterLocalTag, "open http://localhost", true},
+		{"# @weight", weightTag, "", true},
+		{"# @weighty 3", weightTag, "", false},
+		{"Host @weight", weightTag, "", false},
+	}
+	for _, tt := range tests {
+		got, ok := parseTag(tt.line, tt.tag)
+		if got != tt.want || ok != tt.ok {
+			t.Errorf("parseTag(%q, %q) = %q, %v; want %q, %v", tt.line, tt.tag, got, ok, tt.want, tt.ok)
+		}
+	}
+}
@@ -0,0 +1,68 @@
+package config
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// SchemeEnvPart is the part of an alias scheme that names the environment.
+const SchemeEnvPart = "env"
+
+// Scheme describes a delimiter-based alias naming convention such as
+// "svc-env-region", which splits "api-prod-us" into svc=api, env=prod and
+// region=us.
+type Scheme struct {
+	sep   string
+	parts []string
+}
+
+// ParseScheme parses a template of part names joined by a single delimiter
+// character (the first non-alphanumeric character in template). The template
+// must have at least two parts and include one named "env".
+func ParseScheme(template string) (Scheme, error) {
+	i := strings.IndexFunc(template, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+	})
+	if i <= 0 {
+		return Scheme{}, fmt.Errorf("scheme %q: expected part names joined by a delimiter, e.g. svc-env-region", template)
+	}
+	s := Scheme{sep: template[i : i+1], parts: strings.Split(template, template[i:i+1])}
+	hasEnv := false
+	for _, p := range s.parts {
+		if p == "" {
+			return Scheme{}, fmt.Errorf("scheme %q: empty part name", template)
+		}
+		hasEnv = hasEnv || p == SchemeEnvPart
+	}
+	if !hasEnv {
+		return Scheme{}, fmt.Errorf("scheme %q: no %q part", template, SchemeEnvPart)
+	}
+	return s, nil
+}
+
+// Extract splits alias by the scheme and returns each part by name. ok is
+// false if alias has a different number of parts or an empty one.
+func (s Scheme) Extract(alias string) (parts map[string]string, ok bool) {
+	values := strings.Split(alias, s.sep)
+	if len(values) != len(s.parts) {
+		return nil, false
+	}
+	parts = make(map[string]string, len(values))
+	for i, v := range values {
+		if v == "" {
+			return nil, false
+		}
+		parts[s.parts[i]] = v
+	}
+	return parts, true
+}
+
+// Environment returns the env part of alias, if alias follows the scheme.
+func (s Scheme) Environment(alias string) (string, bool) {
+	parts, ok := s.Extract(alias)
+	if !ok {
+		return "", false
+	}
+	return parts[SchemeEnvPart], true
+}
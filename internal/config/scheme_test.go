@@ -0,0 +1,49 @@
+package config
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseScheme(t *testing.T) {
+	for _, tc := range []struct {
+		template string
+		wantErr  string
+	}{
+		{"svc-env-region", ""},
+		{"env.svc", ""},
+		{"svc_env", "delimiter"}, // underscore is part of a name
+		{"svc--env", "empty part"},
+		{"svc-region", `no "env" part`},
+		{"", "delimiter"},
+	} {
+		_, err := ParseScheme(tc.template)
+		switch {
+		case tc.wantErr == "" && err != nil:
+			t.Errorf("ParseScheme(%q): unexpected error %v", tc.template, err)
+		case tc.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tc.wantErr)):
+			t.Errorf("ParseScheme(%q) = %v; want error containing %q", tc.template, err, tc.wantErr)
+		}
+	}
+}
+
+func TestScheme_Extract(t *testing.T) {
+	s, err := ParseScheme("svc-env-region")
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	parts, ok := s.Extract("api-prod-us")
+	if !ok || parts["svc"] != "api" || parts["env"] != "prod" || parts["region"] != "us" {
+		t.Errorf("Extract(api-prod-us) = %v, %v", parts, ok)
+	}
+	if env, ok := s.Environment("db-staging-eu"); !ok || env != "staging" {
+		t.Errorf("Environment(db-staging-eu) = %q, %v; want staging", env, ok)
+	}
+
+	for _, alias := range []string{"bastion", "api-prod", "api-prod-us-1", "api--us", "api.prod.us"} {
+		if _, ok := s.Extract(alias); ok {
+			t.Errorf("Extract(%q) matched; want no match", alias)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package config
+
+import (
+	"errors"
+	"io/fs"
+)
+
+// ParseSystem parses the system-wide SSH config at path and marks every host
+// ReadOnly. A missing file yields no hosts and no error.
+func ParseSystem(path string) ([]Host, error) {
+	hosts, err := Parse(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	for i := range hosts {
+		hosts[i].ReadOnly = true
+	}
+	return hosts, nil
+}
+
+// MergeSystemHosts appends system hosts to the user's hosts, skipping any
+// whose alias the user config already defines (the user config wins, as in
+// OpenSSH where the first obtained value is used).
+func MergeSystemHosts(user, system []Host) []Host {
+	defined := make(map[string]bool, len(user))
+	for _, h := range user {
+		defined[h.Alias] = true
+	}
+	merged := append([]Host(nil), user...)
+	for _, h := range system {
+		if !defined[h.Alias] {
+			merged = append(merged, h)
+		}
+	}
+	return merged
+}
@@ -0,0 +1,44 @@
+package config
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/srava/swiftssh/internal/testutil"
+)
+
+func TestParseSystem_MarksReadOnly(t *testing.T) {
+	path := writeTempConfig(t, "Host bastion\n    Hostname bastion.corp\n\nHost shared\n    Hostname shared.corp\n")
+
+	hosts, err := ParseSystem(path)
+	testutil.AssertNoError(t, err, "ParseSystem should not error")
+	if len(hosts) != 2 {
+		t.Fatalf("expected 2 hosts, got %d", len(hosts))
+	}
+	for _, h := range hosts {
+		testutil.AssertTrue(t, h.ReadOnly, h.Alias+" should be read-only")
+	}
+}
+
+func TestParseSystem_MissingFile(t *testing.T) {
+	hosts, err := ParseSystem(filepath.Join(t.TempDir(), "ssh_config"))
+	testutil.AssertNoError(t, err, "missing system config should not error")
+	testutil.AssertEqual(t, len(hosts), 0, "host count")
+}
+
+func TestMergeSystemHosts_UserWins(t *testing.T) {
+	user := []Host{{Alias: "shared", Hostname: "mine.example.com"}, {Alias: "dev", Hostname: "dev"}}
+	system := []Host{
+		{Alias: "shared", Hostname: "shared.corp", ReadOnly: true},
+		{Alias: "bastion", Hostname: "bastion.corp", ReadOnly: true},
+	}
+
+	merged := MergeSystemHosts(user, system)
+	if len(merged) != 3 {
+		t.Fatalf("expected 3 hosts, got %d: %v", len(merged), merged)
+	}
+	testutil.AssertStringEqual(t, merged[0].Hostname, "mine.example.com", "user definition of shared")
+	testutil.AssertFalse(t, merged[0].ReadOnly, "user host must stay editable")
+	testutil.AssertStringEqual(t, merged[2].Alias, "bastion", "system-only host appended")
+	testutil.AssertTrue(t, merged[2].ReadOnly, "system host read-only")
+}
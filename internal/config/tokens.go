@@ -0,0 +1,126 @@
+package config
+
+import (
+	"crypto/sha1"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"os/user"
+	"strings"
+)
+
+// HasTokens reports whether value contains an OpenSSH percent token ("%h",
+// "%d", "%%", ...).
+func HasTokens(value string) bool {
+	i := strings.IndexByte(value, '%')
+	return i >= 0 && i+1 < len(value)
+}
+
+// ExpandTokens expands the OpenSSH percent tokens in value as ssh would for h:
+//
+//	%%  a literal "%"
+//	%d  local home directory
+//	%h  remote hostname (Hostname, or the alias if unset)
+//	%n  the alias as given on the command line
+//	%p  remote port
+//	%r  remote user (User, or the local user if unset)
+//	%u  local user
+//	%l  local hostname, including any domain
+//	%L  local hostname with the domain stripped
+//	%C  SHA-1 hash of the local hostname, %h, %p, and %r, as in ControlPath
+//
+// Unknown tokens are left as they are. A leading "~" is also expanded.
+func ExpandTokens(value string, h Host) (string, error) {
+	return expandPercent(value, func(c byte) (string, error) {
+		return expandToken(c, h)
+	})
+}
+
+// expandPercent replaces each "%c" token in value with expand(c), then
+// expands a leading "~". A "%" at the very end is kept.
+func expandPercent(value string, expand func(c byte) (string, error)) (string, error) {
+	var b strings.Builder
+	for i := 0; i < len(value); i++ {
+		if value[i] != '%' || i+1 == len(value) {
+			b.WriteByte(value[i])
+			continue
+		}
+		i++
+		expanded, err := expand(value[i])
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(expanded)
+	}
+	return expandTilde(b.String())
+}
+
+// expandToken returns the value of the token "%c" for h.
+func expandToken(c byte, h Host) (string, error) {
+	switch c {
+	case '%':
+		return "%", nil
+	case 'd':
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", fmt.Errorf("cannot get home directory: %w", err)
+		}
+		return home, nil
+	case 'h':
+		if h.Hostname != "" {
+			return h.Hostname, nil
+		}
+		return h.Alias, nil
+	case 'n':
+		return h.Alias, nil
+	case 'p':
+		if h.Port != "" {
+			return h.Port, nil
+		}
+		return "22", nil
+	case 'r':
+		if h.User != "" {
+			return h.User, nil
+		}
+		return localUser()
+	case 'u':
+		return localUser()
+	case 'C':
+		return connectionHash(h)
+	case 'L', 'l':
+		name, err := os.Hostname()
+		if err != nil {
+			return "", fmt.Errorf("cannot get local hostname: %w", err)
+		}
+		if c == 'L' {
+			name, _, _ = strings.Cut(name, ".")
+		}
+		return name, nil
+	default:
+		return "%" + string(c), nil
+	}
+}
+
+// connectionHash returns ssh's %C: the hex SHA-1 of the full local hostname,
+// remote hostname, port, and remote user concatenated.
+func connectionHash(h Host) (string, error) {
+	var b strings.Builder
+	for _, c := range []byte{'l', 'h', 'p', 'r'} {
+		v, err := expandToken(c, h)
+		if err != nil {
+			return "", err
+		}
+		b.WriteString(v)
+	}
+	sum := sha1.Sum([]byte(b.String()))
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// localUser returns the name of the user running sssh.
+func localUser() (string, error) {
+	u, err := user.Current()
+	if err != nil {
+		return "", fmt.Errorf("cannot get local user: %w", err)
+	}
+	return u.Username, nil
+}
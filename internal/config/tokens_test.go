@@ -0,0 +1,62 @@
+package config
+
+import (
+	"os"
+	"strings"
+	"testing"
+)
+
+func TestExpandTokens(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+	h := Host{Alias: "web", Hostname: "web.example.com", User: "deploy", Port: "2222"}
+
+	cases := map[string]string{
+		"%d/.ssh/id_%n":          home + "/.ssh/id_web",
+		"ssh -W %h:%p bastion":   "ssh -W web.example.com:2222 bastion",
+		"%r@%h":                  "deploy@web.example.com",
+		"100%%":                  "100%",
+		"keep %Z and trailing %": "keep %Z and trailing %",
+		"/etc/ssh/plain":         "/etc/ssh/plain",
+	}
+	for in, want := range cases {
+		got, err := ExpandTokens(in, h)
+		if err != nil || got != want {
+			t.Errorf("ExpandTokens(%q) = %q, %v; want %q", in, got, err, want)
+		}
+	}
+}
+
+func TestExpandTokens_Fallbacks(t *testing.T) {
+	h := Host{Alias: "bare"}
+
+	got, err := ExpandTokens("%h:%p", h)
+	if err != nil || got != "bare:22" {
+		t.Errorf("got %q, %v; want alias and default port", got, err)
+	}
+
+	local, _ := os.Hostname()
+	got, err = ExpandTokens("%l", h)
+	if err != nil || got != local {
+		t.Errorf("%%l = %q, %v; want %q", got, err, local)
+	}
+	short, _, _ := strings.Cut(local, ".")
+	got, err = ExpandTokens("%L", h)
+	if err != nil || got != short {
+		t.Errorf("%%L = %q, %v; want %q", got, err, short)
+	}
+}
+
+func TestHasTokens(t *testing.T) {
+	for in, want := range map[string]bool{
+		"%d/.ssh/id": true,
+		"~/.ssh/id":  false,
+		"trailing%":  false,
+		"":           false,
+	} {
+		if got := HasTokens(in); got != want {
+			t.Errorf("HasTokens(%q) = %v; want %v", in, got, want)
+		}
+	}
+}
@@ -1,15 +1,85 @@
 package config
 
+import "strings"
+
 // Host represents a single SSH host entry from the config.
 type Host struct {
 	Alias        string   // The host alias (e.g., "dev" from "Host dev")
 	Hostname     string   // The actual hostname or IP to connect to
 	User         string   // The SSH user (defaults to current user if not specified)
 	Port         string   // The SSH port (defaults to "22" if not specified)
-	IdentityFile string   // Path to the private key file (IdentityFile directive)
+	IdentityFile string   // Path to the private key file (first IdentityFile directive)
+	ProxyJump    string   // Jump host(s) to connect through (ProxyJump directive)
 	Groups       []string // Group tags parsed from magic comment "# @group Work, Personal"
 	SourceFile   string   // The config file this host was parsed from (for Include support)
 	LineStart    int      // 1-based line of "Host <alias>" in SourceFile; 0 if untracked
+	ExtraLines   []string // Directives SwiftSSH does not model, verbatim (trimmed), in file order
+	ReadOnly     bool     // Host comes from the system-wide config and must not be rewritten
+	Hidden       bool     // Matched a --hide pattern; left out of the TUI list unless revealed
+	AfterLocal   string   // Local command from "# @after-local" to run once ssh returns (or its window opens)
+	Weight       int      // Connection count multiplier from "# @weight N"; parsed hosts default to 1
+	// Inherited holds fields filled from "# @group-default" comments (keyword to
+	// value). They are not written back into the host's block.
+	Inherited map[string]string
+	// IdentityFiles holds every IdentityFile directive in file order; the
+	// first matches IdentityFile. Use Identities to read them.
+	IdentityFiles []string
+}
+
+// Directive returns the value of the first unmodeled directive matching keyword
+// (case-insensitive) from ExtraLines, and whether it was present.
+func (h Host) Directive(keyword string) (string, bool) {
+	for _, line := range h.ExtraLines {
+		k, v := parseHostLine(line)
+		if strings.EqualFold(k, keyword) {
+			return v, true
+		}
+	}
+	return "", false
+}
+
+// Options returns the unmodeled directives in ExtraLines as a map from
+// lowercased keyword to value. As in ssh, the first occurrence of a keyword
+// wins. The map is a view: ExtraLines stays the source of truth for rewrites,
+// so edits to the map are not written back.
+func (h Host) Options() map[string]string {
+	opts := make(map[string]string, len(h.ExtraLines))
+	for _, line := range h.ExtraLines {
+		k, v := parseHostLine(line)
+		if k == "" {
+			continue
+		}
+		k = strings.ToLower(k)
+		if _, ok := opts[k]; !ok {
+			opts[k] = v
+		}
+	}
+	return opts
+}
+
+// Identities returns h's identity files in order: IdentityFile followed by
+// the rest of IdentityFiles. Code that only sets IdentityFile therefore
+// replaces the first key and keeps any others; an empty IdentityFile means
+// none.
+func (h Host) Identities() []string {
+	if h.IdentityFile == "" {
+		return nil
+	}
+	ids := []string{h.IdentityFile}
+	if len(h.IdentityFiles) > 1 {
+		ids = append(ids, h.IdentityFiles[1:]...)
+	}
+	return ids
+}
+
+// InGroup reports whether h is tagged with group (case-insensitive).
+func (h Host) InGroup(group string) bool {
+	for _, g := range h.Groups {
+		if strings.EqualFold(g, group) {
+			return true
+		}
+	}
+	return false
 }
 
 // ParsedConfig represents the complete parsed SSH configuration.
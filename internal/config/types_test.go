@@ -93,6 +93,13 @@ func TestHostStructCreation(t *testing.T) {
 
 // TestHostGroups validates Group handling.
 func TestHostGroups(t *testing.T) {
+	t.Run("InGroup is case-insensitive", func(t *testing.T) {
+		h := Host{Groups: []string{"Work", "Client A"}}
+		if !h.InGroup("work") || !h.InGroup("client a") || h.InGroup("Home") {
+			t.Errorf("InGroup mismatch for groups %v", h.Groups)
+		}
+	})
+
 	t.Run("single group", func(t *testing.T) {
 		h := Host{Groups: []string{"Work"}}
 		if len(h.Groups) != 1 || h.Groups[0] != "Work" {
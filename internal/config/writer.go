@@ -4,20 +4,40 @@ import (
 	"bufio"
 	"bytes"
 	"fmt"
+	"net"
 	"os"
+	"slices"
+	"sort"
 	"strings"
 )
 
 // IsKnownHost returns true if any host in the list has the given hostname.
+// DNS names match case-insensitively; see HostnameKey.
 func IsKnownHost(hosts []Host, hostname string) bool {
+	key := HostnameKey(hostname)
 	for _, h := range hosts {
-		if h.Hostname == hostname {
+		if HostnameKey(h.Hostname) == key {
 			return true
 		}
 	}
 	return false
 }
 
+// HostnameKey returns the form of hostname used to detect duplicates: DNS
+// names are lowercased, since they are case-insensitive, while IP literals
+// are kept as they are, minus any brackets around an IPv6 address. The
+// stored Hostname keeps its original casing.
+func HostnameKey(hostname string) string {
+	ip := hostname
+	if strings.HasPrefix(ip, "[") && strings.HasSuffix(ip, "]") {
+		ip = ip[1 : len(ip)-1]
+	}
+	if net.ParseIP(ip) != nil {
+		return ip
+	}
+	return strings.ToLower(hostname)
+}
+
 // buildHostBlock serializes a Host to its SSH config text block.
 // If h has groups, a magic comment is prepended.
 func buildHostBlock(h Host) string {
@@ -30,7 +50,7 @@ func buildHostBlock(h Host) string {
 	fmt.Fprintf(&b, "Host %s\n", h.Alias)
 	fmt.Fprintf(&b, "    Hostname %s\n", h.Hostname)
 
-	if h.User != "" {
+	if h.User != "" && !h.isInherited("User", h.User) {
 		fmt.Fprintf(&b, "    User %s\n", h.User)
 	}
 
@@ -38,16 +58,52 @@ func buildHostBlock(h Host) string {
 		fmt.Fprintf(&b, "    Port %s\n", h.Port)
 	}
 
-	if h.IdentityFile != "" {
-		fmt.Fprintf(&b, "    IdentityFile \"%s\"\n", h.IdentityFile)
+	for i, id := range h.Identities() {
+		if i == 0 && h.isInherited("IdentityFile", id) {
+			continue
+		}
+		fmt.Fprintf(&b, "    IdentityFile \"%s\"\n", id)
+	}
+
+	if h.ProxyJump != "" {
+		fmt.Fprintf(&b, "    ProxyJump %s\n", h.ProxyJump)
+	}
+
+	for _, line := range h.ExtraLines {
+		fmt.Fprintf(&b, "    %s\n", line)
+	}
+
+	if h.AfterLocal != "" {
+		fmt.Fprintf(&b, "    # %s %s\n", afterLocalTag, h.AfterLocal)
+	}
+
+	if h.Weight > 1 {
+		fmt.Fprintf(&b, "    # %s %d\n", weightTag, h.Weight)
 	}
 
 	return b.String()
 }
 
+// FormatHosts returns hosts as SSH config text, one block per host separated
+// by blank lines, as AppendHost would write them.
+func FormatHosts(hosts []Host) string {
+	blocks := make([]string, len(hosts))
+	for i, h := range hosts {
+		blocks[i] = buildHostBlock(h)
+	}
+	return strings.Join(blocks, "\n")
+}
+
 // AppendHost appends a new host block to the SSH config file.
 // It first backs up the config file, then appends the new host block.
 func AppendHost(configPath, backupPath string, h Host) error {
+	return AppendHosts(configPath, backupPath, []Host{h})
+}
+
+// AppendHosts appends a block for each host to the SSH config file, in order,
+// after a single backup, so the backup holds the config as it was before all
+// of them.
+func AppendHosts(configPath, backupPath string, hosts []Host) error {
 	// Read the original config file
 	original, err := os.ReadFile(configPath)
 	if err != nil && !os.IsNotExist(err) {
@@ -55,7 +111,7 @@ func AppendHost(configPath, backupPath string, h Host) error {
 	}
 
 	// Write backup (even if original doesn't exist, backup will be empty)
-	if err := os.WriteFile(backupPath, original, 0600); err != nil {
+	if err := writeBackup(backupPath, original); err != nil {
 		return fmt.Errorf("failed to write backup: %w", err)
 	}
 
@@ -70,39 +126,297 @@ func AppendHost(configPath, backupPath string, h Host) error {
 	if len(original) == 0 {
 		sep = ""
 	}
-	_, err = fmt.Fprintf(f, "%s%s", sep, buildHostBlock(h))
-	if err != nil {
-		return fmt.Errorf("failed to write host block: %w", err)
+	for _, h := range hosts {
+		if _, err := fmt.Fprintf(f, "%s%s", sep, buildHostBlock(h)); err != nil {
+			return fmt.Errorf("failed to write host block: %w", err)
+		}
+		sep = "\n"
 	}
 
 	return nil
 }
 
+// ReplaceResult describes the outcome of ReplaceHostBlock.
+type ReplaceResult struct {
+	NewLineStart int    // new 1-based line of the Host directive in the updated file
+	LineDelta    int    // how many lines the block grew (+) or shrank (-)
+	BackupPath   string // where the previous contents were backed up
+}
+
 // ReplaceHostBlock replaces the host block identified by h.LineStart and h.SourceFile
 // with a freshly serialized block built from h.
-// It writes a backup to h.SourceFile+".bak" before modifying the file.
-// Returns (newLineStart, lineDelta, error):
-//   - newLineStart: the new 1-based line number of the Host directive in the updated file.
-//   - lineDelta: how many lines the block grew (+) or shrank (-) relative to the original.
-func ReplaceHostBlock(h Host) (int, int, error) {
+// It writes a backup to h.SourceFile+".bak" before modifying the file, once
+// the block has been located, so a refused edit leaves the backups alone.
+func ReplaceHostBlock(h Host) (ReplaceResult, error) {
 	if h.LineStart == 0 {
-		return 0, 0, fmt.Errorf("ReplaceHostBlock: LineStart is 0, cannot locate host block")
+		return ReplaceResult{}, fmt.Errorf("ReplaceHostBlock: LineStart is 0, cannot locate host block")
 	}
 
 	// Read all lines
 	raw, err := os.ReadFile(h.SourceFile)
 	if err != nil {
-		return 0, 0, fmt.Errorf("failed to read config: %w", err)
+		return ReplaceResult{}, fmt.Errorf("failed to read config: %w", err)
 	}
 
 	lines := splitLines(raw)
 
+	result, res, err := replaceBlock(lines, h)
+	if err != nil {
+		return ReplaceResult{}, err
+	}
+
 	// Write backup
-	backupPath := h.SourceFile + ".bak"
-	if err := os.WriteFile(backupPath, raw, 0600); err != nil {
-		return 0, 0, fmt.Errorf("failed to write backup: %w", err)
+	backupPath := BackupPath(h.SourceFile)
+	if err := writeBackup(backupPath, raw); err != nil {
+		return ReplaceResult{}, fmt.Errorf("failed to write backup: %w", err)
+	}
+	if err := writeLines(h.SourceFile, raw, result); err != nil {
+		return ReplaceResult{}, err
+	}
+	res.BackupPath = backupPath
+	return res, nil
+}
+
+// DeleteHost removes the host block identified by h.LineStart and h.SourceFile,
+// including its "# @group" comment and the blank lines that separated it from
+// the next block. It writes a backup to h.SourceFile+".bak" first. lineDelta
+// is minus the number of lines removed, for shifting the hosts below it.
+// A block whose Host line names more than one pattern is refused, since
+// deleting it would remove the other hosts too.
+func DeleteHost(h Host) (lineDelta int, err error) {
+	if h.LineStart == 0 {
+		return 0, fmt.Errorf("DeleteHost: LineStart is 0, cannot locate host block")
+	}
+
+	raw, err := os.ReadFile(h.SourceFile)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read config: %w", err)
+	}
+	lines := splitLines(raw)
+
+	start, end, err := locateBlock(lines, h)
+	if err != nil {
+		return 0, err
+	}
+	if err := checkAlias(lines[start:end], h); err != nil {
+		return 0, err
+	}
+	if err := checkSinglePattern(lines[start:end]); err != nil {
+		return 0, err
+	}
+	// Take the blank lines after the block with it. The last block has none
+	// after it, so take the ones before it instead.
+	for end < len(lines) && strings.TrimSpace(lines[end]) == "" {
+		end++
+	}
+	if end == len(lines) {
+		for start > 0 && strings.TrimSpace(lines[start-1]) == "" {
+			start--
+		}
+	}
+
+	if err := writeBackup(BackupPath(h.SourceFile), raw); err != nil {
+		return 0, fmt.Errorf("failed to write backup: %w", err)
+	}
+	result := append(lines[:start:start], lines[end:]...)
+	if err := writeLines(h.SourceFile, raw, result); err != nil {
+		return 0, err
+	}
+	return start - end, nil
+}
+
+// ReplaceHostBlocks rewrites several host blocks, possibly in the same file.
+// Each file is read, backed up, and written once. Within a file, blocks are
+// replaced top to bottom, and each host's LineStart is shifted by the growth
+// of the blocks above it. The results are in the same order as hosts.
+// If a block cannot be located, that file is left untouched and an error is
+// returned; files already written stay written.
+func ReplaceHostBlocks(hosts []Host) ([]ReplaceResult, error) {
+	results := make([]ReplaceResult, len(hosts))
+	var files []string
+	byFile := make(map[string][]int)
+	for i, h := range hosts {
+		if h.LineStart == 0 {
+			return nil, fmt.Errorf("ReplaceHostBlocks: %s has LineStart 0, cannot locate host block", h.Alias)
+		}
+		if _, ok := byFile[h.SourceFile]; !ok {
+			files = append(files, h.SourceFile)
+		}
+		byFile[h.SourceFile] = append(byFile[h.SourceFile], i)
+	}
+
+	for _, path := range files {
+		idx := byFile[path]
+		sort.SliceStable(idx, func(a, b int) bool { return hosts[idx[a]].LineStart < hosts[idx[b]].LineStart })
+		for k := 1; k < len(idx); k++ {
+			if hosts[idx[k]].LineStart == hosts[idx[k-1]].LineStart {
+				return nil, fmt.Errorf("ReplaceHostBlocks: %s and %s share line %d of %s", hosts[idx[k-1]].Alias, hosts[idx[k]].Alias, hosts[idx[k]].LineStart, path)
+			}
+		}
+
+		raw, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read config: %w", err)
+		}
+		lines := splitLines(raw)
+
+		drift := 0
+		for _, i := range idx {
+			h := hosts[i]
+			h.LineStart += drift
+			var res ReplaceResult
+			lines, res, err = replaceBlock(lines, h)
+			if err != nil {
+				return nil, fmt.Errorf("%s: %w", hosts[i].Alias, err)
+			}
+			drift += res.LineDelta
+			results[i] = res
+		}
+
+		backupPath := BackupPath(path)
+		if err := writeBackup(backupPath, raw); err != nil {
+			return nil, fmt.Errorf("failed to write backup: %w", err)
+		}
+		if err := writeLines(path, raw, lines); err != nil {
+			return nil, err
+		}
+		for _, i := range idx {
+			results[i].BackupPath = backupPath
+		}
+	}
+	return results, nil
+}
+
+// replaceBlock returns lines with h's block swapped for one built from h. The
+// result's BackupPath is left empty.
+func replaceBlock(lines []string, h Host) ([]string, ReplaceResult, error) {
+	magicStart, blockEnd, err := locateBlock(lines, h)
+	if err != nil {
+		return nil, ReplaceResult{}, err
+	}
+	if err := checkSinglePattern(lines[magicStart:blockEnd]); err != nil {
+		return nil, ReplaceResult{}, err
+	}
+
+	// Build new block lines
+	newBlockLines := keepComments(lines[magicStart:blockEnd], splitLines([]byte(buildHostBlock(h))))
+
+	// Reconstruct file: before + new block + after
+	result := make([]string, 0, magicStart+len(newBlockLines)+(len(lines)-blockEnd))
+	result = append(result, lines[:magicStart]...)
+	result = append(result, newBlockLines...)
+	result = append(result, lines[blockEnd:]...)
+
+	// Compute the new 1-based LineStart of the Host directive in the written file.
+	// magicStart is the 0-based index of the block's first line in the result.
+	newLineStart := magicStart + 1 // 1-based; Host line when no groups
+	if len(h.Groups) > 0 {
+		newLineStart++ // Host line is one below the magic comment
+	}
+
+	// lineDelta: positive means block grew, negative means block shrank.
+	oldBlockSize := blockEnd - magicStart
+	lineDelta := len(newBlockLines) - oldBlockSize
+
+	return result, ReplaceResult{NewLineStart: newLineStart, LineDelta: lineDelta}, nil
+}
+
+// keepComments carries the comments and blank lines of a host's old block
+// over into block, its freshly built replacement, so an edit only rewrites
+// the directives. Each run goes back before the directive it preceded,
+// matched by keyword and occurrence; the run after the last directive stays
+// at the end, and runs whose directive is gone go just before it. A directive
+// whose value is unchanged keeps its inline comment (ExtraLines already carry
+// theirs). Magic comments are skipped because buildHostBlock writes them.
+func keepComments(old, block []string) []string {
+	var (
+		before  = make(map[string][]string)
+		keys    []string
+		pending []string
+		comment = make(map[string]string)
+		value   = make(map[string]string)
+	)
+	seen := make(map[string]int)
+	for _, line := range old {
+		k, v := parseHostLine(line)
+		if k == "" {
+			if !isMagicComment(line) && !isGeneratedComment(line) {
+				pending = append(pending, line)
+			}
+			continue
+		}
+		key := directiveKey(k, seen)
+		keys = append(keys, key)
+		before[key], pending = pending, nil
+		value[key] = v
+		if trimmed := strings.TrimSpace(line); inlineCommentIndex(trimmed) != -1 {
+			comment[key] = trimmed[inlineCommentIndex(trimmed):]
+		}
+	}
+
+	out := make([]string, 0, len(block)+len(old))
+	seen = make(map[string]int)
+	for _, line := range block {
+		k, v := parseHostLine(line)
+		if k == "" {
+			out = append(out, line)
+			continue
+		}
+		key := directiveKey(k, seen)
+		out = append(out, before[key]...)
+		delete(before, key)
+		if c, ok := comment[key]; ok && inlineCommentIndex(strings.TrimSpace(line)) == -1 &&
+			strings.Trim(v, `"`) == strings.Trim(value[key], `"`) {
+			line += " " + c
+		}
+		out = append(out, line)
+	}
+	for _, key := range keys {
+		out = append(out, before[key]...)
 	}
+	return append(out, pending...)
+}
+
+// directiveKey names the n-th occurrence of keyword (case-insensitive) in a
+// block, counting occurrences in seen.
+func directiveKey(keyword string, seen map[string]int) string {
+	k := strings.ToLower(keyword)
+	seen[k]++
+	return fmt.Sprintf("%s#%d", k, seen[k])
+}
 
+// isGeneratedComment reports whether line is a magic comment buildHostBlock
+// writes from a Host field (@after-local, @weight).
+func isGeneratedComment(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	_, isAfterLocal := parseAfterLocal(trimmed)
+	_, isWeight := parseWeight(trimmed)
+	return isAfterLocal || isWeight
+}
+
+// writeLines atomically replaces path with lines, ending with a newline if the
+// original contents raw did.
+func writeLines(path string, raw []byte, lines []string) error {
+	output := strings.Join(lines, "\n")
+	// Preserve trailing newline: if original ended with newline, ensure result does too
+	if len(raw) > 0 && raw[len(raw)-1] == '\n' && !strings.HasSuffix(output, "\n") {
+		output += "\n"
+	}
+
+	tmpPath := path + ".tmp"
+	if err := os.WriteFile(tmpPath, []byte(output), 0600); err != nil {
+		return fmt.Errorf("failed to write temp file: %w", err)
+	}
+
+	if err := os.Rename(tmpPath, path); err != nil {
+		return fmt.Errorf("failed to rename temp file: %w", err)
+	}
+	return nil
+}
+
+// locateBlock finds h's block in lines and returns the 0-based index of its
+// first line (including a preceding @group comment) and the index just past it.
+func locateBlock(lines []string, h Host) (magicStart, blockEnd int, err error) {
 	blockStart := h.LineStart - 1 // convert to 0-based
 
 	if blockStart < 0 || blockStart >= len(lines) {
@@ -114,7 +428,7 @@ func ReplaceHostBlock(h Host) (int, int, error) {
 	// (e.g. parser off-by-one or drift after a previous save), look one line ahead.
 	firstWord, _ := parseHostLine(lines[blockStart])
 	if !strings.EqualFold(firstWord, "host") {
-		if strings.Contains(lines[blockStart], "@group") && blockStart+1 < len(lines) {
+		if isMagicComment(lines[blockStart]) && blockStart+1 < len(lines) {
 			nextWord, _ := parseHostLine(lines[blockStart+1])
 			if strings.EqualFold(nextWord, "host") {
 				blockStart++ // advance past the mispointed magic comment
@@ -127,57 +441,123 @@ func ReplaceHostBlock(h Host) (int, int, error) {
 	}
 
 	// Determine if there's a magic comment line just before the block
-	magicStart := blockStart
-	if blockStart > 0 && strings.Contains(lines[blockStart-1], "@group") {
+	magicStart = blockStart
+	if blockStart > 0 && isMagicComment(lines[blockStart-1]) {
 		magicStart = blockStart - 1
 	}
 
 	// Find the end of this host block
-	blockEnd := findBlockEnd(lines, blockStart)
+	blockEnd = findBlockEnd(lines, blockStart)
 
-	// Build new block lines
-	newBlock := buildHostBlock(h)
-	newBlockLines := splitLines([]byte(newBlock))
-
-	// Reconstruct file: before + new block + after
-	result := make([]string, 0, magicStart+len(newBlockLines)+(len(lines)-blockEnd))
-	result = append(result, lines[:magicStart]...)
-	result = append(result, newBlockLines...)
-	result = append(result, lines[blockEnd:]...)
+	return magicStart, blockEnd, nil
+}
 
-	// Join and write atomically
-	output := strings.Join(result, "\n")
-	// Preserve trailing newline: if original ended with newline, ensure result does too
-	if len(raw) > 0 && raw[len(raw)-1] == '\n' && !strings.HasSuffix(output, "\n") {
-		output += "\n"
+// checkSinglePattern returns an error if the Host line in block names more
+// than one pattern. Such a block is shared by several hosts, and rewriting it
+// for one of them would drop the others from the Host line.
+func checkSinglePattern(block []string) error {
+	for _, line := range block {
+		if keyword, value := parseHostLine(line); strings.EqualFold(keyword, "host") {
+			if patterns := strings.Fields(value); len(patterns) > 1 {
+				return fmt.Errorf("the block is shared by Host %s; edit it in the config file", strings.Join(patterns, " "))
+			}
+			return nil
+		}
 	}
+	return nil
+}
 
-	tmpPath := h.SourceFile + ".tmp"
-	if err := os.WriteFile(tmpPath, []byte(output), 0600); err != nil {
-		return 0, 0, fmt.Errorf("failed to write temp file: %w", err)
+// PreviewReplace returns the lines of h's current block and the lines
+// ReplaceHostBlock would write in their place, without modifying the file.
+func PreviewReplace(h Host) (oldLines, newLines []string, err error) {
+	if h.LineStart == 0 {
+		return nil, nil, fmt.Errorf("PreviewReplace: LineStart is 0, cannot locate host block")
+	}
+	raw, err := os.ReadFile(h.SourceFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("failed to read config: %w", err)
+	}
+	lines := splitLines(raw)
+	magicStart, blockEnd, err := locateBlock(lines, h)
+	if err != nil {
+		return nil, nil, err
+	}
+	oldLines = lines[magicStart:blockEnd]
+	if err := checkSinglePattern(oldLines); err != nil {
+		return nil, nil, err
 	}
+	return oldLines, keepComments(oldLines, splitLines([]byte(buildHostBlock(h)))), nil
+}
 
-	if err := os.Rename(tmpPath, h.SourceFile); err != nil {
-		return 0, 0, fmt.Errorf("failed to rename temp file: %w", err)
+// RawBlock returns the lines of h's block exactly as they appear in its
+// SourceFile, including a preceding @group comment, and the 1-based line number
+// of the first of them. It fails if the file is unreadable or LineStart no
+// longer points at h's Host line.
+func RawBlock(h Host) (lines []string, first int, err error) {
+	if h.LineStart == 0 {
+		return nil, 0, fmt.Errorf("no source line recorded for %s", h.Alias)
 	}
+	raw, err := os.ReadFile(h.SourceFile)
+	if err != nil {
+		return nil, 0, fmt.Errorf("failed to read config: %w", err)
+	}
+	all := splitLines(raw)
+	magicStart, blockEnd, err := locateBlock(all, h)
+	if err != nil {
+		return nil, 0, err
+	}
+	if err := checkAlias(all[magicStart:blockEnd], h); err != nil {
+		return nil, 0, err
+	}
+	return all[magicStart:blockEnd], magicStart + 1, nil
+}
 
-	// Compute the new 1-based LineStart of the Host directive in the written file.
-	// magicStart is the 0-based index of the block's first line in the result.
-	newLineStart := magicStart + 1 // 1-based; Host line when no groups
-	if len(h.Groups) > 0 {
-		newLineStart++ // Host line is one below the magic comment
+// checkAlias fails unless the Host line of block, as found by locateBlock,
+// names h.Alias. A LineStart left behind by an edit elsewhere in the file can
+// point at another host's block.
+func checkAlias(block []string, h Host) error {
+	for _, line := range block {
+		if keyword, value := parseHostLine(line); strings.EqualFold(keyword, "host") {
+			if !slices.Contains(strings.Fields(value), h.Alias) {
+				return fmt.Errorf("stale LineStart %d: block is Host %s, not %s", h.LineStart, value, h.Alias)
+			}
+			return nil
+		}
 	}
+	return nil
+}
 
-	// lineDelta: positive means block grew, negative means block shrank.
-	oldBlockSize := blockEnd - magicStart
-	lineDelta := len(newBlockLines) - oldBlockSize
+// BackupPath returns where a change to configPath is backed up. Every write
+// to the file uses it, so edits, deletes and added hosts share one rotation
+// of .bak, .bak.1 and .bak.2.
+func BackupPath(configPath string) string {
+	return configPath + ".bak"
+}
 
-	return newLineStart, lineDelta, nil
+// backupGenerations is how many backups writeBackup keeps: path, path.1, path.2.
+const backupGenerations = 3
+
+// writeBackup writes data to path after shifting existing backups one
+// generation back (path.1 -> path.2, path -> path.1). The oldest is dropped.
+func writeBackup(path string, data []byte) error {
+	for i := backupGenerations - 1; i > 0; i-- {
+		older := fmt.Sprintf("%s.%d", path, i)
+		newer := path
+		if i > 1 {
+			newer = fmt.Sprintf("%s.%d", path, i-1)
+		}
+		if err := os.Rename(newer, older); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+	}
+	return os.WriteFile(path, data, 0600)
 }
 
-// splitLines splits raw bytes into lines, stripping \r for Windows CRLF.
-// Each element in the returned slice does NOT include the line terminator.
+// splitLines splits raw bytes into lines, stripping \r for Windows CRLF and a
+// leading UTF-8 BOM. Each element in the returned slice does NOT include the
+// line terminator.
 func splitLines(data []byte) []string {
+	data = bytes.TrimPrefix(data, []byte(utf8BOM))
 	var lines []string
 	scanner := bufio.NewScanner(bytes.NewReader(data))
 	for scanner.Scan() {
@@ -188,16 +568,17 @@ func splitLines(data []byte) []string {
 
 // findBlockEnd returns the index of the first line (0-based) that belongs to the
 // NEXT host block after the block starting at blockStart. Returns len(lines) at EOF.
-// A line beginning a new block is one whose first non-blank, non-comment token is "host".
+// A line beginning a new block is one whose first non-blank, non-comment token is
+// "host" or "match".
 // Trailing blank lines between host blocks are NOT included in the current block; they
 // fall into the lines[blockEnd:] "after" section so they are preserved on rewrite.
 func findBlockEnd(lines []string, blockStart int) int {
 	for i := blockStart + 1; i < len(lines); i++ {
 		word, _ := parseHostLine(lines[i])
-		if strings.EqualFold(word, "host") {
+		if strings.EqualFold(word, "host") || strings.EqualFold(word, "match") {
 			end := i
 			// magic comment belongs to the next block — back up over it first
-			if end > blockStart+1 && strings.Contains(lines[end-1], "@group") {
+			if end > blockStart+1 && isMagicComment(lines[end-1]) {
 				end--
 			}
 			// back up past trailing blank lines so they are preserved
@@ -210,6 +591,18 @@ func findBlockEnd(lines []string, blockStart int) int {
 	return len(lines)
 }
 
+// isMagicComment reports whether line is a "# @group" magic comment.
+// Ordinary comments that merely mention "@group" (e.g. a banner explaining the
+// syntax) are not magic comments and must never be treated as part of a block.
+func isMagicComment(line string) bool {
+	trimmed := strings.TrimSpace(line)
+	if !strings.HasPrefix(trimmed, "#") {
+		return false
+	}
+	rest := strings.TrimSpace(trimmed[1:])
+	return strings.HasPrefix(rest, "@group") && !strings.HasPrefix(rest, groupDefaultTag)
+}
+
 // parseHostLine returns the first keyword and its value from a config line,
 // or ("", "") if the line is blank or a comment.
 func parseHostLine(line string) (keyword, value string) {
@@ -217,9 +610,6 @@ func parseHostLine(line string) (keyword, value string) {
 	if trimmed == "" || strings.HasPrefix(trimmed, "#") {
 		return "", ""
 	}
-	idx := strings.IndexAny(trimmed, " \t")
-	if idx == -1 {
-		return trimmed, ""
-	}
-	return trimmed[:idx], strings.TrimSpace(trimmed[idx+1:])
+	keyword, value, _ = splitDirective(trimmed)
+	return keyword, value
 }
@@ -1,10 +1,13 @@
 package config
 
 import (
+	"fmt"
 	"os"
 	"path/filepath"
 	"strings"
 	"testing"
+
+	"github.com/srava/swiftssh/internal/testutil"
 )
 
 func TestIsKnownHost_Found(t *testing.T) {
@@ -28,6 +31,26 @@ func TestIsKnownHost_NotFound(t *testing.T) {
 	}
 }
 
+func TestIsKnownHost_IgnoresDNSCase(t *testing.T) {
+	hosts := []Host{
+		{Alias: "web", Hostname: "example.com"},
+		{Alias: "v6", Hostname: "fe80::1"},
+	}
+
+	if !IsKnownHost(hosts, "Example.COM") {
+		t.Error("expected Example.COM to match example.com")
+	}
+	if !IsKnownHost(hosts, "fe80::1") {
+		t.Error("expected the same IP literal to match")
+	}
+	if IsKnownHost(hosts, "FE80::1") {
+		t.Error("expected IP literals to compare as written")
+	}
+	if !IsKnownHost(hosts, "[fe80::1]") {
+		t.Error("expected a bracketed IPv6 literal to match the unbracketed one")
+	}
+}
+
 func TestIsKnownHost_EmptyList(t *testing.T) {
 	if IsKnownHost([]Host{}, "192.168.1.1") {
 		t.Error("expected IsKnownHost to return false for empty list")
@@ -376,7 +399,7 @@ func TestReplaceHostBlock_Basic(t *testing.T) {
 		LineStart:  1,
 	}
 
-	if _, _, err := ReplaceHostBlock(h); err != nil {
+	if _, err := ReplaceHostBlock(h); err != nil {
 		t.Fatalf("ReplaceHostBlock failed: %v", err)
 	}
 
@@ -395,6 +418,70 @@ func TestReplaceHostBlock_Basic(t *testing.T) {
 	}
 }
 
+func TestReplaceHostBlock_StopsAtMatch(t *testing.T) {
+	content := "Host web\n    Hostname 10.0.0.5\n\nMatch host *.corp exec \"true\"\n    ForwardAgent yes\n"
+	path := writeHostConfig(t, content)
+	hosts, err := Parse(path)
+	testutil.AssertNoError(t, err, "Parse should not error")
+
+	h := hosts[0]
+	h.User = "deploy"
+	if _, err := ReplaceHostBlock(h); err != nil {
+		t.Fatalf("ReplaceHostBlock failed: %v", err)
+	}
+
+	result, _ := os.ReadFile(path)
+	want := "Host web\n    Hostname 10.0.0.5\n    User deploy\n\nMatch host *.corp exec \"true\"\n    ForwardAgent yes\n"
+	testutil.AssertStringEqual(t, string(result), want, "Match block kept after the host")
+}
+
+func TestReplaceHostBlock_KeepsProxyJump(t *testing.T) {
+	path := writeHostConfig(t, "Host internal\n    Hostname 10.1.0.5\n    ProxyJump admin@bastion:2222\n\nHost bastion\n    Hostname bastion.example.com\n")
+	hosts, err := Parse(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := hosts[0]
+	h.User = "deploy"
+	if _, err := ReplaceHostBlock(h); err != nil {
+		t.Fatalf("ReplaceHostBlock failed: %v", err)
+	}
+
+	result, _ := os.ReadFile(path)
+	want := "Host internal\n    Hostname 10.1.0.5\n    User deploy\n    ProxyJump admin@bastion:2222\n\nHost bastion\n"
+	if !strings.HasPrefix(string(result), want) {
+		t.Errorf("rewritten config:\n%s\nwant prefix:\n%s", result, want)
+	}
+	reparsed, _ := Parse(path)
+	testutil.AssertStringEqual(t, reparsed[0].ProxyJump, "admin@bastion:2222", "ProxyJump after edit")
+}
+
+func TestReplaceHostBlock_KeepsEveryIdentityFile(t *testing.T) {
+	path := writeHostConfig(t, "Host multi\n    Hostname 10.1.0.6\n    IdentityFile ~/.ssh/id_ed25519\n    IdentityFile \"~/.ssh/old rsa\"\n")
+	hosts, err := Parse(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := hosts[0]
+	h.User = "deploy"
+	if _, err := ReplaceHostBlock(h); err != nil {
+		t.Fatalf("ReplaceHostBlock failed: %v", err)
+	}
+
+	result, _ := os.ReadFile(path)
+	want := "Host multi\n    Hostname 10.1.0.6\n    User deploy\n    IdentityFile \"~/.ssh/id_ed25519\"\n    IdentityFile \"~/.ssh/old rsa\"\n"
+	testutil.AssertStringEqual(t, string(result), want, "rewritten config")
+
+	// Setting only IdentityFile replaces the first key and keeps the rest.
+	h.IdentityFile = "~/.ssh/new"
+	block := buildHostBlock(h)
+	if !strings.Contains(block, "IdentityFile \"~/.ssh/new\"\n    IdentityFile \"~/.ssh/old rsa\"\n") {
+		t.Errorf("expected new first key followed by the old second one:\n%s", block)
+	}
+}
+
 func TestReplaceHostBlock_WithMagicComment(t *testing.T) {
 	content := "# @group OldGroup\nHost myhost\n    Hostname old.example.com\n"
 	path := writeHostConfig(t, content)
@@ -407,7 +494,7 @@ func TestReplaceHostBlock_WithMagicComment(t *testing.T) {
 		LineStart:  2,
 	}
 
-	if _, _, err := ReplaceHostBlock(h); err != nil {
+	if _, err := ReplaceHostBlock(h); err != nil {
 		t.Fatalf("ReplaceHostBlock failed: %v", err)
 	}
 
@@ -434,7 +521,7 @@ func TestReplaceHostBlock_AddGroups(t *testing.T) {
 		LineStart:  1,
 	}
 
-	if _, _, err := ReplaceHostBlock(h); err != nil {
+	if _, err := ReplaceHostBlock(h); err != nil {
 		t.Fatalf("ReplaceHostBlock failed: %v", err)
 	}
 
@@ -456,7 +543,7 @@ func TestReplaceHostBlock_RemoveGroups(t *testing.T) {
 		LineStart:  2,
 	}
 
-	if _, _, err := ReplaceHostBlock(h); err != nil {
+	if _, err := ReplaceHostBlock(h); err != nil {
 		t.Fatalf("ReplaceHostBlock failed: %v", err)
 	}
 
@@ -478,7 +565,7 @@ func TestReplaceHostBlock_LastHost(t *testing.T) {
 		LineStart:  4,
 	}
 
-	if _, _, err := ReplaceHostBlock(h); err != nil {
+	if _, err := ReplaceHostBlock(h); err != nil {
 		t.Fatalf("ReplaceHostBlock failed: %v", err)
 	}
 
@@ -505,7 +592,7 @@ func TestReplaceHostBlock_StaleLine(t *testing.T) {
 		LineStart:  2, // line 2 is "    Hostname old.example.com"
 	}
 
-	_, _, err := ReplaceHostBlock(h)
+	_, err := ReplaceHostBlock(h)
 	if err == nil {
 		t.Error("expected error for stale LineStart pointing to non-Host line")
 	}
@@ -519,7 +606,7 @@ func TestReplaceHostBlock_ZeroLineStart(t *testing.T) {
 		LineStart:  0,
 	}
 
-	_, _, err := ReplaceHostBlock(h)
+	_, err := ReplaceHostBlock(h)
 	if err == nil {
 		t.Error("expected error when LineStart is 0")
 	}
@@ -537,7 +624,7 @@ func TestReplaceHostBlock_CreatesBackup(t *testing.T) {
 		LineStart:  1,
 	}
 
-	if _, _, err := ReplaceHostBlock(h); err != nil {
+	if _, err := ReplaceHostBlock(h); err != nil {
 		t.Fatalf("ReplaceHostBlock failed: %v", err)
 	}
 
@@ -567,7 +654,7 @@ func TestReplaceHostBlock_MagicCommentAtBlockStart(t *testing.T) {
 		LineStart:  1, // points to "# @group Local", not the Host line
 	}
 
-	newLineStart, _, err := ReplaceHostBlock(h)
+	res, err := ReplaceHostBlock(h)
 	if err != nil {
 		t.Fatalf("expected lenient stale check to succeed, got: %v", err)
 	}
@@ -577,8 +664,8 @@ func TestReplaceHostBlock_MagicCommentAtBlockStart(t *testing.T) {
 		t.Error("expected new hostname in result")
 	}
 	// With groups, Host line follows the magic comment, so newLineStart = magicStart+2 = 2
-	if newLineStart != 2 {
-		t.Errorf("expected newLineStart=2, got %d", newLineStart)
+	if res.NewLineStart != 2 {
+		t.Errorf("expected newLineStart=2, got %d", res.NewLineStart)
 	}
 }
 
@@ -596,14 +683,14 @@ func TestReplaceHostBlock_ReturnsNewLineStart_AddGroups(t *testing.T) {
 		LineStart:  1, // Host line is at line 1 before the save
 	}
 
-	newLineStart, _, err := ReplaceHostBlock(h)
+	res, err := ReplaceHostBlock(h)
 	if err != nil {
 		t.Fatalf("ReplaceHostBlock failed: %v", err)
 	}
 
 	// After save: line 1 = "# @group Work", line 2 = "Host myhost"
-	if newLineStart != 2 {
-		t.Errorf("expected newLineStart=2 after adding groups, got %d", newLineStart)
+	if res.NewLineStart != 2 {
+		t.Errorf("expected newLineStart=2 after adding groups, got %d", res.NewLineStart)
 	}
 }
 
@@ -621,14 +708,14 @@ func TestReplaceHostBlock_ReturnsNewLineStart_RemoveGroups(t *testing.T) {
 		LineStart:  2, // Host line is at line 2 (after @group comment)
 	}
 
-	newLineStart, _, err := ReplaceHostBlock(h)
+	res, err := ReplaceHostBlock(h)
 	if err != nil {
 		t.Fatalf("ReplaceHostBlock failed: %v", err)
 	}
 
 	// After save: line 1 = "Host myhost" (magic comment removed)
-	if newLineStart != 1 {
-		t.Errorf("expected newLineStart=1 after removing groups, got %d", newLineStart)
+	if res.NewLineStart != 1 {
+		t.Errorf("expected newLineStart=1 after removing groups, got %d", res.NewLineStart)
 	}
 }
 
@@ -646,7 +733,7 @@ func TestReplaceHostBlock_PreservesBlankLine(t *testing.T) {
 		LineStart:  1,
 	}
 
-	if _, _, err := ReplaceHostBlock(h); err != nil {
+	if _, err := ReplaceHostBlock(h); err != nil {
 		t.Fatalf("ReplaceHostBlock failed: %v", err)
 	}
 
@@ -681,16 +768,16 @@ func TestReplaceHostBlock_ReturnsLineDelta_AddGroup(t *testing.T) {
 		LineStart:  1,
 	}
 
-	newLineStart, lineDelta, err := ReplaceHostBlock(h)
+	res, err := ReplaceHostBlock(h)
 	if err != nil {
 		t.Fatalf("ReplaceHostBlock failed: %v", err)
 	}
 
-	if newLineStart != 2 {
-		t.Errorf("expected newLineStart=2, got %d", newLineStart)
+	if res.NewLineStart != 2 {
+		t.Errorf("expected newLineStart=2, got %d", res.NewLineStart)
 	}
-	if lineDelta != 1 {
-		t.Errorf("expected lineDelta=+1 when adding a group, got %d", lineDelta)
+	if res.LineDelta != 1 {
+		t.Errorf("expected lineDelta=+1 when adding a group, got %d", res.LineDelta)
 	}
 }
 
@@ -708,15 +795,740 @@ func TestReplaceHostBlock_ReturnsLineDelta_RemoveGroup(t *testing.T) {
 		LineStart:  2,
 	}
 
-	newLineStart, lineDelta, err := ReplaceHostBlock(h)
+	res, err := ReplaceHostBlock(h)
+	if err != nil {
+		t.Fatalf("ReplaceHostBlock failed: %v", err)
+	}
+
+	if res.NewLineStart != 1 {
+		t.Errorf("expected newLineStart=1, got %d", res.NewLineStart)
+	}
+	if res.LineDelta != -1 {
+		t.Errorf("expected lineDelta=-1 when removing a group, got %d", res.LineDelta)
+	}
+	if res.BackupPath != path+".bak" {
+		t.Errorf("expected BackupPath=%q, got %q", path+".bak", res.BackupPath)
+	}
+}
+
+// TestReplaceHostBlock_LeadingBanner verifies that editing the first host in a file
+// that begins with a non-@group comment banner neither consumes nor duplicates it.
+func TestReplaceHostBlock_LeadingBanner(t *testing.T) {
+	banner := "# SSH config for acme.example.com\n# Tag hosts with @group comments.\n"
+
+	t.Run("banner separated by blank line", func(t *testing.T) {
+		content := banner + "\nHost first\n    Hostname first.example.com\n\nHost second\n    Hostname second.example.com\n"
+		path := writeHostConfig(t, content)
+
+		hosts, err := Parse(path)
+		if err != nil {
+			t.Fatalf("Parse failed: %v", err)
+		}
+		if hosts[0].LineStart != 4 || len(hosts[0].Groups) != 0 {
+			t.Fatalf("expected first host at line 4 with no groups, got line %d groups %v",
+				hosts[0].LineStart, hosts[0].Groups)
+		}
+
+		h := hosts[0]
+		h.Hostname = "updated.example.com"
+		h.Groups = []string{"Work"}
+		if _, err := ReplaceHostBlock(h); err != nil {
+			t.Fatalf("ReplaceHostBlock failed: %v", err)
+		}
+
+		result, _ := os.ReadFile(path)
+		want := banner + "\n# @group Work\nHost first\n    Hostname updated.example.com\n\nHost second\n    Hostname second.example.com\n"
+		if string(result) != want {
+			t.Errorf("unexpected result:\nwant: %q\ngot:  %q", want, string(result))
+		}
+	})
+
+	t.Run("banner directly above Host line", func(t *testing.T) {
+		content := banner + "Host first\n    Hostname first.example.com\n"
+		path := writeHostConfig(t, content)
+
+		h := Host{
+			Alias:      "first",
+			Hostname:   "updated.example.com",
+			SourceFile: path,
+			LineStart:  3,
+		}
+		res, err := ReplaceHostBlock(h)
+		if err != nil {
+			t.Fatalf("ReplaceHostBlock failed: %v", err)
+		}
+
+		result, _ := os.ReadFile(path)
+		want := banner + "Host first\n    Hostname updated.example.com\n"
+		if string(result) != want {
+			t.Errorf("unexpected result:\nwant: %q\ngot:  %q", want, string(result))
+		}
+		if strings.Count(string(result), "Tag hosts with @group comments.") != 1 {
+			t.Error("expected banner to appear exactly once")
+		}
+		if res.NewLineStart != 3 {
+			t.Errorf("expected newLineStart=3, got %d", res.NewLineStart)
+		}
+		if res.LineDelta != 0 {
+			t.Errorf("expected lineDelta=0, got %d", res.LineDelta)
+		}
+	})
+}
+
+func TestIsMagicComment(t *testing.T) {
+	tests := []struct {
+		line string
+		want bool
+	}{
+		{"# @group Work", true},
+		{"  #@group Work, Home", true},
+		{"# Tag hosts with @group comments.", false},
+		{"Host @group", false},
+		{"", false},
+	}
+	for _, tc := range tests {
+		if got := isMagicComment(tc.line); got != tc.want {
+			t.Errorf("isMagicComment(%q) = %v; want %v", tc.line, got, tc.want)
+		}
+	}
+}
+
+// TestReplaceHostBlock_PreservesCryptoDirectives verifies that Ciphers/KexAlgorithms/MACs
+// captured in ExtraLines survive an edit.
+func TestReplaceHostBlock_PreservesCryptoDirectives(t *testing.T) {
+	content := "Host legacy\n    Hostname 10.0.0.9\n    Ciphers aes128-cbc\n    KexAlgorithms diffie-hellman-group1-sha1\n    MACs hmac-sha1\n"
+	path := writeHostConfig(t, content)
+
+	hosts, err := Parse(path)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	h := hosts[0]
+	h.User = "admin"
+	if _, err := ReplaceHostBlock(h); err != nil {
+		t.Fatalf("ReplaceHostBlock failed: %v", err)
+	}
+
+	reparsed, err := Parse(path)
+	if err != nil {
+		t.Fatalf("re-Parse failed: %v", err)
+	}
+	if reparsed[0].User != "admin" {
+		t.Errorf("expected User=admin after edit, got %q", reparsed[0].User)
+	}
+	want := []string{"Ciphers aes128-cbc", "KexAlgorithms diffie-hellman-group1-sha1", "MACs hmac-sha1"}
+	if strings.Join(reparsed[0].ExtraLines, "|") != strings.Join(want, "|") {
+		t.Errorf("ExtraLines after edit = %v; want %v", reparsed[0].ExtraLines, want)
+	}
+}
+
+// TestReplaceHostBlock_RefusesMultiPatternBlock verifies that editing one
+// alias of "Host web1 web2 web3" fails instead of dropping the others.
+func TestReplaceHostBlock_RefusesMultiPatternBlock(t *testing.T) {
+	content := "Host web1 web2 web3\n    Hostname web.example.com\n"
+	path := writeHostConfig(t, content)
+	hosts, err := Parse(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := hosts[1]
+	h.User = "deploy"
+	if _, err := ReplaceHostBlock(h); err == nil || !strings.Contains(err.Error(), "web1 web2 web3") {
+		t.Errorf("ReplaceHostBlock(web2) error = %v; want one naming the shared Host line", err)
+	}
+	if _, _, err := PreviewReplace(h); err == nil {
+		t.Error("PreviewReplace(web2) should fail too")
+	}
+	if data, _ := os.ReadFile(path); string(data) != content {
+		t.Errorf("file should be untouched, got:\n%s", data)
+	}
+}
+
+func TestReplaceHostBlock_KeepsBooleanDirectives(t *testing.T) {
+	path := writeHostConfig(t, "Host dev\n    Hostname dev.example.com\n    ForwardAgent yes\n    Compression yes\n")
+	hosts, err := Parse(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := hosts[0]
+	h.Port = "2200"
+	if _, err := ReplaceHostBlock(h); err != nil {
+		t.Fatalf("ReplaceHostBlock failed: %v", err)
+	}
+
+	result, _ := os.ReadFile(path)
+	want := "Host dev\n    Hostname dev.example.com\n    Port 2200\n    ForwardAgent yes\n    Compression yes\n"
+	testutil.AssertStringEqual(t, string(result), want, "rewritten block")
+	reparsed, _ := Parse(path)
+	testutil.AssertStringEqual(t, reparsed[0].Options()["forwardagent"], "yes", "ForwardAgent after edit")
+}
+
+func TestReplaceHostBlock_KeepsServerAliveInterval(t *testing.T) {
+	path := writeHostConfig(t, "Host dev\n    Hostname dev.example.com\n    ServerAliveInterval 60\n\nHost other\n    Hostname other.example.com\n")
+	hosts, err := Parse(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := hosts[0]
+	h.User = "deploy"
+	if _, err := ReplaceHostBlock(h); err != nil {
+		t.Fatalf("ReplaceHostBlock failed: %v", err)
+	}
+
+	reparsed, _ := Parse(path)
+	testutil.AssertStringEqual(t, reparsed[0].User, "deploy", "User after edit")
+	if v, ok := reparsed[0].Directive("ServerAliveInterval"); !ok || v != "60" {
+		t.Errorf("Directive(ServerAliveInterval) = %q, %v; want \"60\", true", v, ok)
+	}
+}
+
+func TestReplaceHostBlock_KeepsComments(t *testing.T) {
+	content := "Host web  # frontend\n" +
+		"    # primary address\n" +
+		"    Hostname web.example.com # behind the LB\n" +
+		"    User old # to be replaced\n" +
+		"    # keepalive for flaky wifi\n" +
+		"    ServerAliveInterval 60 # seconds\n" +
+		"    # @weight 2\n" +
+		"\n" +
+		"# --- databases ---\n" +
+		"\n" +
+		"Host db\n" +
+		"    Hostname db.example.com\n"
+	path := writeHostConfig(t, content)
+	hosts, err := Parse(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	h := hosts[0]
+	h.User = "deploy"
+	h.Port = "2222"
+	h.Weight = 3
+	res, err := ReplaceHostBlock(h)
 	if err != nil {
 		t.Fatalf("ReplaceHostBlock failed: %v", err)
 	}
 
-	if newLineStart != 1 {
-		t.Errorf("expected newLineStart=1, got %d", newLineStart)
+	want := "Host web # frontend\n" +
+		"    # primary address\n" +
+		"    Hostname web.example.com # behind the LB\n" +
+		"    User deploy\n" +
+		"    Port 2222\n" +
+		"    # keepalive for flaky wifi\n" +
+		"    ServerAliveInterval 60 # seconds\n" +
+		"    # @weight 3\n" +
+		"\n" +
+		"# --- databases ---\n" +
+		"\n" +
+		"Host db\n" +
+		"    Hostname db.example.com\n"
+	result, _ := os.ReadFile(path)
+	testutil.AssertStringEqual(t, string(result), want, "rewritten config")
+	testutil.AssertEqual(t, res.LineDelta, 1, "LineDelta")
+
+	reparsed, _ := Parse(path)
+	testutil.AssertStringEqual(t, reparsed[1].Alias, "db", "next host")
+	testutil.AssertEqual(t, reparsed[1].LineStart, 12, "next host line")
+}
+
+func TestParseHostLine_Separators(t *testing.T) {
+	tests := []struct {
+		line        string
+		wantKeyword string
+		wantValue   string
+	}{
+		{"Host myhost", "Host", "myhost"},
+		{"Host\t\tmyhost", "Host", "myhost"},
+		{"\tHostname\t\texample.com", "Hostname", "example.com"},
+		{"  User \t alice  ", "User", "alice"},
+		{"Port\t \t2222", "Port", "2222"},
+		{"# comment", "", ""},
+		{"   ", "", ""},
+		{"Host prod  # production box", "Host", "prod"},
+		{"Hostname a#b", "Hostname", "a#b"},
+	}
+	for _, tc := range tests {
+		k, v := parseHostLine(tc.line)
+		if k != tc.wantKeyword || v != tc.wantValue {
+			t.Errorf("parseHostLine(%q) = (%q, %q); want (%q, %q)", tc.line, k, v, tc.wantKeyword, tc.wantValue)
+		}
+	}
+}
+
+func TestReplaceHostBlock_InlineCommentOnHostLine(t *testing.T) {
+	path := writeHostConfig(t, "Host prod  # production box\n    Hostname prod.example.com # primary\n\nHost other\n    Hostname other.example.com\n")
+	hosts, err := Parse(path)
+	if err != nil {
+		t.Fatal(err)
 	}
-	if lineDelta != -1 {
-		t.Errorf("expected lineDelta=-1 when removing a group, got %d", lineDelta)
+
+	h := hosts[0]
+	h.User = "deploy"
+	if _, err := ReplaceHostBlock(h); err != nil {
+		t.Fatalf("ReplaceHostBlock failed: %v", err)
 	}
+
+	reparsed, _ := Parse(path)
+	testutil.AssertStringEqual(t, reparsed[0].Alias, "prod", "alias after edit")
+	testutil.AssertStringEqual(t, reparsed[0].User, "deploy", "User after edit")
+	testutil.AssertStringEqual(t, reparsed[1].Alias, "other", "next host untouched")
+}
+
+// TestReplaceHostBlock_TabSeparatedHostLine verifies the stale check accepts a
+// Host line whose alias is separated by multiple tabs.
+func TestReplaceHostBlock_TabSeparatedHostLine(t *testing.T) {
+	content := "Host\t\tmyhost\n\tHostname\t\told.example.com\n\nHost\tother\n\tHostname other.example.com\n"
+	path := writeHostConfig(t, content)
+
+	hosts, err := Parse(path)
+	if err != nil || len(hosts) != 2 {
+		t.Fatalf("Parse: expected 2 hosts, got %d (err %v)", len(hosts), err)
+	}
+	h := hosts[0]
+	h.Hostname = "new.example.com"
+	if _, err := ReplaceHostBlock(h); err != nil {
+		t.Fatalf("ReplaceHostBlock failed: %v", err)
+	}
+
+	result, _ := os.ReadFile(path)
+	want := "Host myhost\n    Hostname new.example.com\n\nHost\tother\n\tHostname other.example.com\n"
+	if string(result) != want {
+		t.Errorf("unexpected result:\nwant: %q\ngot:  %q", want, string(result))
+	}
+}
+
+// TestReplaceHostBlock_BOMPrefixedFile verifies the first host of a file saved
+// with a UTF-8 BOM can still be located and rewritten.
+func TestReplaceHostBlock_BOMPrefixedFile(t *testing.T) {
+	path := writeHostConfig(t, "\ufeffHost first\n    Hostname old.example.com\n")
+
+	hosts, err := Parse(path)
+	if err != nil || len(hosts) != 1 {
+		t.Fatalf("Parse: expected 1 host, got %d (err %v)", len(hosts), err)
+	}
+	h := hosts[0]
+	h.Hostname = "new.example.com"
+	if _, err := ReplaceHostBlock(h); err != nil {
+		t.Fatalf("ReplaceHostBlock failed: %v", err)
+	}
+
+	result, _ := os.ReadFile(path)
+	want := "Host first\n    Hostname new.example.com\n"
+	if string(result) != want {
+		t.Errorf("unexpected result:\nwant: %q\ngot:  %q", want, string(result))
+	}
+}
+
+// TestReplaceHostBlock_RotatesBackups verifies successive edits keep earlier
+// backups as .bak.1 and .bak.2 instead of overwriting .bak.
+func TestReplaceHostBlock_RotatesBackups(t *testing.T) {
+	path := writeHostConfig(t, "Host myhost\n    Hostname v0.example.com\n")
+
+	var versions []string
+	for i := 1; i <= 4; i++ {
+		before, _ := os.ReadFile(path)
+		versions = append(versions, string(before))
+
+		hosts, err := Parse(path)
+		if err != nil || len(hosts) != 1 {
+			t.Fatalf("Parse: expected 1 host, got %d (err %v)", len(hosts), err)
+		}
+		h := hosts[0]
+		h.Hostname = fmt.Sprintf("v%d.example.com", i)
+		if _, err := ReplaceHostBlock(h); err != nil {
+			t.Fatalf("edit %d: ReplaceHostBlock failed: %v", i, err)
+		}
+	}
+
+	for suffix, want := range map[string]string{
+		".bak":   versions[3],
+		".bak.1": versions[2],
+		".bak.2": versions[1],
+	} {
+		got, err := os.ReadFile(path + suffix)
+		if err != nil {
+			t.Fatalf("expected %s to exist: %v", suffix, err)
+		}
+		if string(got) != want {
+			t.Errorf("%s: want %q, got %q", suffix, want, string(got))
+		}
+	}
+	if _, err := os.Stat(path + ".bak.3"); !os.IsNotExist(err) {
+		t.Errorf("expected at most 3 backups, found .bak.3 (err %v)", err)
+	}
+}
+
+// TestReplaceHostBlock_FailureKeepsBackups verifies a refused edit neither
+// rotates nor overwrites the existing backups.
+func TestReplaceHostBlock_FailureKeepsBackups(t *testing.T) {
+	path := writeHostConfig(t, "Host web1 web2\n    Hostname web.example.com\n")
+	backups := map[string]string{".bak": "newest\n", ".bak.1": "middle\n", ".bak.2": "oldest\n"}
+	for suffix, content := range backups {
+		if err := os.WriteFile(path+suffix, []byte(content), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	hosts, err := Parse(path)
+	testutil.AssertNoError(t, err, "Parse should not error")
+	h := hosts[0]
+	h.User = "deploy"
+	if _, err := ReplaceHostBlock(h); err == nil {
+		t.Fatal("expected editing a shared block to fail")
+	}
+
+	for suffix, want := range backups {
+		got, _ := os.ReadFile(path + suffix)
+		testutil.AssertStringEqual(t, string(got), want, suffix+" untouched")
+	}
+}
+
+func TestAppendHost_RotatesBackups(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config")
+	backupPath := filepath.Join(dir, "config.bak")
+
+	for _, alias := range []string{"one", "two", "three"} {
+		if err := AppendHost(configPath, backupPath, Host{Alias: alias, Hostname: alias + ".example.com"}); err != nil {
+			t.Fatalf("AppendHost %s failed: %v", alias, err)
+		}
+	}
+
+	bak, _ := os.ReadFile(backupPath)
+	bak1, _ := os.ReadFile(backupPath + ".1")
+	bak2, _ := os.ReadFile(backupPath + ".2")
+	if !strings.Contains(string(bak), "Host two") || strings.Contains(string(bak), "Host three") {
+		t.Errorf(".bak should hold the config before the third append, got %q", bak)
+	}
+	if !strings.Contains(string(bak1), "Host one") || strings.Contains(string(bak1), "Host two") {
+		t.Errorf(".bak.1 should hold the config before the second append, got %q", bak1)
+	}
+	if len(bak2) != 0 {
+		t.Errorf(".bak.2 should hold the empty original, got %q", bak2)
+	}
+}
+
+// TestReplaceHostBlock_UppercaseKeywords verifies block boundaries are found
+// regardless of Host keyword casing.
+func TestReplaceHostBlock_UppercaseKeywords(t *testing.T) {
+	path := writeHostConfig(t, "HOST dev\n    HOSTNAME old.example.com\n\nhost other\n    hostname other.example.com\n")
+
+	hosts, err := Parse(path)
+	if err != nil || len(hosts) != 2 {
+		t.Fatalf("Parse: expected 2 hosts, got %d (err %v)", len(hosts), err)
+	}
+	h := hosts[0]
+	h.Hostname = "new.example.com"
+	if _, err := ReplaceHostBlock(h); err != nil {
+		t.Fatalf("ReplaceHostBlock failed: %v", err)
+	}
+
+	result, _ := os.ReadFile(path)
+	want := "Host dev\n    Hostname new.example.com\n\nhost other\n    hostname other.example.com\n"
+	if string(result) != want {
+		t.Errorf("unexpected result:\nwant: %q\ngot:  %q", want, string(result))
+	}
+}
+
+// TestReplaceHostBlock_KeepsAfterLocal verifies that an edited host keeps its
+// "# @after-local" comment.
+func TestReplaceHostBlock_KeepsAfterLocal(t *testing.T) {
+	path := writeHostConfig(t, "Host tunnel\n    Hostname bastion\n    # @after-local open http://localhost:8080\n")
+	hosts, err := Parse(path)
+	if err != nil || len(hosts) != 1 {
+		t.Fatalf("Parse: %v (%d hosts)", err, len(hosts))
+	}
+
+	h := hosts[0]
+	h.User = "ops"
+	if _, err := ReplaceHostBlock(h); err != nil {
+		t.Fatalf("ReplaceHostBlock failed: %v", err)
+	}
+
+	reparsed, _ := Parse(path)
+	if reparsed[0].AfterLocal != "open http://localhost:8080" || reparsed[0].User != "ops" {
+		t.Errorf("after edit: User=%q AfterLocal=%q", reparsed[0].User, reparsed[0].AfterLocal)
+	}
+}
+
+// TestReplaceHostBlock_KeepsWeight verifies that an edited host keeps its
+// "# @weight" comment.
+func TestReplaceHostBlock_KeepsWeight(t *testing.T) {
+	path := writeHostConfig(t, "Host prod\n    Hostname prod.example.com\n    # @weight 4\n")
+	hosts, err := Parse(path)
+	if err != nil || len(hosts) != 1 {
+		t.Fatalf("Parse: %v (%d hosts)", err, len(hosts))
+	}
+
+	h := hosts[0]
+	h.User = "ops"
+	if _, err := ReplaceHostBlock(h); err != nil {
+		t.Fatalf("ReplaceHostBlock failed: %v", err)
+	}
+
+	reparsed, _ := Parse(path)
+	if reparsed[0].Weight != 4 || reparsed[0].User != "ops" {
+		t.Errorf("after edit: User=%q Weight=%d", reparsed[0].User, reparsed[0].Weight)
+	}
+}
+
+// TestReplaceHostBlocks_AccumulatesDrift verifies that rewriting several blocks
+// in one file shifts later hosts by the growth of the blocks above them, and
+// that the file is backed up only once.
+func TestReplaceHostBlocks_AccumulatesDrift(t *testing.T) {
+	content := "Host a\n    Hostname a.old.example.com\n\nHost b\n    Hostname b.old.example.com\n\nHost c\n    Hostname c.old.example.com\n"
+	path := writeHostConfig(t, content)
+	hosts, err := Parse(path)
+	if err != nil || len(hosts) != 3 {
+		t.Fatalf("Parse: %v (%d hosts)", err, len(hosts))
+	}
+
+	// a grows by a group comment and a User line; b shrinks to nothing extra;
+	// c must still be found two lines further down.
+	hosts[0].Groups = []string{"Work"}
+	hosts[0].User = "deploy"
+	for i := range hosts {
+		hosts[i].Hostname = strings.Replace(hosts[i].Hostname, "old", "new", 1)
+	}
+	// Pass them out of order to check results stay aligned with the input.
+	results, err := ReplaceHostBlocks([]Host{hosts[2], hosts[0], hosts[1]})
+	if err != nil {
+		t.Fatalf("ReplaceHostBlocks failed: %v", err)
+	}
+
+	if results[1].LineDelta != 2 || results[1].NewLineStart != 2 {
+		t.Errorf("a: got %+v; want LineDelta 2, NewLineStart 2", results[1])
+	}
+	if results[2].NewLineStart != 6 || results[0].NewLineStart != 9 {
+		t.Errorf("b/c NewLineStart = %d/%d; want 6/9", results[2].NewLineStart, results[0].NewLineStart)
+	}
+
+	reparsed, _ := Parse(path)
+	for i, line := range []int{2, 6, 9} {
+		hosts[i].LineStart = line
+	}
+	testutil.AssertHostsEqual(t, reparsed, hosts, "reparsed hosts")
+
+	backup, _ := os.ReadFile(path + ".bak")
+	if string(backup) != content {
+		t.Errorf("backup should hold the original file, got:\n%s", backup)
+	}
+	if _, err := os.Stat(path + ".bak.1"); !os.IsNotExist(err) {
+		t.Error("expected a single backup for one batch")
+	}
+}
+
+// TestReplaceHostBlocks_DuplicateLineStart verifies that two hosts claiming
+// the same block are rejected without writing.
+func TestReplaceHostBlocks_DuplicateLineStart(t *testing.T) {
+	content := "Host a\n    Hostname a\n"
+	path := writeHostConfig(t, content)
+	h := Host{Alias: "a", Hostname: "x", SourceFile: path, LineStart: 1}
+	if _, err := ReplaceHostBlocks([]Host{h, h}); err == nil {
+		t.Fatal("expected an error for duplicate LineStart")
+	}
+	data, _ := os.ReadFile(path)
+	if string(data) != content {
+		t.Errorf("file should be untouched, got:\n%s", data)
+	}
+}
+
+func TestRawBlock_ReturnsSourceLines(t *testing.T) {
+	content := "# top\n\n# @group Work\nHost web\n    Hostname web.example.com\n    # keep me\n    ServerAliveInterval 30\n\nHost db\n    Hostname db.example.com\n"
+	path := writeHostConfig(t, content)
+	hosts, err := Parse(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	lines, first, err := RawBlock(hosts[0])
+	if err != nil {
+		t.Fatal(err)
+	}
+	testutil.AssertEqual(t, first, 3, "first line")
+	testutil.AssertSliceEqual(t, lines, []string{
+		"# @group Work", "Host web", "    Hostname web.example.com", "    # keep me", "    ServerAliveInterval 30",
+	}, "block lines")
+}
+
+func TestRawBlock_StaleLineStart(t *testing.T) {
+	path := writeHostConfig(t, "Host web\n    Hostname web.example.com\n\nHost db\n    Hostname db.example.com\n")
+	hosts, _ := Parse(path)
+
+	stale := hosts[1]
+	stale.LineStart = 1 // now points at "Host web"
+	if _, _, err := RawBlock(stale); err == nil || !strings.Contains(err.Error(), "stale") {
+		t.Errorf("RawBlock with stale LineStart: err = %v; want stale error", err)
+	}
+
+	missing := hosts[0]
+	missing.SourceFile = filepath.Join(t.TempDir(), "gone")
+	if _, _, err := RawBlock(missing); err == nil {
+		t.Error("RawBlock on a missing file: want error")
+	}
+}
+
+const deleteConfig = "Host first\n    Hostname first.example.com\n\n# @group Work\nHost middle\n    Hostname middle.example.com\n    User deploy\n\nHost last\n    Hostname last.example.com\n"
+
+func TestDeleteHost(t *testing.T) {
+	tests := []struct {
+		name      string
+		alias     string
+		want      string
+		lineDelta int
+	}{
+		{
+			name:      "first",
+			alias:     "first",
+			want:      "# @group Work\nHost middle\n    Hostname middle.example.com\n    User deploy\n\nHost last\n    Hostname last.example.com\n",
+			lineDelta: -3,
+		},
+		{
+			name:      "middle with magic comment",
+			alias:     "middle",
+			want:      "Host first\n    Hostname first.example.com\n\nHost last\n    Hostname last.example.com\n",
+			lineDelta: -5,
+		},
+		{
+			name:      "last",
+			alias:     "last",
+			want:      "Host first\n    Hostname first.example.com\n\n# @group Work\nHost middle\n    Hostname middle.example.com\n    User deploy\n",
+			lineDelta: -3,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path := writeHostConfig(t, deleteConfig)
+			hosts, err := Parse(path)
+			testutil.AssertNoError(t, err, "Parse should not error")
+
+			var target Host
+			for _, h := range hosts {
+				if h.Alias == tt.alias {
+					target = h
+				}
+			}
+			lineDelta, err := DeleteHost(target)
+			testutil.AssertNoError(t, err, "DeleteHost should not error")
+			testutil.AssertEqual(t, lineDelta, tt.lineDelta, "lineDelta")
+
+			got, _ := os.ReadFile(path)
+			testutil.AssertStringEqual(t, string(got), tt.want, "config after delete")
+
+			// The remaining hosts re-parse with LineStart shifted by lineDelta.
+			reparsed, err := Parse(path)
+			testutil.AssertNoError(t, err, "re-parse should not error")
+			for _, h := range reparsed {
+				for _, orig := range hosts {
+					if orig.Alias != h.Alias {
+						continue
+					}
+					want := orig.LineStart
+					if orig.LineStart > target.LineStart {
+						want += lineDelta
+					}
+					testutil.AssertEqual(t, h.LineStart, want, h.Alias+" LineStart")
+				}
+			}
+		})
+	}
+}
+
+func TestDeleteHost_WritesBackup(t *testing.T) {
+	path := writeHostConfig(t, deleteConfig)
+	hosts, _ := Parse(path)
+
+	if _, err := DeleteHost(hosts[1]); err != nil {
+		t.Fatalf("DeleteHost failed: %v", err)
+	}
+
+	backup, err := os.ReadFile(path + ".bak")
+	testutil.AssertNoError(t, err, "backup should exist")
+	testutil.AssertStringEqual(t, string(backup), deleteConfig, "backup holds the pre-delete config")
+}
+
+func TestDeleteHost_StaleLineStart(t *testing.T) {
+	path := writeHostConfig(t, deleteConfig)
+
+	_, err := DeleteHost(Host{Alias: "first", SourceFile: path, LineStart: 2})
+	if err == nil {
+		t.Fatal("expected an error for a LineStart that is not a Host line")
+	}
+	got, _ := os.ReadFile(path)
+	testutil.AssertStringEqual(t, string(got), deleteConfig, "config untouched")
+	if _, err := os.Stat(path + ".bak"); !os.IsNotExist(err) {
+		t.Error("expected no backup when nothing was deleted")
+	}
+}
+
+func TestDeleteHost_LineStartOfAnotherHost(t *testing.T) {
+	path := writeHostConfig(t, deleteConfig)
+
+	// Line 9 is "Host last"; a stale LineStart for first must not delete it.
+	_, err := DeleteHost(Host{Alias: "first", SourceFile: path, LineStart: 9})
+	if err == nil {
+		t.Fatal("expected an error when LineStart points at another host")
+	}
+	got, _ := os.ReadFile(path)
+	testutil.AssertStringEqual(t, string(got), deleteConfig, "config untouched")
+}
+
+// TestDeleteHost_RefusesMultiPatternBlock verifies that deleting one alias of
+// "Host web1 web2" fails instead of removing web2 with it.
+func TestDeleteHost_RefusesMultiPatternBlock(t *testing.T) {
+	content := "Host web1 web2\n    Hostname web.example.com\n\nHost db\n    Hostname db.example.com\n"
+	path := writeHostConfig(t, content)
+	hosts, err := Parse(path)
+	testutil.AssertNoError(t, err, "Parse should not error")
+
+	if _, err := DeleteHost(hosts[0]); err == nil || !strings.Contains(err.Error(), "web1 web2") {
+		t.Errorf("DeleteHost(web1) error = %v; want one naming the shared Host line", err)
+	}
+	got, _ := os.ReadFile(path)
+	testutil.AssertStringEqual(t, string(got), content, "config untouched")
+	if _, err := os.Stat(path + ".bak"); !os.IsNotExist(err) {
+		t.Error("expected no backup when nothing was deleted")
+	}
+}
+
+func TestReplaceHostBlock_EqualsSeparatorHostLine(t *testing.T) {
+	path := writeHostConfig(t, "Host=first\n    Hostname=first.example.com\n\nHost = second\n    Hostname second.example.com\n")
+	hosts, err := Parse(path)
+	testutil.AssertNoError(t, err, "Parse should not error")
+
+	h := hosts[1]
+	h.User = "deploy"
+	if _, err := ReplaceHostBlock(h); err != nil {
+		t.Fatalf("ReplaceHostBlock should recognize \"Host = second\": %v", err)
+	}
+
+	got, _ := os.ReadFile(path)
+	want := "Host=first\n    Hostname=first.example.com\n\nHost second\n    Hostname second.example.com\n    User deploy\n"
+	testutil.AssertStringEqual(t, string(got), want, "config after edit")
+}
+
+func TestReplaceHostBlock_CanonicalizesAfterHostGroupComment(t *testing.T) {
+	path := writeHostConfig(t, "Host web\n# @group Work\n    Hostname web.example.com\n\nHost db\n    Hostname db.example.com\n")
+	hosts, err := Parse(path)
+	testutil.AssertNoError(t, err, "Parse should not error")
+
+	h := hosts[0]
+	h.User = "deploy"
+	res, err := ReplaceHostBlock(h)
+	if err != nil {
+		t.Fatalf("ReplaceHostBlock failed: %v", err)
+	}
+
+	got, _ := os.ReadFile(path)
+	want := "# @group Work\nHost web\n    Hostname web.example.com\n    User deploy\n\nHost db\n    Hostname db.example.com\n"
+	testutil.AssertStringEqual(t, string(got), want, "group comment moved above the Host line")
+	testutil.AssertEqual(t, res.NewLineStart, 2, "NewLineStart")
+
+	reparsed, _ := Parse(path)
+	testutil.AssertSliceEqual(t, reparsed[0].Groups, []string{"Work"}, "groups after rewrite")
+	testutil.AssertEqual(t, reparsed[1].LineStart, hosts[1].LineStart+res.LineDelta, "db LineStart follows lineDelta")
 }
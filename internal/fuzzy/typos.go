@@ -0,0 +1,72 @@
+// Package fuzzy provides typo-tolerant matching used as a fallback when
+// subsequence fuzzy search finds nothing.
+package fuzzy
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+// maxTypos is the largest edit distance FuzzyWithTypos accepts.
+const maxTypos = 2
+
+// FuzzyWithTypos returns the indices of items containing a word within a small
+// Levenshtein distance of query (case-insensitive), closest first and then in
+// item order. Words are split on any non-alphanumeric rune, so "prod.example.com"
+// offers "prod", "example" and "com". The allowed distance is at most 2 and at
+// most half the query length, so very short queries do not match everything.
+func FuzzyWithTypos(query string, items []string) []int {
+	q := []rune(strings.ToLower(query))
+	limit := min(maxTypos, len(q)/2)
+	if limit == 0 {
+		return nil
+	}
+
+	type hit struct{ index, dist int }
+	var hits []hit
+	for i, item := range items {
+		best := limit + 1
+		for _, word := range strings.FieldsFunc(strings.ToLower(item), isSeparator) {
+			if d := levenshtein(q, []rune(word)); d < best {
+				best = d
+			}
+		}
+		if best <= limit {
+			hits = append(hits, hit{i, best})
+		}
+	}
+
+	sort.SliceStable(hits, func(a, b int) bool { return hits[a].dist < hits[b].dist })
+	indices := make([]int, len(hits))
+	for i, h := range hits {
+		indices[i] = h.index
+	}
+	return indices
+}
+
+// isSeparator reports whether r splits words for typo matching.
+func isSeparator(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// levenshtein returns the edit distance between a and b.
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,66 @@
+package fuzzy
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestFuzzyWithTypos(t *testing.T) {
+	items := []string{
+		"staging staging.example.com",
+		"prod prod.example.com",
+		"pi 10.0.0.5",
+	}
+
+	cases := []struct {
+		name  string
+		query string
+		want  []int
+	}{
+		{"transposition", "pord", []int{1}},
+		{"hostname word", "exmaple", []int{0, 1}},
+		{"case-insensitive", "PORD", []int{1}},
+		{"too far", "xyzzy", []int{}},
+		{"short query", "p", []int{}},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			got := FuzzyWithTypos(tc.query, items)
+			if len(got) == 0 && len(tc.want) == 0 {
+				return
+			}
+			if !reflect.DeepEqual(got, tc.want) {
+				t.Errorf("FuzzyWithTypos(%q) = %v, want %v", tc.query, got, tc.want)
+			}
+		})
+	}
+}
+
+func TestFuzzyWithTypos_ExactOutranksTypo(t *testing.T) {
+	items := []string{"pord-typo-host", "prod", "prd"}
+	got := FuzzyWithTypos("prod", items)
+	if len(got) == 0 || got[0] != 1 {
+		t.Fatalf("expected exact match 'prod' ranked first, got %v", got)
+	}
+	if len(got) != 3 {
+		t.Errorf("expected all three near matches, got %v", got)
+	}
+}
+
+func TestLevenshtein(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"prod", "prod", 0},
+		{"pord", "prod", 2},
+		{"prod", "prd", 1},
+		{"kitten", "sitting", 3},
+	}
+	for _, tc := range cases {
+		if got := levenshtein([]rune(tc.a), []rune(tc.b)); got != tc.want {
+			t.Errorf("levenshtein(%q, %q) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
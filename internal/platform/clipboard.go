@@ -0,0 +1,73 @@
+package platform
+
+import (
+	"errors"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// ErrNoClipboard is returned by CopyToClipboard when no clipboard tool is
+// installed or none of the installed ones worked (e.g. xclip without a
+// display). Callers should show the text instead.
+var ErrNoClipboard = errors.New("no clipboard tool found")
+
+// clipboardCommands returns the clipboard tools to try on goos, in order of
+// preference, each as argv reading the text from stdin.
+func clipboardCommands(goos string) [][]string {
+	switch goos {
+	case "darwin":
+		return [][]string{{"pbcopy"}}
+	case "windows":
+		return [][]string{{"clip"}}
+	default:
+		return [][]string{
+			{"wl-copy"},
+			{"xclip", "-selection", "clipboard"},
+			{"xsel", "--clipboard", "--input"},
+		}
+	}
+}
+
+// detectClipboard returns the candidates whose program lookPath can find,
+// preserving order.
+func detectClipboard(candidates [][]string, lookPath func(string) (string, error)) [][]string {
+	var found [][]string
+	for _, argv := range candidates {
+		if _, err := lookPath(argv[0]); err == nil {
+			found = append(found, argv)
+		}
+	}
+	return found
+}
+
+// CopyToClipboard copies text to the system clipboard with the first
+// installed tool that succeeds. It returns an error wrapping ErrNoClipboard
+// if there is none.
+func CopyToClipboard(text string) error {
+	return copyWith(text, detectClipboard(clipboardCommands(runtime.GOOS), exec.LookPath), runWithStdin)
+}
+
+// copyWith tries each tool in turn and returns nil on the first success.
+func copyWith(text string, tools [][]string, run func(argv []string, stdin string) error) error {
+	var lastErr error
+	for _, argv := range tools {
+		if lastErr = run(argv, text); lastErr == nil {
+			return nil
+		}
+	}
+	if lastErr != nil {
+		return fmt.Errorf("%w (last tried: %v)", ErrNoClipboard, lastErr)
+	}
+	return ErrNoClipboard
+}
+
+func runWithStdin(argv []string, stdin string) error {
+	cmd := exec.Command(argv[0], argv[1:]...)
+	cmd.Stdin = strings.NewReader(stdin)
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%s: %w", argv[0], err)
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package platform
+
+import (
+	"errors"
+	"os/exec"
+	"strings"
+	"testing"
+)
+
+func TestDetectClipboard_KeepsPreferenceOrder(t *testing.T) {
+	installed := map[string]bool{"xsel": true, "xclip": true}
+	lookPath := func(name string) (string, error) {
+		if installed[name] {
+			return "/usr/bin/" + name, nil
+		}
+		return "", exec.ErrNotFound
+	}
+
+	found := detectClipboard(clipboardCommands("linux"), lookPath)
+	if len(found) != 2 || found[0][0] != "xclip" || found[1][0] != "xsel" {
+		t.Errorf("detectClipboard = %v; want xclip then xsel", found)
+	}
+
+	if found := detectClipboard(clipboardCommands("darwin"), lookPath); len(found) != 0 {
+		t.Errorf("expected nothing on darwin without pbcopy, got %v", found)
+	}
+}
+
+func TestCopyWith_FallsThroughToWorkingTool(t *testing.T) {
+	var tried []string
+	run := func(argv []string, stdin string) error {
+		tried = append(tried, argv[0])
+		if argv[0] == "wl-copy" {
+			return errors.New("no wayland display")
+		}
+		if stdin != "ssh prod" {
+			t.Errorf("stdin = %q", stdin)
+		}
+		return nil
+	}
+
+	err := copyWith("ssh prod", [][]string{{"wl-copy"}, {"xclip"}}, run)
+	if err != nil {
+		t.Fatalf("copyWith: %v", err)
+	}
+	if strings.Join(tried, ",") != "wl-copy,xclip" {
+		t.Errorf("tried %v", tried)
+	}
+}
+
+func TestCopyWith_NoToolReturnsSentinel(t *testing.T) {
+	run := func([]string, string) error { return errors.New("exit status 1") }
+
+	if err := copyWith("x", nil, run); !errors.Is(err, ErrNoClipboard) {
+		t.Errorf("no tools: got %v; want ErrNoClipboard", err)
+	}
+	if err := copyWith("x", [][]string{{"xclip"}}, run); !errors.Is(err, ErrNoClipboard) {
+		t.Errorf("all tools failing: got %v; want ErrNoClipboard", err)
+	}
+}
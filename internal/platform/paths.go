@@ -3,10 +3,26 @@ package platform
 import (
 	"os"
 	"path/filepath"
+	"runtime"
+	"strings"
 )
 
-// SSHConfigPath returns the path to ~/.ssh/config (or Windows equivalent).
+// ConfigEnvVar names the environment variable that overrides the SSH config
+// path SwiftSSH reads and writes.
+const ConfigEnvVar = "SWIFTSSH_CONFIG"
+
+// SSHConfigPath returns the SSH config path: $SWIFTSSH_CONFIG with ~ expanded
+// if set, otherwise DefaultSSHConfigPath.
 func SSHConfigPath() string {
+	if p := os.Getenv(ConfigEnvVar); p != "" {
+		return expandHome(p)
+	}
+	return DefaultSSHConfigPath()
+}
+
+// DefaultSSHConfigPath returns the path to ~/.ssh/config (or Windows
+// equivalent), the file ssh reads when not given -F.
+func DefaultSSHConfigPath() string {
 	home, err := os.UserHomeDir()
 	if err != nil {
 		return ""
@@ -14,13 +30,41 @@ func SSHConfigPath() string {
 	return filepath.Join(home, ".ssh", "config")
 }
 
-// SSHConfigBackupPath returns the path to ~/.ssh/config.bak (or Windows equivalent).
+// SSHConfigBackupPath returns the backup path for SSHConfigPath, which is
+// ~/.ssh/config.bak by default.
 func SSHConfigBackupPath() string {
+	p := SSHConfigPath()
+	if p == "" {
+		return ""
+	}
+	return p + ".bak"
+}
+
+// expandHome expands a leading ~ to the home directory. Paths that don't
+// start with ~, or when the home directory is unknown, are returned as is.
+func expandHome(path string) string {
+	if path != "~" && !strings.HasPrefix(path, "~/") && !strings.HasPrefix(path, `~\`) {
+		return path
+	}
 	home, err := os.UserHomeDir()
 	if err != nil {
-		return ""
+		return path
 	}
-	return filepath.Join(home, ".ssh", "config.bak")
+	return filepath.Join(home, path[1:])
+}
+
+// SystemSSHConfigPath returns the path to the system-wide SSH client config.
+// On Unix: /etc/ssh/ssh_config
+// On Windows: %ProgramData%\ssh\ssh_config
+func SystemSSHConfigPath() string {
+	if runtime.GOOS == "windows" {
+		programData := os.Getenv("ProgramData")
+		if programData == "" {
+			programData = `C:\ProgramData`
+		}
+		return filepath.Join(programData, "ssh", "ssh_config")
+	}
+	return "/etc/ssh/ssh_config"
 }
 
 // StateFilePath returns the path to the state file.
@@ -34,6 +78,16 @@ func StateFilePath() string {
 	return filepath.Join(configDir, "swiftssh", "state.json")
 }
 
+// ConnectionLogPath returns the path to the connection audit log, next to the
+// state file: ~/.config/swiftssh/connections.log on Unix.
+func ConnectionLogPath() string {
+	configDir, err := os.UserConfigDir()
+	if err != nil {
+		return ""
+	}
+	return filepath.Join(configDir, "swiftssh", "connections.log")
+}
+
 // SSHKeyDir returns the path to ~/.ssh (or Windows equivalent).
 func SSHKeyDir() string {
 	home, err := os.UserHomeDir()
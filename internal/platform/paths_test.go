@@ -38,6 +38,8 @@ func TestPathFunctions(t *testing.T) {
 		"SSHConfigBackupPath": SSHConfigBackupPath,
 		"StateFilePath":       StateFilePath,
 		"SSHKeyDir":           SSHKeyDir,
+		"ConnectionLogPath":   ConnectionLogPath,
+		"SystemSSHConfigPath": SystemSSHConfigPath,
 	}
 
 	for name, fn := range pathFuncs {
@@ -89,6 +91,39 @@ func TestSSHConfigPath(t *testing.T) {
 	})
 }
 
+// TestSSHConfigPath_EnvOverride validates that $SWIFTSSH_CONFIG replaces the
+// default path, with ~ expanded, and that an empty value is ignored.
+func TestSSHConfigPath_EnvOverride(t *testing.T) {
+	homeDir, err := os.UserHomeDir()
+	if err != nil {
+		t.Skip("cannot get home dir")
+	}
+	defaultPath := filepath.Join(homeDir, ".ssh", "config")
+
+	tests := []struct {
+		env  string
+		want string
+	}{
+		{"", defaultPath},
+		{"/etc/swiftssh/config", "/etc/swiftssh/config"},
+		{"~/work/ssh_config", filepath.Join(homeDir, "work", "ssh_config")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.env, func(t *testing.T) {
+			t.Setenv(ConfigEnvVar, tt.env)
+			if got := SSHConfigPath(); got != tt.want {
+				t.Errorf("SSHConfigPath() = %q; want %q", got, tt.want)
+			}
+			if got := SSHConfigBackupPath(); got != tt.want+".bak" {
+				t.Errorf("SSHConfigBackupPath() = %q; want %q", got, tt.want+".bak")
+			}
+			if got := DefaultSSHConfigPath(); got != defaultPath {
+				t.Errorf("DefaultSSHConfigPath() = %q; want %q", got, defaultPath)
+			}
+		})
+	}
+}
+
 // TestSSHConfigBackupPath validates SSH config backup path resolution.
 func TestSSHConfigBackupPath(t *testing.T) {
 	t.Run("returns non-empty path", func(t *testing.T) {
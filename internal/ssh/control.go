@@ -0,0 +1,24 @@
+package ssh
+
+import "os"
+
+// MasterArgs returns args with "-M -N -f" prepended, so ssh authenticates,
+// becomes a connection master, and moves to the background without running
+// a command. Later connections to the host reuse it through its ControlPath.
+func MasterArgs(args []string) []string {
+	return append([]string{"-M", "-N", "-f"}, args...)
+}
+
+// MasterRunning reports whether a control socket exists at path, meaning a
+// master connection is already listening there.
+func MasterRunning(path string) bool {
+	info, err := os.Stat(path)
+	return err == nil && info.Mode()&os.ModeSocket != 0
+}
+
+// NeedsMaster reports whether a master connection should be started for the
+// control socket at path: the host must have a socket path and nothing may be
+// listening there yet.
+func NeedsMaster(path string, running func(path string) bool) bool {
+	return path != "" && !running(path)
+}
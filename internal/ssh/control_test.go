@@ -0,0 +1,60 @@
+package ssh
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestMasterArgs(t *testing.T) {
+	got := strings.Join(MasterArgs([]string{"-F", "/tmp/config", "web"}), " ")
+	if want := "-M -N -f -F /tmp/config web"; got != want {
+		t.Errorf("MasterArgs = %q; want %q", got, want)
+	}
+}
+
+func TestMasterRunning(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("unix sockets")
+	}
+	dir := t.TempDir()
+	sock := filepath.Join(dir, "cm.sock")
+	if MasterRunning(sock) {
+		t.Error("MasterRunning = true for a missing socket")
+	}
+
+	plain := filepath.Join(dir, "plain")
+	if err := os.WriteFile(plain, nil, 0600); err != nil {
+		t.Fatal(err)
+	}
+	if MasterRunning(plain) {
+		t.Error("MasterRunning = true for a regular file")
+	}
+
+	l, err := net.Listen("unix", sock)
+	if err != nil {
+		t.Skipf("cannot create unix socket: %v", err)
+	}
+	defer l.Close()
+	if !MasterRunning(sock) {
+		t.Error("MasterRunning = false with a socket listening")
+	}
+}
+
+func TestNeedsMaster(t *testing.T) {
+	present := func(string) bool { return true }
+	absent := func(string) bool { return false }
+
+	if NeedsMaster("/tmp/cm-web", present) {
+		t.Error("NeedsMaster = true with the socket present")
+	}
+	if !NeedsMaster("/tmp/cm-web", absent) {
+		t.Error("NeedsMaster = false with the socket absent")
+	}
+	if NeedsMaster("", absent) {
+		t.Error("NeedsMaster = true without a ControlPath")
+	}
+}
@@ -2,14 +2,20 @@ package ssh
 
 import (
 	"os/exec"
+	"strings"
 
 	"github.com/srava/swiftssh/internal/config"
 )
 
 // BuildArgs constructs the SSH command-line arguments for a given host and identity.
+// An empty identity falls back to host.IdentityFile.
 func BuildArgs(host config.Host, identity string) []string {
 	var args []string
 
+	if identity == "" {
+		identity = host.IdentityFile
+	}
+
 	// Add identity flag if specified
 	if identity != "" {
 		args = append(args, "-i", identity)
@@ -31,7 +37,150 @@ func BuildArgs(host config.Host, identity string) []string {
 	return args
 }
 
+// BuildArgsWithConfig is BuildArgs with "-F configPath" prepended, so ssh
+// resolves the alias against the same config SwiftSSH read. An empty
+// configPath leaves ssh on its default config.
+func BuildArgsWithConfig(host config.Host, identity, configPath string) []string {
+	return WithConfigFile(configPath, BuildArgs(host, identity))
+}
+
+// WithConfigFile prepends "-F configPath" to args unless configPath is empty.
+func WithConfigFile(configPath string, args []string) []string {
+	if configPath == "" {
+		return args
+	}
+	return append([]string{"-F", configPath}, args...)
+}
+
+// MaxVerbosity is the highest ssh debug level, "-vvv".
+const MaxVerbosity = 3
+
+// BuildArgsWithVerbosity is BuildArgs with "-v", "-vv", or "-vvv" prepended for
+// level 1 to 3. Level 0 adds nothing; higher levels are capped at 3.
+func BuildArgsWithVerbosity(host config.Host, identity string, level int) []string {
+	return WithVerbosity(level, BuildArgs(host, identity))
+}
+
+// WithVerbosity prepends ssh's "-v" flag repeated level times (at most
+// MaxVerbosity) to args. It returns args unchanged for level <= 0.
+func WithVerbosity(level int, args []string) []string {
+	if level <= 0 {
+		return args
+	}
+	level = min(level, MaxVerbosity)
+	return append([]string{"-" + strings.Repeat("v", level)}, args...)
+}
+
+// BuildArgsDirect constructs SSH arguments that target [user@]hostname directly
+// instead of the alias, bypassing alias resolution in the SSH config.
+// Falls back to the alias when the host has no Hostname.
+func BuildArgsDirect(host config.Host, identity string) []string {
+	var args []string
+
+	if identity != "" {
+		args = append(args, "-i", identity)
+	}
+
+	if host.Port != "" && host.Port != "22" {
+		args = append(args, "-p", host.Port)
+	}
+
+	target := host.Hostname
+	if target == "" {
+		target = host.Alias
+	}
+	if host.User != "" {
+		target = host.User + "@" + target
+	}
+	args = append(args, target)
+
+	return args
+}
+
+// BuildSFTPArgs is BuildArgs for sftp, which spells the port flag -P and has
+// no -l, so the user goes into the destination as user@alias. An empty
+// identity falls back to host.IdentityFile.
+func BuildSFTPArgs(host config.Host, identity string) []string {
+	var args []string
+
+	if identity == "" {
+		identity = host.IdentityFile
+	}
+	if identity != "" {
+		args = append(args, "-i", identity)
+	}
+
+	if host.Port != "" && host.Port != "22" {
+		args = append(args, "-P", host.Port)
+	}
+
+	target := host.Alias
+	if host.User != "" {
+		target = host.User + "@" + target
+	}
+	return append(args, target)
+}
+
+// BuildSCPArgs constructs scp arguments copying src to dst. Whichever of them
+// is written "<host.Alias>:path" is rewritten to "[user@]hostname:path", with
+// an IPv6 hostname in brackets, so the command names the real destination;
+// the other is passed as is. scp still reads the config, but a Host block
+// keyed by the alias no longer matches the hostname. The fields SwiftSSH
+// models are therefore passed explicitly: the port as -P (scp's spelling),
+// the identity (falling back to host.IdentityFile), and ProxyJump. Other
+// directives in the host's block, such as ControlPath, do not apply.
+func BuildSCPArgs(host config.Host, identity, src, dst string) []string {
+	var args []string
+
+	if identity == "" {
+		identity = host.IdentityFile
+	}
+	if identity != "" {
+		args = append(args, "-i", identity)
+	}
+
+	if host.Port != "" && host.Port != "22" {
+		args = append(args, "-P", host.Port)
+	}
+
+	if host.ProxyJump != "" {
+		args = append(args, "-J", host.ProxyJump)
+	}
+
+	return append(args, scpPath(host, src), scpPath(host, dst))
+}
+
+// scpPath rewrites an "<alias>:path" argument to address host directly and
+// returns any other argument unchanged.
+func scpPath(host config.Host, arg string) string {
+	path, ok := strings.CutPrefix(arg, host.Alias+":")
+	if !ok {
+		return arg
+	}
+	target := host.Hostname
+	if target == "" {
+		target = host.Alias
+	}
+	if strings.Contains(target, ":") {
+		target = "[" + target + "]"
+	}
+	if host.User != "" {
+		target = host.User + "@" + target
+	}
+	return target + ":" + path
+}
+
+// SFTPCommand returns an exec.Cmd running sftp with the given arguments.
+func SFTPCommand(args []string) *exec.Cmd {
+	return exec.Command("sftp", args...)
+}
+
+// Command returns an exec.Cmd running ssh with the given arguments.
+func Command(args []string) *exec.Cmd {
+	return exec.Command("ssh", args...)
+}
+
 // ConnectCmd returns an exec.Cmd for connecting to the host via SSH.
 func ConnectCmd(host config.Host, identity string) *exec.Cmd {
-	return exec.Command("ssh", BuildArgs(host, identity)...)
+	return Command(BuildArgs(host, identity))
 }
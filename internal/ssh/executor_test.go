@@ -1,6 +1,7 @@
 package ssh
 
 import (
+	"strings"
 	"testing"
 
 	"github.com/srava/swiftssh/internal/config"
@@ -90,3 +91,127 @@ func TestBuildArgs_EmptyUserAndIdentity(t *testing.T) {
 		}
 	}
 }
+
+func TestBuildArgs_IdentityFallback(t *testing.T) {
+	tests := []struct {
+		name     string
+		hostKey  string
+		identity string
+		want     string
+	}{
+		{"explicit identity wins", "/keys/id_config", "/keys/id_override", "-i /keys/id_override prod"},
+		{"host IdentityFile when identity empty", "/keys/id_config", "", "-i /keys/id_config prod"},
+		{"neither present", "", "", "prod"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			host := config.Host{Alias: "prod", Hostname: "10.0.0.1", Port: "22", IdentityFile: tc.hostKey}
+			if got := strings.Join(BuildArgs(host, tc.identity), " "); got != tc.want {
+				t.Errorf("BuildArgs = %q; want %q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestBuildArgsDirect(t *testing.T) {
+	tests := []struct {
+		name     string
+		host     config.Host
+		identity string
+		want     []string
+	}{
+		{"user and hostname", config.Host{Alias: "dev", Hostname: "10.0.0.1", User: "alice", Port: "22"}, "", []string{"alice@10.0.0.1"}},
+		{"port and identity", config.Host{Alias: "dev", Hostname: "dev.example.com", User: "bob", Port: "2222"}, "/k", []string{"-i", "/k", "-p", "2222", "bob@dev.example.com"}},
+		{"no user", config.Host{Alias: "dev", Hostname: "dev.example.com", Port: "22"}, "", []string{"dev.example.com"}},
+		{"no hostname falls back to alias", config.Host{Alias: "dev", User: "carol"}, "", []string{"carol@dev"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BuildArgsDirect(tt.host, tt.identity)
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("BuildArgsDirect = %v; want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildSFTPArgs(t *testing.T) {
+	tests := []struct {
+		name     string
+		host     config.Host
+		identity string
+		want     []string
+	}{
+		{"default port", config.Host{Alias: "dev", Hostname: "10.0.0.1", User: "alice", Port: "22"}, "", []string{"alice@dev"}},
+		{"port is uppercase -P", config.Host{Alias: "dev", User: "bob", Port: "2222"}, "/k", []string{"-i", "/k", "-P", "2222", "bob@dev"}},
+		{"identity fallback", config.Host{Alias: "dev", IdentityFile: "~/.ssh/id_dev"}, "", []string{"-i", "~/.ssh/id_dev", "dev"}},
+		{"no user", config.Host{Alias: "dev", Port: "2200"}, "", []string{"-P", "2200", "dev"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := BuildSFTPArgs(tt.host, tt.identity)
+			if strings.Join(got, " ") != strings.Join(tt.want, " ") {
+				t.Errorf("BuildSFTPArgs = %v; want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildSCPArgs(t *testing.T) {
+	host := config.Host{Alias: "web", Hostname: "web.example.com", User: "deploy", Port: "2222", IdentityFile: "/keys/web"}
+	tests := []struct {
+		name     string
+		host     config.Host
+		identity string
+		src, dst string
+		want     string
+	}{
+		{"download", host, "", "web:/var/log/app.log", ".", "-i /keys/web -P 2222 deploy@web.example.com:/var/log/app.log ."},
+		{"upload with identity", host, "/keys/other", "build.tar", "web:/tmp/", "-i /keys/other -P 2222 build.tar deploy@web.example.com:/tmp/"},
+		{"default port, no user", config.Host{Alias: "db", Hostname: "10.0.0.2", Port: "22"}, "", "db:dump.sql", "out.sql", "10.0.0.2:dump.sql out.sql"},
+		{"ipv6 hostname", config.Host{Alias: "v6", Hostname: "fe80::1", User: "root"}, "", "v6:~/a", "a", "root@[fe80::1]:~/a a"},
+		{"proxy jump", config.Host{Alias: "in", Hostname: "10.1.0.5", ProxyJump: "bastion"}, "", "f", "in:f", "-J bastion f 10.1.0.5:f"},
+		{"no hostname keeps alias", config.Host{Alias: "box"}, "", "box:x", "y", "box:x y"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := strings.Join(BuildSCPArgs(tt.host, tt.identity, tt.src, tt.dst), " ")
+			if got != tt.want {
+				t.Errorf("BuildSCPArgs = %q; want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildArgsWithConfig(t *testing.T) {
+	host := config.Host{Alias: "dev", Hostname: "10.0.0.1", User: "alice", Port: "22"}
+
+	got := BuildArgsWithConfig(host, "", "/tmp/other")
+	if want := "-F /tmp/other -l alice dev"; strings.Join(got, " ") != want {
+		t.Errorf("BuildArgsWithConfig = %v; want %q", got, want)
+	}
+
+	got = BuildArgsWithConfig(host, "", "")
+	if want := "-l alice dev"; strings.Join(got, " ") != want {
+		t.Errorf("BuildArgsWithConfig with default config = %v; want %q", got, want)
+	}
+}
+
+func TestBuildArgsWithVerbosity(t *testing.T) {
+	host := config.Host{Alias: "dev", Hostname: "10.0.0.1", User: "alice", Port: "2222"}
+
+	got := BuildArgsWithVerbosity(host, "/keys/id", 2)
+	if want := "-vv -i /keys/id -p 2222 -l alice dev"; strings.Join(got, " ") != want {
+		t.Errorf("level 2 = %v; want %q", got, want)
+	}
+
+	got = BuildArgsWithVerbosity(host, "", 0)
+	if want := "-p 2222 -l alice dev"; strings.Join(got, " ") != want {
+		t.Errorf("level 0 = %v; want %q", got, want)
+	}
+
+	got = BuildArgsWithVerbosity(host, "", 7)
+	if got[0] != "-vvv" {
+		t.Errorf("level 7 = %v; want capped at -vvv", got)
+	}
+}
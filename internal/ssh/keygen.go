@@ -0,0 +1,57 @@
+package ssh
+
+import (
+	"os"
+	"os/exec"
+
+	"github.com/srava/swiftssh/internal/config"
+)
+
+// AttachedRunner runs the named program on the current terminal and waits
+// for it to exit. Unlike Runner it is for interactive tools such as
+// ssh-keygen, which prompt for a passphrase.
+type AttachedRunner func(name string, args ...string) error
+
+// RunAttached is the default AttachedRunner: it connects the program to the
+// process's stdin, stdout, and stderr.
+func RunAttached(name string, args ...string) error {
+	cmd := exec.Command(name, args...)
+	cmd.Stdin = os.Stdin
+	cmd.Stdout = os.Stdout
+	cmd.Stderr = os.Stderr
+	return cmd.Run()
+}
+
+// DefaultKeyType is the key type KeygenArgs uses when none is given.
+const DefaultKeyType = "ed25519"
+
+// KeygenArgs returns the ssh-keygen arguments that create a key of keyType
+// (DefaultKeyType if empty) at path, labelled with comment if it is set.
+func KeygenArgs(path, keyType, comment string) []string {
+	if keyType == "" {
+		keyType = DefaultKeyType
+	}
+	args := []string{"-t", keyType, "-f", path}
+	if comment != "" {
+		args = append(args, "-C", comment)
+	}
+	return args
+}
+
+// CopyIDArgs returns the ssh-copy-id arguments that install the public half
+// of keyPath on host. Like BuildArgsDirect it targets [user@]hostname, since
+// ssh-copy-id cannot be pointed at a non-default config.
+func CopyIDArgs(host config.Host, keyPath string) []string {
+	args := []string{"-i", keyPath + ".pub"}
+	if host.Port != "" && host.Port != "22" {
+		args = append(args, "-p", host.Port)
+	}
+	target := host.Hostname
+	if target == "" {
+		target = host.Alias
+	}
+	if host.User != "" {
+		target = host.User + "@" + target
+	}
+	return append(args, target)
+}
@@ -0,0 +1,33 @@
+package ssh
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/srava/swiftssh/internal/config"
+)
+
+func TestKeygenArgs(t *testing.T) {
+	got := strings.Join(KeygenArgs("/home/a/.ssh/id_ed25519_web", "", "a@laptop"), " ")
+	if want := "-t ed25519 -f /home/a/.ssh/id_ed25519_web -C a@laptop"; got != want {
+		t.Errorf("KeygenArgs = %q; want %q", got, want)
+	}
+
+	got = strings.Join(KeygenArgs("/k/id_rsa", "rsa", ""), " ")
+	if want := "-t rsa -f /k/id_rsa"; got != want {
+		t.Errorf("KeygenArgs without comment = %q; want %q", got, want)
+	}
+}
+
+func TestCopyIDArgs(t *testing.T) {
+	host := config.Host{Alias: "web", Hostname: "10.0.0.5", User: "deploy", Port: "2222"}
+	got := strings.Join(CopyIDArgs(host, "/k/id_web"), " ")
+	if want := "-i /k/id_web.pub -p 2222 deploy@10.0.0.5"; got != want {
+		t.Errorf("CopyIDArgs = %q; want %q", got, want)
+	}
+
+	got = strings.Join(CopyIDArgs(config.Host{Alias: "bare", Port: "22"}, "/k/id"), " ")
+	if want := "-i /k/id.pub bare"; got != want {
+		t.Errorf("CopyIDArgs fallback = %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,107 @@
+package ssh
+
+import (
+	"fmt"
+	"os/exec"
+	"strings"
+)
+
+// Runner runs the named program with args and returns its standard output.
+// Launchers exit as soon as the new window or tab is open, so this does not
+// wait for the session itself.
+type Runner func(name string, args ...string) (string, error)
+
+// RunOutput is the default Runner.
+func RunOutput(name string, args ...string) (string, error) {
+	out, err := exec.Command(name, args...).Output()
+	return string(out), err
+}
+
+// Launcher opens an SSH session outside the current terminal, e.g. in a new
+// tmux window or a new terminal tab.
+type Launcher struct {
+	Name  string                                        // human-readable name, e.g. "tmux"
+	Build func(title string, sshArgs []string) []string // full argv including the program
+	// Focus and Kill return argv that brings the session with the given ID to
+	// the front or closes it. They are nil when the launcher's sessions cannot
+	// be followed once opened; Build then prints no ID either.
+	Focus func(id string) []string
+	Kill  func(id string) []string
+}
+
+// DetectLauncher picks a Launcher for the current environment. getenv is
+// usually os.Getenv and goos is usually runtime.GOOS. Multiplexers take
+// priority over platform terminal apps. Returns false if none is available.
+func DetectLauncher(getenv func(string) string, goos string) (Launcher, bool) {
+	switch {
+	case getenv("TMUX") != "":
+		// -P -F prints the new window's ID, which tmux never reuses.
+		return Launcher{Name: "tmux", Build: func(title string, sshArgs []string) []string {
+			return append([]string{"tmux", "new-window", "-P", "-F", "#{window_id}", "-n", title, "ssh"}, sshArgs...)
+		}, Focus: func(id string) []string {
+			return []string{"tmux", "select-window", "-t", id}
+		}, Kill: func(id string) []string {
+			return []string{"tmux", "kill-window", "-t", id}
+		}}, true
+	case getenv("STY") != "":
+		return Launcher{Name: "screen", Build: func(title string, sshArgs []string) []string {
+			return append([]string{"screen", "-t", title, "ssh"}, sshArgs...)
+		}}, true
+	case getenv("WT_SESSION") != "":
+		return Launcher{Name: "Windows Terminal", Build: func(title string, sshArgs []string) []string {
+			return append([]string{"wt.exe", "-w", "0", "new-tab", "--title", title, "ssh"}, sshArgs...)
+		}}, true
+	case goos == "darwin":
+		return Launcher{Name: "Terminal", Build: func(title string, sshArgs []string) []string {
+			script := `tell application "Terminal" to do script "` +
+				strings.ReplaceAll(ShellJoin(append([]string{"ssh"}, sshArgs...)), `"`, `\"`) + `"`
+			return []string{"osascript", "-e", script}
+		}}, true
+	}
+	return Launcher{}, false
+}
+
+// Spawn launches an SSH session for host via l using run. It returns the
+// session's ID for launchers that can follow it (see Launcher.Kill), and ""
+// for the others.
+func Spawn(l Launcher, run Runner, title string, sshArgs []string) (string, error) {
+	argv := l.Build(title, sshArgs)
+	out, err := run(argv[0], argv[1:]...)
+	if err != nil || l.Kill == nil {
+		return "", err
+	}
+	id := strings.TrimSpace(out)
+	if id == "" {
+		return "", fmt.Errorf("%s did not report the new window", l.Name)
+	}
+	return id, nil
+}
+
+// ShellJoin joins args into a single POSIX sh command line, single-quoting
+// any argument that contains characters outside a conservative safe set.
+func ShellJoin(args []string) string {
+	quoted := make([]string, len(args))
+	for i, a := range args {
+		quoted[i] = shellQuote(a)
+	}
+	return strings.Join(quoted, " ")
+}
+
+// shellQuote returns s quoted for POSIX sh if needed.
+func shellQuote(s string) string {
+	if s == "" {
+		return "''"
+	}
+	safe := true
+	for _, r := range s {
+		if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' ||
+			strings.ContainsRune("@%+=:,./_-~", r)) {
+			safe = false
+			break
+		}
+	}
+	if safe {
+		return s
+	}
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
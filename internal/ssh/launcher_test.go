@@ -0,0 +1,113 @@
+package ssh
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+// fakeEnv returns a getenv func backed by vars.
+func fakeEnv(vars map[string]string) func(string) string {
+	return func(k string) string { return vars[k] }
+}
+
+func TestDetectLauncher(t *testing.T) {
+	tests := []struct {
+		name     string
+		env      map[string]string
+		goos     string
+		wantName string
+		wantOK   bool
+		wantArgv string
+	}{
+		{"tmux", map[string]string{"TMUX": "/tmp/tmux-1000/default,1,0"}, "linux", "tmux", true, "tmux new-window -P -F #{window_id} -n dev ssh -l alice dev"},
+		{"screen", map[string]string{"STY": "1234.pts-0"}, "linux", "screen", true, "screen -t dev ssh -l alice dev"},
+		{"windows terminal", map[string]string{"WT_SESSION": "abc"}, "windows", "Windows Terminal", true, "wt.exe -w 0 new-tab --title dev ssh -l alice dev"},
+		{"tmux beats macOS Terminal", map[string]string{"TMUX": "x"}, "darwin", "tmux", true, "tmux new-window -P -F #{window_id} -n dev ssh -l alice dev"},
+		{"macOS Terminal", map[string]string{}, "darwin", "Terminal", true, ""},
+		{"nothing available", map[string]string{}, "linux", "", false, ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			l, ok := DetectLauncher(fakeEnv(tt.env), tt.goos)
+			if ok != tt.wantOK {
+				t.Fatalf("ok = %v; want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if l.Name != tt.wantName {
+				t.Errorf("Name = %q; want %q", l.Name, tt.wantName)
+			}
+			if tt.wantArgv != "" {
+				got := strings.Join(l.Build("dev", []string{"-l", "alice", "dev"}), " ")
+				if got != tt.wantArgv {
+					t.Errorf("argv = %q; want %q", got, tt.wantArgv)
+				}
+			}
+		})
+	}
+}
+
+func TestDetectLauncher_MacOSScriptQuotesArgs(t *testing.T) {
+	l, _ := DetectLauncher(fakeEnv(nil), "darwin")
+	argv := l.Build("dev", []string{"-i", "/Users/me/my key", "dev"})
+	if argv[0] != "osascript" {
+		t.Fatalf("expected osascript, got %q", argv[0])
+	}
+	if !strings.Contains(argv[2], `ssh -i '/Users/me/my key' dev`) {
+		t.Errorf("expected quoted ssh command in script, got %q", argv[2])
+	}
+}
+
+func TestSpawn_UsesRunner(t *testing.T) {
+	l, _ := DetectLauncher(fakeEnv(map[string]string{"TMUX": "x"}), "linux")
+
+	var gotName string
+	var gotArgs []string
+	run := func(name string, args ...string) (string, error) {
+		gotName, gotArgs = name, args
+		return "@12\n", nil
+	}
+	id, err := Spawn(l, run, "dev", []string{"dev"})
+	if err != nil {
+		t.Fatalf("Spawn failed: %v", err)
+	}
+	if id != "@12" {
+		t.Errorf("expected the printed window ID, got %q", id)
+	}
+	if gotName != "tmux" || strings.Join(gotArgs, " ") != "new-window -P -F #{window_id} -n dev ssh dev" {
+		t.Errorf("runner got %q %v", gotName, gotArgs)
+	}
+	if got := strings.Join(l.Kill(id), " "); got != "tmux kill-window -t @12" {
+		t.Errorf("Kill argv = %q", got)
+	}
+
+	wantErr := errors.New("boom")
+	if _, err := Spawn(l, func(string, ...string) (string, error) { return "", wantErr }, "dev", nil); err != wantErr {
+		t.Errorf("expected runner error to propagate, got %v", err)
+	}
+	if _, err := Spawn(l, func(string, ...string) (string, error) { return "", nil }, "dev", nil); err == nil {
+		t.Error("expected an error when tmux prints no window ID")
+	}
+}
+
+func TestSpawn_UntrackedLauncherHasNoID(t *testing.T) {
+	l, _ := DetectLauncher(fakeEnv(map[string]string{"STY": "1234.pts-0"}), "linux")
+	if l.Focus != nil || l.Kill != nil {
+		t.Fatal("screen sessions cannot be followed; Focus and Kill should be nil")
+	}
+	id, err := Spawn(l, func(string, ...string) (string, error) { return "ignored", nil }, "dev", []string{"dev"})
+	if err != nil || id != "" {
+		t.Errorf("Spawn = %q, %v; want no ID", id, err)
+	}
+}
+
+func TestShellJoin(t *testing.T) {
+	got := ShellJoin([]string{"ssh", "-i", "/home/me/my key", "it's", "", "user@host"})
+	want := `ssh -i '/home/me/my key' 'it'\''s' '' user@host`
+	if got != want {
+		t.Errorf("ShellJoin = %q; want %q", got, want)
+	}
+}
@@ -0,0 +1,30 @@
+package ssh
+
+import (
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// LocalShellArgs returns argv running command through the platform shell.
+func LocalShellArgs(goos, command string) []string {
+	if goos == "windows" {
+		return []string{"cmd", "/C", command}
+	}
+	return []string{"sh", "-c", command}
+}
+
+// RunLocal runs command through the local shell and waits for it. A failure
+// includes the command's trimmed output.
+func RunLocal(command string) error {
+	argv := LocalShellArgs(runtime.GOOS, command)
+	out, err := exec.Command(argv[0], argv[1:]...).CombinedOutput()
+	if err != nil {
+		if msg := strings.TrimSpace(string(out)); msg != "" {
+			return fmt.Errorf("%w: %s", err, msg)
+		}
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,29 @@
+package ssh
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestLocalShellArgs(t *testing.T) {
+	if got := strings.Join(LocalShellArgs("linux", "open x"), "|"); got != "sh|-c|open x" {
+		t.Errorf("linux: got %q", got)
+	}
+	if got := strings.Join(LocalShellArgs("windows", "start x"), "|"); got != "cmd|/C|start x" {
+		t.Errorf("windows: got %q", got)
+	}
+}
+
+func TestRunLocal_ReportsFailureOutput(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("uses sh")
+	}
+	if err := RunLocal("true"); err != nil {
+		t.Errorf("RunLocal(true) = %v", err)
+	}
+	err := RunLocal("echo boom >&2; exit 3")
+	if err == nil || !strings.Contains(err.Error(), "boom") {
+		t.Errorf("expected error mentioning output, got %v", err)
+	}
+}
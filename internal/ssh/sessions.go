@@ -0,0 +1,68 @@
+package ssh
+
+import (
+	"os/exec"
+	"time"
+)
+
+// Session is an SSH session launched outside the TUI via a Launcher that can
+// follow it (currently tmux).
+type Session struct {
+	Alias    string
+	ID       string // launcher's ID for the session, e.g. tmux window "@3"
+	Started  time.Time
+	Launcher string // Launcher.Name that opened the session
+}
+
+// Registry tracks sessions launched by this process. It is in-memory only.
+type Registry struct {
+	sessions []Session
+	alive    func(id string) bool
+}
+
+// NewRegistry returns an empty Registry that uses alive to decide whether a
+// session is still open. alive is usually TmuxWindowAlive.
+func NewRegistry(alive func(id string) bool) *Registry {
+	return &Registry{alive: alive}
+}
+
+// Add records a newly launched session.
+func (r *Registry) Add(s Session) {
+	r.sessions = append(r.sessions, s)
+}
+
+// Remove forgets the session with the given ID and reports whether it was found.
+func (r *Registry) Remove(id string) bool {
+	for i, s := range r.sessions {
+		if s.ID == id {
+			r.sessions = append(r.sessions[:i], r.sessions[i+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// Prune removes sessions that have been closed and returns them.
+func (r *Registry) Prune() []Session {
+	var live, dead []Session
+	for _, s := range r.sessions {
+		if r.alive(s.ID) {
+			live = append(live, s)
+		} else {
+			dead = append(dead, s)
+		}
+	}
+	r.sessions = live
+	return dead
+}
+
+// List returns the tracked sessions, oldest first.
+func (r *Registry) List() []Session {
+	return append([]Session(nil), r.sessions...)
+}
+
+// TmuxWindowAlive reports whether the tmux window with the given ID is still
+// open.
+func TmuxWindowAlive(id string) bool {
+	return exec.Command("tmux", "display-message", "-p", "-t", id, "").Run() == nil
+}
@@ -0,0 +1,50 @@
+package ssh
+
+import (
+	"testing"
+	"time"
+)
+
+func TestRegistry_AddRemoveLifecycle(t *testing.T) {
+	r := NewRegistry(func(string) bool { return true })
+	r.Add(Session{Alias: "prod", ID: "@1", Started: time.Now(), Launcher: "tmux"})
+	r.Add(Session{Alias: "dev", ID: "@2", Started: time.Now(), Launcher: "tmux"})
+
+	if got := r.List(); len(got) != 2 || got[0].Alias != "prod" || got[1].Alias != "dev" {
+		t.Fatalf("expected prod, dev in launch order, got %v", got)
+	}
+
+	if !r.Remove("@1") {
+		t.Error("expected Remove to find @1")
+	}
+	if r.Remove("@1") {
+		t.Error("expected second Remove of @1 to report not found")
+	}
+	if got := r.List(); len(got) != 1 || got[0].ID != "@2" {
+		t.Errorf("expected only dev to remain, got %v", got)
+	}
+}
+
+func TestRegistry_PruneDropsClosedSessions(t *testing.T) {
+	closed := map[string]bool{"@2": true}
+	r := NewRegistry(func(id string) bool { return !closed[id] })
+	r.Add(Session{Alias: "prod", ID: "@1"})
+	r.Add(Session{Alias: "dev", ID: "@2"})
+
+	pruned := r.Prune()
+	if len(pruned) != 1 || pruned[0].Alias != "dev" {
+		t.Errorf("expected dev to be pruned, got %v", pruned)
+	}
+	if got := r.List(); len(got) != 1 || got[0].Alias != "prod" {
+		t.Errorf("expected prod to remain, got %v", got)
+	}
+}
+
+func TestRegistry_ListIsACopy(t *testing.T) {
+	r := NewRegistry(TmuxWindowAlive)
+	r.Add(Session{Alias: "prod", ID: "@1"})
+	r.List()[0].Alias = "changed"
+	if r.List()[0].Alias != "prod" {
+		t.Error("mutating List's result must not change the registry")
+	}
+}
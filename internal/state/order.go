@@ -0,0 +1,111 @@
+package state
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/srava/swiftssh/internal/config"
+)
+
+// Sort modes accepted by OrderHostsBy.
+const (
+	SortFrequency = "frequency" // hosts with connections first by count, then the rest alphabetically
+	SortAlpha     = "alpha"     // flat alphabetical
+	SortGroup     = "group"     // by first group, then count, then alias; ungrouped hosts last
+	SortRecent    = "recent"    // most recently connected first, then the rest alphabetically
+)
+
+// OrderHosts returns hosts in TUI display order. If noFrequent is true, hosts
+// are sorted purely alphabetically (case-insensitive); otherwise hosts with
+// connections come first by count (descending), followed by the rest
+// alphabetically. The input slice is not modified.
+func OrderHosts(hosts []config.Host, s *State, noFrequent bool) []config.Host {
+	if noFrequent {
+		return OrderHostsBy(hosts, s, SortAlpha)
+	}
+	return OrderHostsBy(hosts, s, SortFrequency)
+}
+
+// OrderHostsBy returns hosts ordered by mode, one of SortFrequency, SortAlpha,
+// SortGroup, or SortRecent; an unknown mode sorts by frequency. With SortGroup a nil s
+// ranks hosts within each group alphabetically. The input slice is not
+// modified.
+func OrderHostsBy(hosts []config.Host, s *State, mode string) []config.Host {
+	switch mode {
+	case SortAlpha:
+		ordered := make([]config.Host, len(hosts))
+		copy(ordered, hosts)
+		sortByAlias(ordered)
+		return ordered
+	case SortGroup:
+		ordered := make([]config.Host, len(hosts))
+		copy(ordered, hosts)
+		sortByGroup(ordered, s)
+		return ordered
+	}
+
+	// Get frequent (or recent) hosts, most used first
+	frequent := FrequentHosts(s, hosts, len(hosts))
+	if mode == SortRecent {
+		frequent = RecentHosts(s, hosts, len(hosts))
+	}
+
+	// Build a set of frequent host IDs to exclude from remaining hosts
+	frequentSet := make(map[string]bool)
+	for _, h := range frequent {
+		frequentSet[hostKey(h)] = true
+	}
+
+	// Collect remaining hosts (not in frequent set)
+	var remaining []config.Host
+	for _, h := range hosts {
+		if !frequentSet[hostKey(h)] {
+			remaining = append(remaining, h)
+		}
+	}
+	sortByAlias(remaining)
+
+	return append(frequent, remaining...)
+}
+
+// hostKey identifies a host by alias and source file, so duplicate aliases in
+// different files are treated as distinct entries.
+func hostKey(h config.Host) string {
+	return h.Alias + "\x00" + h.SourceFile
+}
+
+// sortByGroup sorts hosts by their first group (case-insensitive), with
+// ungrouped hosts last; within a group by weighted connection count
+// (descending), then alias.
+func sortByGroup(hosts []config.Host, s *State) {
+	sort.SliceStable(hosts, func(i, j int) bool {
+		gi, gj := primaryGroup(hosts[i]), primaryGroup(hosts[j])
+		if gi != gj {
+			if gi == "" || gj == "" {
+				return gj == ""
+			}
+			return gi < gj
+		}
+		if s != nil {
+			if ci, cj := weightedCount(s, hosts[i]), weightedCount(s, hosts[j]); ci != cj {
+				return ci > cj
+			}
+		}
+		return strings.ToLower(hosts[i].Alias) < strings.ToLower(hosts[j].Alias)
+	})
+}
+
+// primaryGroup returns h's first group, lower-cased, or "" if it has none.
+func primaryGroup(h config.Host) string {
+	if len(h.Groups) == 0 {
+		return ""
+	}
+	return strings.ToLower(h.Groups[0])
+}
+
+// sortByAlias sorts hosts alphabetically by alias (case-insensitive).
+func sortByAlias(hosts []config.Host) {
+	sort.Slice(hosts, func(i, j int) bool {
+		return strings.ToLower(hosts[i].Alias) < strings.ToLower(hosts[j].Alias)
+	})
+}
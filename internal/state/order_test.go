@@ -0,0 +1,103 @@
+package state
+
+import (
+	"testing"
+
+	"github.com/srava/swiftssh/internal/config"
+)
+
+// aliases returns the alias of each host, in order.
+func aliases(hosts []config.Host) []string {
+	out := make([]string, len(hosts))
+	for i, h := range hosts {
+		out[i] = h.Alias
+	}
+	return out
+}
+
+func TestOrderHosts(t *testing.T) {
+	hosts := []config.Host{
+		{Alias: "beta"}, {Alias: "Alpha"}, {Alias: "gamma"}, {Alias: "delta"},
+	}
+	s := &State{Connections: map[string]int{"gamma": 5, "delta": 2}}
+
+	t.Run("frequent first then alphabetical", func(t *testing.T) {
+		got := aliases(OrderHosts(hosts, s, false))
+		want := []string{"gamma", "delta", "Alpha", "beta"}
+		assertAliases(t, got, want)
+	})
+
+	t.Run("noFrequent is flat alphabetical", func(t *testing.T) {
+		got := aliases(OrderHosts(hosts, s, true))
+		want := []string{"Alpha", "beta", "delta", "gamma"}
+		assertAliases(t, got, want)
+	})
+
+	t.Run("input is not modified", func(t *testing.T) {
+		_ = OrderHosts(hosts, s, true)
+		if hosts[0].Alias != "beta" {
+			t.Errorf("expected input order preserved, got %v", aliases(hosts))
+		}
+	})
+}
+
+// assertAliases compares alias sequences.
+func assertAliases(t *testing.T, got, want []string) {
+	t.Helper()
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("got %v, want %v", got, want)
+		}
+	}
+}
+
+// TestOrderHosts_Weight verifies that "# @weight" multiplies connection
+// counts: weight 3 with 2 connections (6) outranks weight 1 with 5.
+func TestOrderHosts_Weight(t *testing.T) {
+	hosts := []config.Host{
+		{Alias: "dev", Weight: 1},
+		{Alias: "prod", Weight: 3},
+		{Alias: "unweighted"},
+	}
+	s := &State{Connections: map[string]int{"dev": 5, "prod": 2, "unweighted": 4}}
+
+	got := aliases(OrderHosts(hosts, s, false))
+	assertAliases(t, got, []string{"prod", "dev", "unweighted"})
+}
+
+// TestOrderHostsBy_Group verifies group order sorts by first group, then by
+// connection count within the group, then alias, with ungrouped hosts last.
+func TestOrderHostsBy_Group(t *testing.T) {
+	hosts := []config.Host{
+		{Alias: "loner"},
+		{Alias: "web2", Groups: []string{"Work"}},
+		{Alias: "pi", Groups: []string{"home"}},
+		{Alias: "web1", Groups: []string{"Work"}},
+		{Alias: "db", Groups: []string{"work", "Databases"}},
+		{Alias: "nas", Groups: []string{"Home"}},
+		{Alias: "adhoc"},
+	}
+	s := &State{Connections: map[string]int{"web1": 2, "web2": 5, "db": 7, "pi": 3, "loner": 9}}
+
+	got := aliases(OrderHostsBy(hosts, s, SortGroup))
+	assertAliases(t, got, []string{"pi", "nas", "db", "web2", "web1", "loner", "adhoc"})
+
+	t.Run("nil state ranks by alias within groups", func(t *testing.T) {
+		got := aliases(OrderHostsBy(hosts, nil, SortGroup))
+		assertAliases(t, got, []string{"nas", "pi", "db", "web1", "web2", "adhoc", "loner"})
+	})
+}
+
+func TestOrderHostsBy_Recent(t *testing.T) {
+	hosts := []config.Host{{Alias: "zeta"}, {Alias: "old"}, {Alias: "busy"}, {Alias: "alpha"}}
+	s := &State{
+		Connections:   map[string]int{"busy": 40, "old": 1, "zeta": 2},
+		LastConnected: map[string]int64{"old": 900, "busy": 100, "zeta": 500},
+	}
+
+	got := aliases(OrderHostsBy(hosts, s, SortRecent))
+	assertAliases(t, got, []string{"old", "zeta", "busy", "alpha"})
+}
@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"sort"
+	"time"
 
 	"github.com/srava/swiftssh/internal/config"
 	"github.com/srava/swiftssh/internal/platform"
@@ -12,10 +13,20 @@ import (
 
 // State represents the persistent state of SwiftSSH, tracking connection history.
 type State struct {
-	Connections map[string]int `json:"connections"` // key: host alias, value: count
-	FirstRun    bool           `json:"first_run"`
+	Connections map[string]int `json:"connections"`      // key: host alias, value: count
+	Pinned      []string       `json:"pinned,omitempty"` // favorite host aliases, in pin order
+	// LastError maps an alias to why its last ssh session failed. It is
+	// cleared by the next successful session.
+	LastError map[string]string `json:"last_error,omitempty"`
+	// LastConnected maps an alias to when it was last connected to, in unix
+	// seconds.
+	LastConnected map[string]int64 `json:"last_connected,omitempty"`
+	FirstRun      bool             `json:"first_run"`
 }
 
+// now is the clock RecordConnection stamps connections with; replaced in tests.
+var now = time.Now
+
 // Load loads the state from the given path.
 // If the file does not exist, it returns a new State with FirstRun: true.
 // Any other error is returned.
@@ -38,6 +49,10 @@ func Load(path string) (*State, error) {
 	if s.Connections == nil {
 		s.Connections = make(map[string]int)
 	}
+	// Likewise for state files written before LastConnected existed.
+	if s.LastConnected == nil {
+		s.LastConnected = make(map[string]int64)
+	}
 
 	return s, nil
 }
@@ -73,27 +88,119 @@ func Save(path string, s *State) error {
 	return nil
 }
 
-// RecordConnection increments the connection count for the given host alias.
+// RecordConnection increments the connection count for the given host alias
+// and stamps it as connected now.
 func RecordConnection(s *State, alias string) {
+	if s == nil {
+		return
+	}
+	if s.Connections == nil {
+		s.Connections = make(map[string]int)
+	}
 	s.Connections[alias]++
+	if s.LastConnected == nil {
+		s.LastConnected = make(map[string]int64)
+	}
+	s.LastConnected[alias] = now().Unix()
+}
+
+// RecordError remembers reason as alias's last failure. A nil s is ignored.
+func RecordError(s *State, alias, reason string) {
+	if s == nil {
+		return
+	}
+	if s.LastError == nil {
+		s.LastError = make(map[string]string)
+	}
+	s.LastError[alias] = reason
+}
+
+// ClearError forgets alias's last failure. A nil s is ignored.
+func ClearError(s *State, alias string) {
+	if s == nil {
+		return
+	}
+	delete(s.LastError, alias)
+}
+
+// TogglePin pins alias, or unpins it if it is already pinned, and reports
+// whether it is pinned afterwards. New pins go last.
+func TogglePin(s *State, alias string) bool {
+	for i, p := range s.Pinned {
+		if p == alias {
+			s.Pinned = append(s.Pinned[:i:i], s.Pinned[i+1:]...)
+			return false
+		}
+	}
+	s.Pinned = append(s.Pinned, alias)
+	return true
+}
+
+// IsPinned reports whether alias is pinned. A nil s has no pins.
+func IsPinned(s *State, alias string) bool {
+	if s == nil {
+		return false
+	}
+	for _, p := range s.Pinned {
+		if p == alias {
+			return true
+		}
+	}
+	return false
+}
+
+// Merge folds src into dst: connection counts for the same alias are summed,
+// aliases only present in src are added, src's pins not already in dst are
+// appended, src's last errors fill in aliases dst has none for, and the later
+// of the two last-connected times is kept. src is not modified.
+func Merge(dst, src *State) {
+	if dst.Connections == nil {
+		dst.Connections = make(map[string]int)
+	}
+	for alias, n := range src.Connections {
+		dst.Connections[alias] += n
+	}
+	for _, alias := range src.Pinned {
+		if !IsPinned(dst, alias) {
+			dst.Pinned = append(dst.Pinned, alias)
+		}
+	}
+	for alias, reason := range src.LastError {
+		if _, ok := dst.LastError[alias]; !ok {
+			RecordError(dst, alias, reason)
+		}
+	}
+	for alias, t := range src.LastConnected {
+		if t > dst.LastConnected[alias] {
+			if dst.LastConnected == nil {
+				dst.LastConnected = make(map[string]int64)
+			}
+			dst.LastConnected[alias] = t
+		}
+	}
+	dst.FirstRun = dst.FirstRun && src.FirstRun
 }
 
 // FrequentHosts returns the top n most frequently connected hosts from the given list,
 // sorted by connection count in descending order.
 // If n <= 0 or n >= len(candidates), all candidates are returned.
-// Hosts with 0 connections are excluded.
+// Hosts with 0 connections are excluded. A nil s counts as no connections.
+// Counts are multiplied by each host's "# @weight" before ranking.
 func FrequentHosts(s *State, hosts []config.Host, n int) []config.Host {
 	// Build candidates: only hosts with at least one connection.
 	candidates := []config.Host{}
+	if s == nil {
+		return candidates
+	}
 	for _, h := range hosts {
 		if s.Connections[h.Alias] > 0 {
 			candidates = append(candidates, h)
 		}
 	}
 
-	// Sort by connection count (descending) using stable sort to preserve order for ties.
+	// Sort by weighted connection count (descending) using stable sort to preserve order for ties.
 	sort.SliceStable(candidates, func(i, j int) bool {
-		return s.Connections[candidates[i].Alias] > s.Connections[candidates[j].Alias]
+		return weightedCount(s, candidates[i]) > weightedCount(s, candidates[j])
 	})
 
 	// Return top n.
@@ -102,3 +209,37 @@ func FrequentHosts(s *State, hosts []config.Host, n int) []config.Host {
 	}
 	return candidates[:n]
 }
+
+// RecentHosts returns the n most recently connected hosts from the given list,
+// most recent first. If n <= 0 or n >= the number of such hosts, all are
+// returned. Hosts never connected to are excluded, and a nil s has none.
+func RecentHosts(s *State, hosts []config.Host, n int) []config.Host {
+	candidates := []config.Host{}
+	if s == nil {
+		return candidates
+	}
+	for _, h := range hosts {
+		if s.LastConnected[h.Alias] > 0 {
+			candidates = append(candidates, h)
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		return s.LastConnected[candidates[i].Alias] > s.LastConnected[candidates[j].Alias]
+	})
+
+	if n <= 0 || n >= len(candidates) {
+		return candidates
+	}
+	return candidates[:n]
+}
+
+// weightedCount returns h's connection count scaled by its weight. Hosts
+// built without a weight (Weight 0) count as weight 1.
+func weightedCount(s *State, h config.Host) int {
+	weight := h.Weight
+	if weight < 1 {
+		weight = 1
+	}
+	return s.Connections[h.Alias] * weight
+}
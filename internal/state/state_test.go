@@ -5,6 +5,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	"github.com/srava/swiftssh/internal/config"
 	"github.com/srava/swiftssh/internal/testutil"
@@ -172,3 +173,160 @@ func TestSave_MissingParentDirectory(t *testing.T) {
 	testutil.AssertTrue(t, loaded.FirstRun, "FirstRun should be preserved")
 	testutil.AssertEqual(t, loaded.Connections["test"], 1, "Connections should be preserved")
 }
+
+// TestMerge_SumsCounts verifies counts for shared aliases are summed and new
+// aliases are added without touching the source.
+func TestMerge_SumsCounts(t *testing.T) {
+	dst := &State{Connections: map[string]int{"prod": 3, "dev": 1}}
+	src := &State{Connections: map[string]int{"prod": 2, "pi": 4}}
+
+	Merge(dst, src)
+
+	testutil.AssertEqual(t, dst.Connections["prod"], 5, "prod count")
+	testutil.AssertEqual(t, dst.Connections["dev"], 1, "dev count")
+	testutil.AssertEqual(t, dst.Connections["pi"], 4, "pi count")
+	testutil.AssertEqual(t, src.Connections["prod"], 2, "src must not change")
+}
+
+// TestMerge_NilConnections verifies merging into a zero State does not panic.
+func TestMerge_NilConnections(t *testing.T) {
+	dst := &State{FirstRun: true}
+	Merge(dst, &State{Connections: map[string]int{"prod": 1}})
+	testutil.AssertEqual(t, dst.Connections["prod"], 1, "prod count")
+	testutil.AssertFalse(t, dst.FirstRun, "imported history means not a first run")
+}
+
+func TestRecordConnection_NilConnections(t *testing.T) {
+	s := &State{}
+	RecordConnection(s, "dev")
+	RecordConnection(s, "dev")
+	testutil.AssertEqual(t, s.Connections["dev"], 2, "nil map should be initialized and counted")
+
+	RecordConnection(nil, "dev") // must not panic
+}
+
+func TestFrequentHosts_NilConnections(t *testing.T) {
+	hosts := []config.Host{{Alias: "a"}, {Alias: "b"}}
+	testutil.AssertEqual(t, len(FrequentHosts(&State{}, hosts, 5)), 0, "nil map means no frequent hosts")
+	testutil.AssertEqual(t, len(FrequentHosts(nil, hosts, 5)), 0, "nil state means no frequent hosts")
+
+	ordered := OrderHosts(hosts, &State{}, false)
+	testutil.AssertEqual(t, len(ordered), 2, "OrderHosts should still return every host")
+}
+
+func TestTogglePin(t *testing.T) {
+	s := &State{}
+	if !TogglePin(s, "prod") || !TogglePin(s, "db") {
+		t.Fatal("expected new pins to report pinned")
+	}
+	if TogglePin(s, "prod") {
+		t.Error("expected second toggle to unpin")
+	}
+	if len(s.Pinned) != 1 || s.Pinned[0] != "db" || IsPinned(s, "prod") {
+		t.Errorf("unexpected pins %v", s.Pinned)
+	}
+	if IsPinned(nil, "db") {
+		t.Error("nil state should have no pins")
+	}
+}
+
+func TestMerge_UnionsPins(t *testing.T) {
+	dst := &State{Pinned: []string{"a", "b"}}
+	Merge(dst, &State{Pinned: []string{"b", "c"}})
+	testutil.AssertSliceEqual(t, dst.Pinned, []string{"a", "b", "c"}, "merged pins")
+}
+
+func TestRecordAndClearError(t *testing.T) {
+	path := tempStatePath(t)
+	s := &State{Connections: map[string]int{}}
+	RecordError(s, "prod", "exit status 255")
+	RecordError(s, "db", "exit status 1")
+	ClearError(s, "db")
+	RecordError(nil, "prod", "ignored")
+	ClearError(nil, "prod")
+
+	testutil.AssertNoError(t, Save(path, s), "Save")
+	loaded, err := Load(path)
+	testutil.AssertNoError(t, err, "Load")
+	testutil.AssertEqual(t, loaded.LastError["prod"], "exit status 255", "prod error")
+	testutil.AssertEqual(t, len(loaded.LastError), 1, "errors after clear")
+}
+
+func TestLoad_StateWithoutLastError(t *testing.T) {
+	path := tempStatePath(t)
+	if err := os.WriteFile(path, []byte(`{"connections":{"prod":2},"first_run":false}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := Load(path)
+	testutil.AssertNoError(t, err, "Load")
+	testutil.AssertEqual(t, len(s.LastError), 0, "no errors in an older state file")
+
+	data, _ := json.Marshal(&State{Connections: map[string]int{}})
+	if string(data) != `{"connections":{},"first_run":false}` {
+		t.Errorf("empty LastError should be omitted, got %s", data)
+	}
+}
+
+// withClock makes RecordConnection stamp connections at unix second sec.
+func withClock(t *testing.T, sec *int64) {
+	t.Helper()
+	orig := now
+	now = func() time.Time { return time.Unix(*sec, 0) }
+	t.Cleanup(func() { now = orig })
+}
+
+func TestRecordConnection_StampsLastConnected(t *testing.T) {
+	clock := int64(1000)
+	withClock(t, &clock)
+	path := tempStatePath(t)
+
+	s := &State{Connections: map[string]int{}}
+	RecordConnection(s, "prod")
+	clock = 2000
+	RecordConnection(s, "db")
+	RecordConnection(s, "prod")
+
+	testutil.AssertNoError(t, Save(path, s), "Save")
+	loaded, err := Load(path)
+	testutil.AssertNoError(t, err, "Load")
+	testutil.AssertEqual(t, loaded.LastConnected["prod"], int64(2000), "prod last connected")
+	testutil.AssertEqual(t, loaded.LastConnected["db"], int64(2000), "db last connected")
+	testutil.AssertEqual(t, loaded.Connections["prod"], 2, "prod count")
+}
+
+func TestLoad_StateWithoutLastConnected(t *testing.T) {
+	path := tempStatePath(t)
+	if err := os.WriteFile(path, []byte(`{"connections":{"prod":2},"first_run":false}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	s, err := Load(path)
+	testutil.AssertNoError(t, err, "Load")
+	testutil.AssertNotNil(t, s.LastConnected, "LastConnected should be initialized")
+	testutil.AssertEqual(t, len(s.LastConnected), 0, "no timestamps in an older state file")
+}
+
+func TestRecentHosts(t *testing.T) {
+	hosts := []config.Host{{Alias: "a"}, {Alias: "b"}, {Alias: "c"}, {Alias: "never"}}
+	s := &State{LastConnected: map[string]int64{"a": 100, "b": 300, "c": 200}}
+
+	var got []string
+	for _, h := range RecentHosts(s, hosts, 0) {
+		got = append(got, h.Alias)
+	}
+	testutil.AssertSliceEqual(t, got, []string{"b", "c", "a"}, "most recent first, never-used excluded")
+
+	top := RecentHosts(s, hosts, 1)
+	if len(top) != 1 || top[0].Alias != "b" {
+		t.Errorf("RecentHosts(n=1) = %v; want [b]", top)
+	}
+	testutil.AssertEqual(t, len(RecentHosts(nil, hosts, 0)), 0, "nil state has no recent hosts")
+}
+
+func TestMerge_KeepsLaterLastConnected(t *testing.T) {
+	dst := &State{LastConnected: map[string]int64{"a": 100, "b": 500}}
+	src := &State{LastConnected: map[string]int64{"a": 300, "b": 200, "c": 50}}
+	Merge(dst, src)
+	testutil.AssertEqual(t, dst.LastConnected["a"], int64(300), "a takes src's later time")
+	testutil.AssertEqual(t, dst.LastConnected["b"], int64(500), "b keeps dst's later time")
+	testutil.AssertEqual(t, dst.LastConnected["c"], int64(50), "c is added")
+}
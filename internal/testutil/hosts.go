@@ -0,0 +1,57 @@
+package testutil
+
+import (
+	"fmt"
+	"reflect"
+	"testing"
+)
+
+// hostFields are the config.Host fields AssertHostsEqual compares, in the
+// order differences are reported.
+var hostFields = []string{
+	"Alias", "Hostname", "User", "Port", "IdentityFile", "Groups", "SourceFile", "LineStart",
+}
+
+// AssertHostsEqual checks that two host slices have the same length and that
+// each pair of hosts agrees on Alias, Hostname, User, Port, IdentityFile,
+// Groups, SourceFile, and LineStart, reporting every differing field.
+//
+// H is config.Host in practice. It is a type parameter only because config's
+// own tests import testutil, so testutil cannot import config.
+func AssertHostsEqual[H any](t *testing.T, got, want []H, desc string) {
+	t.Helper()
+	for _, msg := range hostDiffs(got, want) {
+		t.Errorf("%s: %s", desc, msg)
+	}
+}
+
+// hostDiffs returns one message per difference between got and want.
+func hostDiffs[H any](got, want []H) []string {
+	if len(got) != len(want) {
+		return []string{fmt.Sprintf("length mismatch: got %d hosts, want %d", len(got), len(want))}
+	}
+	var diffs []string
+	for i := range got {
+		g, w := reflect.ValueOf(got[i]), reflect.ValueOf(want[i])
+		for _, name := range hostFields {
+			gf, wf := g.FieldByName(name), w.FieldByName(name)
+			if !gf.IsValid() {
+				return []string{fmt.Sprintf("%s has no field %s", g.Type(), name)}
+			}
+			if fieldsEqual(gf, wf) {
+				continue
+			}
+			diffs = append(diffs, fmt.Sprintf("host[%d] (%v).%s: got %#v, want %#v",
+				i, w.FieldByName("Alias").Interface(), name, gf.Interface(), wf.Interface()))
+		}
+	}
+	return diffs
+}
+
+// fieldsEqual compares two field values, treating nil and empty slices alike.
+func fieldsEqual(a, b reflect.Value) bool {
+	if a.Kind() == reflect.Slice && a.Len() == 0 && b.Len() == 0 {
+		return true
+	}
+	return reflect.DeepEqual(a.Interface(), b.Interface())
+}
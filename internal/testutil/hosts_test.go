@@ -0,0 +1,53 @@
+package testutil
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/srava/swiftssh/internal/config"
+)
+
+func TestHostDiffs(t *testing.T) {
+	base := []config.Host{
+		{Alias: "web", Hostname: "web.example.com", Port: "22", Groups: []string{"Work"}},
+		{Alias: "db", Hostname: "db.example.com", Port: "5432", SourceFile: "/tmp/config", LineStart: 4},
+	}
+
+	t.Run("equal", func(t *testing.T) {
+		other := []config.Host{base[0], base[1]}
+		other[1].Groups = []string{} // nil and empty groups compare equal
+		other[1].ExtraLines = []string{"ForwardAgent yes"}
+		if diffs := hostDiffs(base, other); len(diffs) != 0 {
+			t.Errorf("expected no differences, got %v", diffs)
+		}
+		AssertHostsEqual(t, base, other, "equal hosts")
+	})
+
+	t.Run("length mismatch", func(t *testing.T) {
+		diffs := hostDiffs(base, base[:1])
+		if len(diffs) != 1 || !strings.Contains(diffs[0], "got 2 hosts, want 1") {
+			t.Errorf("expected a single length message, got %v", diffs)
+		}
+	})
+
+	t.Run("field mismatch", func(t *testing.T) {
+		other := []config.Host{base[0], base[1]}
+		other[0].Groups = []string{"Home"}
+		other[1].Port = "22"
+		other[1].LineStart = 9
+		diffs := hostDiffs(base, other)
+		want := []string{
+			`host[0] (web).Groups: got []string{"Work"}, want []string{"Home"}`,
+			`host[1] (db).Port: got "5432", want "22"`,
+			`host[1] (db).LineStart: got 4, want 9`,
+		}
+		AssertSliceEqual(t, diffs, want, "field diffs")
+	})
+
+	t.Run("type without host fields", func(t *testing.T) {
+		diffs := hostDiffs([]struct{ Alias string }{{"a"}}, []struct{ Alias string }{{"a"}})
+		if len(diffs) != 1 || !strings.Contains(diffs[0], "no field Hostname") {
+			t.Errorf("expected a missing-field message, got %v", diffs)
+		}
+	})
+}
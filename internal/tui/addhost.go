@@ -0,0 +1,80 @@
+package tui
+
+import (
+	"errors"
+
+	tea "github.com/charmbracelet/bubbletea"
+	"github.com/srava/swiftssh/internal/config"
+)
+
+// addHostModel is a standalone form for reviewing a host before it is
+// appended to the config, used by "sssh --interactive user@host".
+type addHostModel struct {
+	form      *editForm
+	result    config.Host
+	confirmed bool
+	cancelled bool
+}
+
+// ErrCancelled is returned by ConfirmHost when the user presses Ctrl+C.
+var ErrCancelled = errors.New("cancelled")
+
+func newAddHostModel(h config.Host) addHostModel {
+	return addHostModel{form: newEditForm(h)}
+}
+
+// Init returns nil (no initial command).
+func (m addHostModel) Init() tea.Cmd {
+	return nil
+}
+
+// Update handles key presses: Enter confirms, Esc declines saving, Ctrl+C
+// cancels the connection, and all other keys edit the form as in the TUI's
+// edit mode.
+func (m addHostModel) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
+	key, ok := msg.(tea.KeyMsg)
+	if !ok {
+		return m, nil
+	}
+	switch key.String() {
+	case "esc":
+		return m, tea.Quit
+	case "ctrl+c":
+		m.cancelled = true
+		return m, tea.Quit
+	case "enter":
+		h, problem := m.form.host()
+		if problem != "" {
+			m.form.statusMsg = problem
+			return m, nil
+		}
+		m.result = h
+		m.confirmed = true
+		return m, tea.Quit
+	}
+	m.form.handleFieldKey(key)
+	return m, nil
+}
+
+// View renders the form.
+func (m addHostModel) View() string {
+	if m.confirmed {
+		return ""
+	}
+	return renderForm("Save New Host", selectedStyle, m.form, "↑/↓/Tab: next field  |  Enter: save & connect  |  Esc: connect without saving  |  Ctrl+C: cancel") + "\n"
+}
+
+// ConfirmHost shows h in an editable form and returns the (possibly edited)
+// host and whether the user confirmed it. It returns ErrCancelled if the user
+// pressed Ctrl+C to abandon the connection.
+func ConfirmHost(h config.Host) (config.Host, bool, error) {
+	final, err := tea.NewProgram(newAddHostModel(h)).Run()
+	if err != nil {
+		return h, false, err
+	}
+	m := final.(addHostModel)
+	if m.cancelled {
+		return h, false, ErrCancelled
+	}
+	return m.result, m.confirmed, nil
+}
@@ -0,0 +1,64 @@
+package tui
+
+import "strings"
+
+// searchHistoryLimit bounds how many past queries are remembered.
+const searchHistoryLimit = 50
+
+// searchHistory keeps recent search queries, most recent first, and a recall
+// position for stepping through them in the search prompt.
+type searchHistory struct {
+	entries []string
+	pos     int // index of the recalled entry; -1 while typing a new query
+}
+
+func newSearchHistory() *searchHistory {
+	return &searchHistory{pos: -1}
+}
+
+// push records query as the most recent entry, moving it to the front if it
+// was already present. Blank queries are ignored. Recall restarts from the top.
+func (h *searchHistory) push(query string) {
+	h.pos = -1
+	query = strings.TrimSpace(query)
+	if query == "" {
+		return
+	}
+	entries := []string{query}
+	for _, e := range h.entries {
+		if e != query {
+			entries = append(entries, e)
+		}
+	}
+	if len(entries) > searchHistoryLimit {
+		entries = entries[:searchHistoryLimit]
+	}
+	h.entries = entries
+}
+
+// older steps back to the previous query. It returns false at the oldest entry.
+func (h *searchHistory) older() (string, bool) {
+	if h.pos+1 >= len(h.entries) {
+		return "", false
+	}
+	h.pos++
+	return h.entries[h.pos], true
+}
+
+// newer steps forward toward the most recent query. Stepping past it returns
+// "" (a fresh query); it returns false if nothing is being recalled.
+func (h *searchHistory) newer() (string, bool) {
+	if h.pos < 0 {
+		return "", false
+	}
+	h.pos--
+	if h.pos < 0 {
+		return "", true
+	}
+	return h.entries[h.pos], true
+}
+
+// reset ends any recall in progress.
+func (h *searchHistory) reset() {
+	h.pos = -1
+}
@@ -1,9 +1,15 @@
 package tui
 
 import (
+	"errors"
+	"fmt"
+	"path/filepath"
 	"strings"
+	"time"
+	"unicode"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/srava/swiftssh/internal/audit"
 	"github.com/srava/swiftssh/internal/config"
 	"github.com/srava/swiftssh/internal/platform"
 	"github.com/srava/swiftssh/internal/ssh"
@@ -14,21 +20,96 @@ import (
 func handleKey(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
 	switch m.mode {
 	case modeNormal:
+		if m.picker != nil {
+			return handlePicker(m, msg)
+		}
+		if m.showSessions {
+			return handleSessionsPanel(m, msg)
+		}
+		if m.rawBlock != nil {
+			return handleRawBlock(m, msg), nil
+		}
+		if m.quickAdd != nil {
+			return handleQuickAdd(m, msg), nil
+		}
 		return handleNormalMode(m, msg)
 	case modeSearch:
 		return handleSearchMode(m, msg)
+	case modePrompt:
+		return handlePromptMode(m, msg)
 	case modeEdit:
 		return handleEditMode(m, msg)
+	case modeConfirmDelete:
+		return handleConfirmDelete(m, msg), nil
+	case modeHelp:
+		// Any key closes the overlay.
+		m.mode = modeNormal
+		return m, nil
+	case modeGroupFilter:
+		return handleGroupFilter(m, msg), nil
 	}
 	return m, nil
 }
 
+// helpKey is one row of the help overlay.
+type helpKey struct {
+	key, desc string
+}
+
+// helpSections lists the keys shown by the help overlay ("?"), by mode. Keys
+// use bubbletea's names, as matched in the handlers below.
+var helpSections = []struct {
+	title string
+	keys  []helpKey
+}{
+	{"List", []helpKey{
+		{"↑/↓", "move the cursor"},
+		{"enter", "connect to the selected host"},
+		{"ctrl+o", "connect in a new window or tab"},
+		{"ctrl+e", "edit the selected host"},
+		{"ctrl+f", "search prompt with query history"},
+		{"ctrl+k", "pick a key to connect with"},
+		{"ctrl+y", "copy the ssh command"},
+		{"ctrl+p", "pin or unpin the host"},
+		{"ctrl+s", "cycle the sort order"},
+		{"ctrl+d", "delete the host's block"},
+		{"ctrl+a", "list background sessions"},
+		{"ctrl+g", "toggle the group legend"},
+		{"ctrl+t", "abbreviate the shared domain"},
+		{"ctrl+\\", "show resolved addresses"},
+		{"alt+1-9", "connect to a pinned favorite"},
+		{"F", "open an sftp session"},
+		{"G", "filter the list by group"},
+		{"A", "quick add a host"},
+		{"R", "show the raw config block"},
+		{"M", "start a master connection"},
+		{"V", "cycle ssh verbosity"},
+		{"H", "reveal hosts hidden by --hide"},
+		{"+", "show more hosts with --limit"},
+		{"?", "show this help"},
+		{"type", "search"},
+		{"esc", "quit"},
+	}},
+	{"Search", []helpKey{
+		{"backspace", "delete a character"},
+		{"ctrl+w/esc", "clear the query"},
+	}},
+	{"Edit form", []helpKey{
+		{"tab/↑/↓", "next or previous field"},
+		{"ctrl+u", "clear the field"},
+		{"ctrl+r", "revert the field"},
+		{"enter", "save"},
+		{"esc", "cancel"},
+	}},
+}
+
 // moveCursorDown moves the cursor down by one, wrapping around to the top.
 func moveCursorDown(m Model) Model {
-	if len(m.filtered) == 0 {
+	n := m.visibleLen()
+	if n == 0 {
 		return m
 	}
-	m.cursor = (m.cursor + 1) % len(m.filtered)
+	m.cursor = (m.cursor + 1) % n
 	if m.cursor == 0 {
 		m.viewport = 0
 	} else if m.cursor >= m.viewport+m.viewHeight {
@@ -39,36 +120,412 @@ func moveCursorDown(m Model) Model {
 
 // moveCursorUp moves the cursor up by one, wrapping around to the bottom.
 func moveCursorUp(m Model) Model {
-	if len(m.filtered) == 0 {
+	n := m.visibleLen()
+	if n == 0 {
 		return m
 	}
-	m.cursor = (m.cursor - 1 + len(m.filtered)) % len(m.filtered)
-	if m.cursor == len(m.filtered)-1 {
-		m.viewport = max(0, len(m.filtered)-m.viewHeight)
+	m.cursor = (m.cursor - 1 + n) % n
+	if m.cursor == n-1 {
+		m.viewport = max(0, n-m.viewHeight)
 	} else if m.cursor < m.viewport {
 		m.viewport = m.cursor
 	}
 	return m
 }
 
+// recordConnection updates connection history and the connection log for
+// host (unless disabled) and saves it to the config if it is not already known.
+// Failures are ignored so they never prevent connecting.
+func recordConnection(m Model, host config.Host) {
+	if !m.noHistory {
+		state.RecordConnection(m.state, host.Alias)
+		_ = state.Save(m.statePath, m.state)
+		if m.logPath != "" {
+			_ = audit.LogConnection(m.logPath, audit.ConnectionLogEntry{
+				Time:         time.Now(),
+				Alias:        host.Alias,
+				Hostname:     host.Hostname,
+				User:         host.User,
+				IdentityFile: host.IdentityFile,
+			})
+		}
+	}
+
+	if !config.IsKnownHost(m.allHosts, host.Hostname) {
+		_ = config.AppendHost(platform.SSHConfigPath(), platform.SSHConfigBackupPath(), host)
+	}
+}
+
+// maxErrorLen caps the failure reason kept per host in the state file.
+const maxErrorLen = 80
+
+// recordOutcome stores why alias's ssh session failed, or clears the stored
+// failure after a clean exit, and saves the state unless history is off.
+func recordOutcome(m Model, alias string, err error) {
+	if m.noHistory || m.state == nil {
+		return
+	}
+	_, hadError := m.state.LastError[alias]
+	if err == nil && !hadError {
+		return
+	}
+	if err != nil {
+		reason, _, _ := strings.Cut(err.Error(), "\n")
+		state.RecordError(m.state, alias, truncateStr(reason, maxErrorLen))
+	} else {
+		state.ClearError(m.state, alias)
+	}
+	_ = state.Save(m.statePath, m.state)
+}
+
+// connectArgs returns the ssh arguments for host according to the connect-by mode.
+func connectArgs(m Model, host config.Host) []string {
+	if m.connectBy == ConnectByHostname {
+		// Alias resolution is bypassed, so the config's IdentityFile must be
+		// passed explicitly.
+		args := ssh.BuildArgsDirect(host, host.IdentityFile)
+		return ssh.WithVerbosity(m.verbosity, ssh.WithConfigFile(m.configPath, args))
+	}
+	// ssh reads the host's own IdentityFile from the config. It knows nothing
+	// of group defaults, though, so only an inherited key is passed explicitly.
+	if _, ok := host.Inherited["IdentityFile"]; !ok {
+		host.IdentityFile = ""
+	}
+	return ssh.WithVerbosity(m.verbosity, ssh.BuildArgsWithConfig(host, "", m.configPath))
+}
+
+// cycleVerbosity steps the ssh debug level for the next connection through
+// off, -v, -vv, and -vvv.
+func cycleVerbosity(m Model) Model {
+	m.verbosity = (m.verbosity + 1) % (ssh.MaxVerbosity + 1)
+	if m.verbosity == 0 {
+		m.statusMsg = "Verbose ssh off."
+	} else {
+		m.statusMsg = ""
+	}
+	return m
+}
+
 // connectToSelected records the connection and executes SSH for the selected host.
 func connectToSelected(m Model) (Model, tea.Cmd) {
+	if len(m.filtered) == 0 {
+		return m, nil
+	}
+	return connectHost(m, m.filtered[m.cursor])
+}
+
+// connectHost records the connection and executes SSH for host.
+func connectHost(m Model, host config.Host) (Model, tea.Cmd) {
+	recordConnection(m, host)
+	m.lastConnectedAlias = host.Alias
+	m.autoConnectGen++
+
+	cmd := ssh.Command(connectArgs(m, host))
+	m.verbosity = 0
+	return m, tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return sshExitMsg{err: err, host: host}
+	})
+}
+
+// sftpSelected records the connection and opens an sftp session to the
+// selected host instead of a shell.
+func sftpSelected(m Model) (Model, tea.Cmd) {
 	if len(m.filtered) == 0 {
 		return m, nil
 	}
 	host := m.filtered[m.cursor]
+	recordConnection(m, host)
+	m.lastConnectedAlias = host.Alias
 
-	state.RecordConnection(m.state, host.Alias)
-	_ = state.Save(m.statePath, m.state)
+	cmd := ssh.SFTPCommand(sftpArgs(m, host))
+	return m, tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return sftpExitMsg{alias: host.Alias, err: err}
+	})
+}
 
-	if !config.IsKnownHost(m.allHosts, host.Hostname) {
-		_ = config.AppendHost(platform.SSHConfigPath(), platform.SSHConfigBackupPath(), host)
+// sftpArgs returns the sftp arguments for host. Like connectArgs, only an
+// inherited IdentityFile is passed; sftp reads the rest from the config.
+func sftpArgs(m Model, host config.Host) []string {
+	if _, ok := host.Inherited["IdentityFile"]; !ok {
+		host.IdentityFile = ""
 	}
+	return ssh.WithConfigFile(m.configPath, ssh.BuildSFTPArgs(host, ""))
+}
 
-	cmd := ssh.ConnectCmd(host, "")
+// connectDetached launches the selected host in a new multiplexer window or
+// terminal tab and keeps the TUI open. Falls back to connectToSelected when no
+// launcher is available for the current environment.
+func connectDetached(m Model) (Model, tea.Cmd) {
+	if len(m.filtered) == 0 {
+		return m, nil
+	}
+	launcher, ok := ssh.DetectLauncher(m.getenv, m.goos)
+	if !ok {
+		m.statusMsg = "No multiplexer or terminal launcher detected; connected in place."
+		return connectToSelected(m)
+	}
+
+	host := m.filtered[m.cursor]
+	id, err := ssh.Spawn(launcher, m.spawn, host.Alias, connectArgs(m, host))
+	if err != nil {
+		m.statusMsg = "Launch via " + launcher.Name + " failed: " + err.Error()
+		return m, nil
+	}
+	m.verbosity = 0
+	if id != "" {
+		m.sessions.Add(ssh.Session{Alias: host.Alias, ID: id, Started: time.Now(), Launcher: launcher.Name})
+	}
+
+	recordConnection(m, host)
+	m.statusMsg = "Opened " + host.Alias + " in " + launcher.Name + "."
+	return m, runAfterLocal(m, host)
+}
+
+// startMaster starts a background master connection (ssh -M -N -f) for the
+// selected host unless its control socket is already listening, so later
+// connects reuse the authenticated connection. The host needs a ControlPath.
+func startMaster(m Model) (Model, tea.Cmd) {
+	if len(m.filtered) == 0 {
+		return m, nil
+	}
+	host := m.filtered[m.cursor]
+	sock, err := host.ControlSocket()
+	switch {
+	case err != nil:
+		m.statusMsg = "ControlPath for " + host.Alias + ": " + err.Error()
+		return m, nil
+	case sock == "":
+		m.statusMsg = host.Alias + " has no ControlPath; add one to share connections."
+		return m, nil
+	case !ssh.NeedsMaster(sock, m.hasMaster):
+		m.statusMsg = "Master connection to " + host.Alias + " already running."
+		return m, nil
+	}
+
+	alias := host.Alias
+	cmd := ssh.Command(ssh.MasterArgs(connectArgs(m, host)))
+	m.verbosity = 0
 	return m, tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return masterStartedMsg{alias: alias, err: err}
+	})
+}
+
+// runAfterLocal returns a command running host's "# @after-local" command in
+// the background, or nil if it has none.
+func runAfterLocal(m Model, host config.Host) tea.Cmd {
+	if host.AfterLocal == "" {
 		return nil
+	}
+	run, alias, command := m.runLocal, host.Alias, host.AfterLocal
+	return func() tea.Msg {
+		return afterLocalMsg{alias: alias, err: run(command)}
+	}
+}
+
+// handleSessionsPanel processes keys while the active-sessions panel is open.
+func handleSessionsPanel(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
+	m.sessions.Prune()
+	sessions := m.sessions.List()
+	if m.sessionCursor >= len(sessions) {
+		m.sessionCursor = max(0, len(sessions)-1)
+	}
+
+	switch msg.String() {
+	case "esc", "ctrl+a":
+		m.showSessions = false
+		return m, nil
+
+	case "down":
+		if len(sessions) > 0 {
+			m.sessionCursor = (m.sessionCursor + 1) % len(sessions)
+		}
+
+	case "up":
+		if len(sessions) > 0 {
+			m.sessionCursor = (m.sessionCursor - 1 + len(sessions)) % len(sessions)
+		}
+
+	case "enter":
+		if len(sessions) == 0 {
+			return m, nil
+		}
+		s := sessions[m.sessionCursor]
+		launcher, ok := ssh.DetectLauncher(m.getenv, m.goos)
+		if !ok || launcher.Focus == nil {
+			m.statusMsg = "Cannot focus " + s.Alias + ": " + s.Launcher + " is no longer reachable."
+			return m, nil
+		}
+		argv := launcher.Focus(s.ID)
+		if _, err := m.spawn(argv[0], argv[1:]...); err != nil {
+			m.statusMsg = "Focus failed: " + err.Error()
+			return m, nil
+		}
+		m.showSessions = false
+		m.statusMsg = "Switched to " + s.Alias + "."
+
+	case "ctrl+k":
+		if len(sessions) == 0 {
+			return m, nil
+		}
+		s := sessions[m.sessionCursor]
+		launcher, ok := ssh.DetectLauncher(m.getenv, m.goos)
+		if !ok || launcher.Kill == nil {
+			m.statusMsg = "Cannot kill " + s.Alias + ": " + s.Launcher + " is no longer reachable."
+			return m, nil
+		}
+		argv := launcher.Kill(s.ID)
+		if _, err := m.spawn(argv[0], argv[1:]...); err != nil {
+			m.statusMsg = "Kill failed: " + err.Error()
+			return m, nil
+		}
+		m.sessions.Remove(s.ID)
+		m.statusMsg = "Killed " + s.Alias + "."
+	}
+	return m, nil
+}
+
+// openPicker opens the identity picker for the selected host.
+func openPicker(m Model) Model {
+	if len(m.filtered) == 0 {
+		return m
+	}
+	return openPickerFor(m, m.filtered[m.cursor])
+}
+
+// openPickerFor opens the identity picker for host.
+func openPickerFor(m Model, host config.Host) Model {
+	keys, err := ssh.ScanPublicKeys(m.keyDir)
+	if err != nil || len(keys) == 0 {
+		m.statusMsg = "No SSH keys found in " + m.keyDir + "."
+		return m
+	}
+	m.picker = &identityPicker{host: host, keys: keys}
+	return m
+}
+
+// handlePicker processes keys while the identity picker is open. Enter
+// connects with the chosen key; Ctrl+S also writes it to the host's
+// IdentityFile so plain ssh uses it too.
+func handlePicker(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
+	p := m.picker
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		m.picker = nil
+
+	case "down":
+		p.cursor = (p.cursor + 1) % len(p.keys)
+
+	case "up":
+		p.cursor = (p.cursor - 1 + len(p.keys)) % len(p.keys)
+
+	case "enter":
+		m.picker = nil
+		return connectWithIdentity(m, p.host, p.keys[p.cursor])
+
+	case "ctrl+s":
+		key := p.keys[p.cursor]
+		host, err := saveIdentity(&m, p.host, key)
+		if err != nil {
+			m.statusMsg = "Save failed: " + err.Error()
+			return m, nil
+		}
+		m.picker = nil
+		var cmd tea.Cmd
+		m, cmd = connectWithIdentity(m, host, key)
+		m.statusMsg = "Saved IdentityFile " + key + " for " + host.Alias + "."
+		return m, cmd
+	}
+	return m, nil
+}
+
+// saveIdentity writes key as host's IdentityFile in the config and updates
+// m.allHosts to match. It returns the updated host.
+func saveIdentity(m *Model, host config.Host, key string) (config.Host, error) {
+	if host.ReadOnly {
+		return host, fmt.Errorf("%s is defined in the read-only system config %s", host.Alias, host.SourceFile)
+	}
+	idx := -1
+	for i, h := range m.allHosts {
+		if h.SourceFile == host.SourceFile && h.LineStart == host.LineStart {
+			idx = i
+			break
+		}
+	}
+
+	updated := host
+	updated.IdentityFile = key
+	res, err := config.ReplaceHostBlock(updated)
+	if err != nil {
+		return host, err
+	}
+	updated.LineStart = res.NewLineStart
+
+	applySavedHost(m, editSavedMsg{
+		updated:           updated,
+		index:             idx,
+		lineDelta:         res.LineDelta,
+		originalLineStart: host.LineStart,
+		sourceFile:        host.SourceFile,
 	})
+	applySearch(m)
+	selectAlias(m, updated.Alias)
+	return updated, nil
+}
+
+// connectWithIdentity connects to host, offering key before any identity
+// from the config.
+func connectWithIdentity(m Model, host config.Host, key string) (Model, tea.Cmd) {
+	recordConnection(m, host)
+	m.lastConnectedAlias = host.Alias
+
+	cmd := ssh.Command(append([]string{"-i", key}, connectArgs(m, host)...))
+	m.verbosity = 0
+	return m, tea.ExecProcess(cmd, func(err error) tea.Msg {
+		return sshExitMsg{err: err, host: host}
+	})
+}
+
+// copyCommand copies the ssh command for the selected host to the clipboard.
+// Without a clipboard tool the command is shown in the status bar instead.
+func copyCommand(m Model) Model {
+	if len(m.filtered) == 0 {
+		return m
+	}
+	text := sshCommandLine(m, m.filtered[m.cursor])
+	switch err := m.copyText(text); {
+	case err == nil:
+		m.statusMsg = "Copied: " + text
+	case errors.Is(err, platform.ErrNoClipboard):
+		m.statusMsg = "No clipboard tool found (install wl-copy, xclip, or xsel): " + text
+	default:
+		m.statusMsg = "Copy failed: " + err.Error()
+	}
+	return m
+}
+
+// toggleDomainStrip switches between full hostnames and hostnames with the
+// common domain suffix abbreviated to "…". Only the display is affected.
+func toggleDomainStrip(m Model) Model {
+	if m.domainSuffix == "" {
+		m.statusMsg = "No common domain suffix to strip."
+		return m
+	}
+	m.stripDomain = !m.stripDomain
+	if m.stripDomain {
+		m.statusMsg = "Hiding " + m.domainSuffix + " in hostnames (Ctrl+T to show)."
+	} else {
+		m.statusMsg = ""
+	}
+	return m
+}
+
+// enterOrEdit dispatches Enter and Ctrl+E. By default Enter connects and Ctrl+E
+// edits; with the "edit" enter action the two are swapped.
+func enterOrEdit(m Model, key string) (Model, tea.Cmd) {
+	if (key == "enter") != m.enterEdits {
+		return connectToSelected(m)
+	}
+	return openEditForm(m), nil
 }
 
 // openEditForm initialises an editForm for the currently selected host.
@@ -78,21 +535,24 @@ func openEditForm(m Model) Model {
 		return m
 	}
 	host := m.filtered[m.cursor]
+	if host.ReadOnly {
+		m.statusMsg = "Cannot edit: " + host.Alias + " is defined in the read-only system config " + host.SourceFile + "."
+		return m
+	}
 	if host.LineStart == 0 {
 		m.statusMsg = "Cannot edit: host has no tracked line position."
 		return m
 	}
 
-	form := &editForm{
-		original:    host,
-		activeField: fieldAlias,
+	form := newEditForm(host)
+
+	seen := make(map[string]bool)
+	for _, h := range m.allHosts {
+		if key := config.HostnameKey(h.Hostname); key != "" && !seen[key] {
+			seen[key] = true
+			form.hostnames = append(form.hostnames, h.Hostname)
+		}
 	}
-	form.fields[fieldAlias] = host.Alias
-	form.fields[fieldHostname] = host.Hostname
-	form.fields[fieldUser] = host.User
-	form.fields[fieldPort] = host.Port
-	form.fields[fieldIdentityFile] = host.IdentityFile
-	form.fields[fieldGroups] = strings.Join(host.Groups, ", ")
 
 	m.edit = form
 	m.mode = modeEdit
@@ -103,59 +563,54 @@ func openEditForm(m Model) Model {
 func saveEditForm(m Model) (Model, tea.Cmd) {
 	form := m.edit
 
-	alias := strings.TrimSpace(form.fields[fieldAlias])
-	hostname := strings.TrimSpace(form.fields[fieldHostname])
-
-	if alias == "" {
-		form.statusMsg = "Alias cannot be empty."
-		m.edit = form
-		return m, nil
-	}
-	if hostname == "" {
-		form.statusMsg = "Hostname cannot be empty."
+	updated, problem := form.host()
+	if problem != "" {
+		form.statusMsg = problem
 		m.edit = form
 		return m, nil
 	}
 
-	// Parse groups from comma-separated string
-	var groups []string
-	for _, g := range strings.Split(form.fields[fieldGroups], ",") {
-		g = strings.TrimSpace(g)
-		if g != "" {
-			groups = append(groups, g)
+	if form.adding {
+		if config.UniqueAlias(m.allHosts, updated.Alias) != updated.Alias {
+			form.statusMsg = "Alias " + updated.Alias + " is already in the config."
+			m.edit = form
+			return m, nil
 		}
+		return appendNewHost(m, updated), nil
 	}
 
-	port := strings.TrimSpace(form.fields[fieldPort])
-	if port == "" {
-		port = "22"
-	}
-
-	updated := form.original
-	updated.Alias = alias
-	updated.Hostname = hostname
-	updated.User = strings.TrimSpace(form.fields[fieldUser])
-	updated.Port = port
-	updated.IdentityFile = strings.TrimSpace(form.fields[fieldIdentityFile])
-	updated.Groups = groups
-
-	// Find index in allHosts by SourceFile + LineStart
+	// Find index in allHosts by SourceFile + LineStart + Alias; the hosts of
+	// a multi-pattern Host line share the first two.
 	idx := -1
 	for i, h := range m.allHosts {
-		if h.SourceFile == form.original.SourceFile && h.LineStart == form.original.LineStart {
+		if h.SourceFile == form.original.SourceFile && h.LineStart == form.original.LineStart && h.Alias == form.original.Alias {
 			idx = i
 			break
 		}
 	}
 
+	// With --confirm-edits, the first Enter only shows the diff; confirming
+	// it calls back into saveEditForm with form.diff set.
+	if m.confirmEdits && form.diff == "" {
+		oldLines, newLines, err := config.PreviewReplace(updated)
+		if err != nil {
+			form.statusMsg = "Save failed: " + err.Error()
+			m.edit = form
+			return m, nil
+		}
+		form.diff = config.BlockDiff(oldLines, newLines)
+		m.edit = form
+		return m, nil
+	}
+
 	originalLineStart := form.original.LineStart
-	newLineStart, lineDelta, err := config.ReplaceHostBlock(updated)
+	res, err := config.ReplaceHostBlock(updated)
 	if err != nil {
 		form.statusMsg = "Save failed: " + err.Error()
 		m.edit = form
 		return m, nil
 	}
-	updated.LineStart = newLineStart
+	updated.LineStart = res.NewLineStart
 
 	savedIdx := idx
 	savedHost := updated
@@ -163,15 +618,101 @@ func saveEditForm(m Model) (Model, tea.Cmd) {
 		return editSavedMsg{
 			updated:           savedHost,
 			index:             savedIdx,
-			lineDelta:         lineDelta,
+			lineDelta:         res.LineDelta,
 			originalLineStart: originalLineStart,
 			sourceFile:        savedHost.SourceFile,
 		}
 	}
 }
 
+// handleQuickAdd processes keys in the quick-add prompt. Enter parses the
+// pasted destination and opens it in the edit form for review.
+func handleQuickAdd(m Model, msg tea.KeyMsg) Model {
+	p := *m.quickAdd
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		m.quickAdd = nil
+		return m
+	case "enter":
+		host, err := config.ParseConnectionString(p.input)
+		if err != nil {
+			p.statusMsg = err.Error()
+			break
+		}
+		if config.IsKnownHost(m.allHosts, host.Hostname) {
+			p.statusMsg = host.Hostname + " is already in the config."
+			break
+		}
+		// A generated alias can still belong to a host with another hostname.
+		host.Alias = config.UniqueAlias(m.allHosts, host.Alias)
+		m.quickAdd = nil
+		form := newEditForm(host)
+		form.adding = true
+		m.edit = form
+		m.mode = modeEdit
+		return m
+	case "backspace":
+		runes := []rune(p.input)
+		if len(runes) > 0 {
+			p.input = string(runes[:len(runes)-1])
+		}
+	case "ctrl+u":
+		p.input = ""
+	default:
+		if msg.Type == tea.KeyRunes {
+			p.input += string(msg.Runes)
+		}
+	}
+	m.quickAdd = &p
+	return m
+}
+
+// appendNewHost appends a quick-added host to the config and lists it,
+// selected. The config is re-read so the host carries its line position.
+func appendNewHost(m Model, h config.Host) Model {
+	path := m.configPath
+	if path == "" {
+		path = platform.SSHConfigPath()
+	}
+	if err := config.AppendHost(path, config.BackupPath(path), h); err != nil {
+		m.edit.statusMsg = "Save failed: " + err.Error()
+		return m
+	}
+	h.SourceFile = path
+	if hosts, err := config.Parse(path); err == nil {
+		for _, parsed := range hosts {
+			if parsed.Alias == h.Alias && parsed.SourceFile == path {
+				h = parsed
+			}
+		}
+	}
+	m.allHosts = append(m.allHosts, h)
+	m.edit = nil
+	m.mode = modeNormal
+	m.statusMsg = "Added " + h.Alias + "."
+	applySearch(&m)
+	selectAlias(&m, h.Alias)
+	return m
+}
+
 // handleNormalMode processes keys in normal mode.
 func handleNormalMode(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
+	if m.retryHost != nil {
+		host := *m.retryHost
+		m.retryHost = nil
+		m.statusMsg = ""
+		switch msg.String() {
+		case "r":
+			return connectHost(m, host)
+		case "i":
+			return openPickerFor(m, host), nil
+		}
+	}
+
+	if host, ok := favoriteForKey(m, msg.String()); ok {
+		return connectHost(m, host)
+	}
+
 	switch msg.String() {
 	case "esc", "ctrl+c":
 		return m, tea.Quit
@@ -182,14 +723,85 @@ func handleNormalMode(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
 	case "up":
 		return moveCursorUp(m), nil
 
-	case "enter":
-		return connectToSelected(m)
+	case "enter", "ctrl+e":
+		return enterOrEdit(m, msg.String())
+
+	case "ctrl+o":
+		return connectDetached(m)
+
+	case "ctrl+g":
+		m.showLegend = !m.showLegend
+		return m, nil
+
+	case "ctrl+t":
+		return toggleDomainStrip(m), nil
+
+	case "ctrl+y":
+		return copyCommand(m), nil
 
-	case "ctrl+e":
-		return openEditForm(m), nil
+	case "ctrl+\\":
+		m.showResolved = !m.showResolved
+		return m, nil
+
+	case "ctrl+f":
+		m.mode = modePrompt
+		m.history.reset()
+		return m, nil
+
+	case "ctrl+k":
+		return openPicker(m), nil
+
+	case "ctrl+p":
+		return togglePin(m), nil
+
+	case "ctrl+d":
+		return confirmDelete(m), nil
+
+	case "ctrl+s":
+		return cycleSort(m), nil
+
+	case "ctrl+a":
+		m.sessions.Prune()
+		m.showSessions = true
+		m.sessionCursor = 0
+		return m, nil
+
+	case "+":
+		if m.hiddenCount() > 0 {
+			m.limit += m.limitStep
+			return m, nil
+		}
+
+	case "H":
+		if m.hasHiddenHosts() {
+			return toggleHidden(m), nil
+		}
+
+	case "V":
+		return cycleVerbosity(m), nil
+
+	case "M":
+		return startMaster(m)
+
+	case "R":
+		return openRawBlock(m), nil
+
+	case "A":
+		m.quickAdd = &quickAddPrompt{}
+		return m, nil
+
+	case "F":
+		return sftpSelected(m)
+
+	case "G":
+		return openGroupFilter(m), nil
+
+	case "?":
+		m.mode = modeHelp
+		return m, nil
 	}
 
-	if msg.Type == tea.KeyRunes {
+	if msg.Type == tea.KeyRunes && startsSearch(msg.Runes) {
 		m.mode = modeSearch
 		m.searchQuery = string(msg.Runes)
 		applySearch(&m)
@@ -197,6 +809,240 @@ func handleNormalMode(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
 	return m, nil
 }
 
+// sortCycle is the order Ctrl+S steps through the sort modes.
+var sortCycle = []string{state.SortFrequency, state.SortAlpha, state.SortGroup, state.SortRecent}
+
+// cycleSort switches to the next sort mode, re-sorts the list, and moves the
+// cursor back to the top.
+func cycleSort(m Model) Model {
+	next := sortCycle[0]
+	for i, mode := range sortCycle {
+		if mode == m.sortMode {
+			next = sortCycle[(i+1)%len(sortCycle)]
+			break
+		}
+	}
+	m.sortMode = next
+	m.allHosts = orderHosts(m, m.allHosts)
+	applySearch(&m)
+	m.cursor, m.viewport = 0, 0
+	m.statusMsg = "Sorted by " + next + " (Ctrl+S to change)."
+	return m
+}
+
+// openGroupFilter lists the groups to filter by, with the cursor on the
+// active one.
+func openGroupFilter(m Model) Model {
+	groups := legendGroups(m)
+	if len(groups) == 0 {
+		m.statusMsg = "No groups to filter by."
+		return m
+	}
+	m.groupCursor = 0
+	for i, g := range groups {
+		if g == m.activeGroup {
+			m.groupCursor = i + 1
+		}
+	}
+	m.mode = modeGroupFilter
+	return m
+}
+
+// handleGroupFilter processes keys in the group filter: Enter restricts the
+// list to the group under the cursor ("All" clears it), and Esc clears the
+// filter.
+func handleGroupFilter(m Model, msg tea.KeyMsg) Model {
+	groups := legendGroups(m)
+	rows := len(groups) + 1
+	switch msg.String() {
+	case "down":
+		m.groupCursor = (m.groupCursor + 1) % rows
+		return m
+	case "up":
+		m.groupCursor = (m.groupCursor - 1 + rows) % rows
+		return m
+	case "enter":
+		m.activeGroup = ""
+		if m.groupCursor > 0 && m.groupCursor <= len(groups) {
+			m.activeGroup = groups[m.groupCursor-1]
+		}
+	case "esc", "ctrl+c":
+		m.activeGroup = ""
+	default:
+		return m
+	}
+	m.mode = modeNormal
+	refilter(&m)
+	return m
+}
+
+// confirmDelete asks whether to delete the selected host's block from the
+// config. The answer is handled by handleConfirmDelete.
+func confirmDelete(m Model) Model {
+	if len(m.filtered) == 0 {
+		return m
+	}
+	host := m.filtered[m.cursor]
+	if host.ReadOnly {
+		m.statusMsg = "Cannot delete: " + host.Alias + " is defined in the read-only system config " + host.SourceFile + "."
+		return m
+	}
+	if host.LineStart == 0 {
+		m.statusMsg = "Cannot delete: host has no tracked line position."
+		return m
+	}
+	m.pendingDelete = &host
+	m.mode = modeConfirmDelete
+	return m
+}
+
+// handleConfirmDelete processes the answer to the delete prompt: "y" deletes
+// the block, "n" or Esc cancels. Other keys are ignored.
+func handleConfirmDelete(m Model, msg tea.KeyMsg) Model {
+	switch msg.String() {
+	case "y", "Y":
+		host := *m.pendingDelete
+		m.pendingDelete = nil
+		m.mode = modeNormal
+		lineDelta, err := config.DeleteHost(host)
+		if err != nil {
+			m.statusMsg = "Delete failed: " + err.Error()
+			return m
+		}
+		removeDeletedHost(&m, host, lineDelta)
+		refilter(&m)
+		m.statusMsg = "Deleted " + host.Alias + " (backup in " + filepath.Base(host.SourceFile) + ".bak)."
+	case "n", "N", "esc", "ctrl+c":
+		m.pendingDelete = nil
+		m.mode = modeNormal
+	}
+	return m
+}
+
+// openRawBlock shows the selected host's block as it is in its config file,
+// read fresh from disk. Errors are reported in the status bar.
+func openRawBlock(m Model) Model {
+	if len(m.filtered) == 0 {
+		return m
+	}
+	host := m.filtered[m.cursor]
+	lines, first, err := config.RawBlock(host)
+	if err != nil {
+		m.statusMsg = "Cannot show the block for " + host.Alias + ": " + err.Error()
+		return m
+	}
+	m.rawBlock = &rawBlockView{alias: host.Alias, source: host.SourceFile, first: first, lines: lines}
+	return m
+}
+
+// handleRawBlock processes keys while the raw block panel is open.
+func handleRawBlock(m Model, msg tea.KeyMsg) Model {
+	v := *m.rawBlock
+	switch msg.String() {
+	case "esc", "ctrl+c", "R", "q":
+		m.rawBlock = nil
+		return m
+	case "down":
+		if v.offset+m.viewHeight < len(v.lines) {
+			v.offset++
+		}
+	case "up":
+		if v.offset > 0 {
+			v.offset--
+		}
+	}
+	m.rawBlock = &v
+	return m
+}
+
+// maxFavorites is how many pinned hosts the favorites bar shows, one per
+// key alt+1 to alt+9.
+const maxFavorites = 9
+
+// favorites returns the pinned hosts shown in the favorites bar, in pin order.
+// Pins whose alias is no longer in the config are skipped.
+func favorites(m Model) []config.Host {
+	if m.state == nil {
+		return nil
+	}
+	var favs []config.Host
+	for _, alias := range m.state.Pinned {
+		for _, h := range m.allHosts {
+			if h.Alias == alias {
+				favs = append(favs, h)
+				break
+			}
+		}
+		if len(favs) == maxFavorites {
+			break
+		}
+	}
+	return favs
+}
+
+// favoriteForKey returns the favorite that key (alt+1 to alt+9) selects, if
+// any. Plain digits are left to start a search, so typing an address such as
+// "10.0." never connects to a favorite.
+func favoriteForKey(m Model, key string) (config.Host, bool) {
+	digit, ok := strings.CutPrefix(key, "alt+")
+	if !ok || len(digit) != 1 || digit[0] < '1' || digit[0] > '9' {
+		return config.Host{}, false
+	}
+	favs := favorites(m)
+	i := int(digit[0] - '1')
+	if i >= len(favs) {
+		return config.Host{}, false
+	}
+	return favs[i], true
+}
+
+// togglePin pins or unpins the selected host and saves the state unless
+// history is disabled.
+func togglePin(m Model) Model {
+	if len(m.filtered) == 0 || m.state == nil {
+		return m
+	}
+	alias := m.filtered[m.cursor].Alias
+	if state.TogglePin(m.state, alias) {
+		m.statusMsg = "Pinned " + alias + "."
+	} else {
+		m.statusMsg = "Unpinned " + alias + "."
+	}
+	if !m.noHistory {
+		_ = state.Save(m.statePath, m.state)
+	}
+	resizeList(&m)
+	return m
+}
+
+// toggleHidden reveals or re-hides hosts marked Hidden, keeping the selection
+// on the same host when it is still listed.
+func toggleHidden(m Model) Model {
+	m.showHidden = !m.showHidden
+	if m.showHidden {
+		m.statusMsg = "Showing hidden hosts (H to hide)."
+	} else {
+		m.statusMsg = ""
+	}
+	refilter(&m)
+	return m
+}
+
+// startsSearch reports whether typing runes in normal mode should begin a
+// search: the first rune must be printable and not whitespace, and no rune may
+// be a control character. A stray space or tab is ignored.
+func startsSearch(runes []rune) bool {
+	if len(runes) == 0 || unicode.IsSpace(runes[0]) {
+		return false
+	}
+	for _, r := range runes {
+		if !unicode.IsPrint(r) && !unicode.IsSpace(r) {
+			return false
+		}
+	}
+	return true
+}
+
 // handleSearchMode processes keys in search mode.
 func handleSearchMode(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
 	switch msg.String() {
@@ -206,8 +1052,13 @@ func handleSearchMode(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
 		m.mode = modeNormal
 		return m, nil
 
-	case "enter":
-		return connectToSelected(m)
+	case "enter", "ctrl+e":
+		m.history.push(m.searchQuery)
+		return enterOrEdit(m, msg.String())
+
+	case "ctrl+o":
+		m.history.push(m.searchQuery)
+		return connectDetached(m)
 
 	case "down":
 		return moveCursorDown(m), nil
@@ -224,9 +1075,6 @@ func handleSearchMode(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
 		m.mode = modeNormal
 		return m, nil
 
-	case "ctrl+e":
-		return openEditForm(m), nil
-
 	case "backspace":
 		runes := []rune(m.searchQuery)
 		if len(runes) == 0 {
@@ -249,57 +1097,182 @@ func handleSearchMode(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
 	}
 }
 
-// handleEditMode processes keys while the editor form is open.
-func handleEditMode(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
-	form := m.edit
-
+// handlePromptMode processes keys in the explicit search prompt. The list
+// filters as in search mode, but ↑/↓ recall earlier queries. Enter keeps the
+// query and returns to search mode so the results can be navigated.
+func handlePromptMode(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
 	switch msg.String() {
 	case "esc":
-		m.edit = nil
+		m.searchQuery = ""
+		applySearch(&m)
 		m.mode = modeNormal
 		return m, nil
 
 	case "ctrl+c":
 		return m, tea.Quit
 
-	case "down":
-		form.activeField = (form.activeField + 1) % fieldCount
-		m.edit = form
+	case "enter":
+		m.history.push(m.searchQuery)
+		if m.searchQuery == "" {
+			m.mode = modeNormal
+		} else {
+			m.mode = modeSearch
+		}
 		return m, nil
 
 	case "up":
-		form.activeField = (form.activeField - 1 + fieldCount) % fieldCount
-		m.edit = form
+		if q, ok := m.history.older(); ok {
+			m.searchQuery = q
+			applySearch(&m)
+		}
+		return m, nil
+
+	case "down":
+		if q, ok := m.history.newer(); ok {
+			m.searchQuery = q
+			applySearch(&m)
+		}
 		return m, nil
 
 	case "backspace":
-		runes := []rune(form.fields[form.activeField])
+		runes := []rune(m.searchQuery)
 		if len(runes) > 0 {
-			form.fields[form.activeField] = string(runes[:len(runes)-1])
+			m.searchQuery = string(runes[:len(runes)-1])
+			applySearch(&m)
 		}
-		form.statusMsg = ""
-		m.edit = form
 		return m, nil
 
-	case "ctrl+u":
-		form.fields[form.activeField] = ""
-		form.statusMsg = ""
-		m.edit = form
+	default:
+		if msg.Type == tea.KeyRunes {
+			m.searchQuery += string(msg.Runes)
+			applySearch(&m)
+		}
+		return m, nil
+	}
+}
+
+// handleConfirmDiff processes keys while the pending edit's diff is shown.
+func handleConfirmDiff(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
+	switch msg.String() {
+	case "enter", "y":
+		return saveEditForm(m)
+	case "esc", "n", "ctrl+c":
+		// As in the form itself, Ctrl+C backs out rather than quitting.
+		m.edit.diff = ""
+	}
+	return m, nil
+}
+
+// handleEditMode processes keys while the editor form is open.
+func handleEditMode(m Model, msg tea.KeyMsg) (Model, tea.Cmd) {
+	form := m.edit
+	if form.diff != "" {
+		return handleConfirmDiff(m, msg)
+	}
+
+	switch msg.String() {
+	case "esc", "ctrl+c":
+		// Ctrl+C closes the form like Esc rather than quitting, and unsaved
+		// changes need a second press to discard.
+		if form.dirty() && !form.confirmDiscard {
+			form.confirmDiscard = true
+			form.statusMsg = "Unsaved changes. Press Esc again to discard, Enter to save."
+			return m, nil
+		}
+		m.edit = nil
+		m.mode = modeNormal
 		return m, nil
 
 	case "enter":
+		form.confirmDiscard = false
 		return saveEditForm(m)
 
 	default:
-		if msg.Type == tea.KeyRunes {
-			form.fields[form.activeField] += string(msg.Runes)
+		if form.confirmDiscard {
+			form.confirmDiscard = false
 			form.statusMsg = ""
-			m.edit = form
 		}
+		form.handleFieldKey(msg)
+		m.edit = form
 		return m, nil
 	}
 }
 
+// handleFieldKey applies a navigation or text-editing key to the form.
+func (f *editForm) handleFieldKey(msg tea.KeyMsg) {
+	switch msg.String() {
+	case "tab":
+		// Tab accepts a ghosted hostname completion if one is shown.
+		if s := f.hostnameSuggestion(); s != "" {
+			f.fields[fieldHostname] = s
+			return
+		}
+		f.activeField = (f.activeField + 1) % fieldCount
+
+	case "down":
+		f.activeField = (f.activeField + 1) % fieldCount
+
+	case "up", "shift+tab":
+		f.activeField = (f.activeField - 1 + fieldCount) % fieldCount
+
+	case "backspace":
+		runes := []rune(f.fields[f.activeField])
+		if len(runes) > 0 {
+			f.fields[f.activeField] = string(runes[:len(runes)-1])
+		}
+		f.statusMsg = ""
+
+	case "ctrl+u":
+		f.fields[f.activeField] = ""
+		f.statusMsg = ""
+
+	case "ctrl+r":
+		// Revert only the active field to the value the form opened with.
+		f.fields[f.activeField] = newEditForm(f.original).fields[f.activeField]
+		f.statusMsg = ""
+
+	default:
+		if msg.Type == tea.KeyRunes {
+			typed, hint := string(msg.Runes), ""
+			if filter := fieldInputFilters[f.activeField]; filter != nil {
+				typed, hint = filter(f.fields[f.activeField], msg.Runes)
+			}
+			f.fields[f.activeField] += typed
+			f.statusMsg = hint
+		}
+	}
+}
+
+// fieldInputFilters restricts what can be typed into a field. A filter gets
+// the field's current value and the typed runes, and returns the text to
+// insert plus a hint to show when something was rejected. Fields without a
+// filter accept anything.
+var fieldInputFilters = [fieldCount]func(value string, typed []rune) (string, string){
+	fieldPort: portInput,
+}
+
+// maxPortDigits is the length of the largest port, 65535.
+const maxPortDigits = 5
+
+// portInput keeps only digits, up to maxPortDigits in total.
+func portInput(value string, typed []rune) (string, string) {
+	var b strings.Builder
+	hint := ""
+	n := len(value)
+	for _, r := range typed {
+		switch {
+		case r < '0' || r > '9':
+			hint = "Port takes digits only."
+		case n >= maxPortDigits:
+			hint = "Port is at most 5 digits."
+		default:
+			b.WriteRune(r)
+			n++
+		}
+	}
+	return b.String(), hint
+}
+
 // max returns the larger of two integers.
 func max(a, b int) int {
 	if a > b {
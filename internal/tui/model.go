@@ -1,12 +1,20 @@
 package tui
 
 import (
-	"sort"
+	"net"
+	"os"
+	"runtime"
+	"strconv"
 	"strings"
+	"time"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
 	"github.com/sahilm/fuzzy"
 	"github.com/srava/swiftssh/internal/config"
+	typos "github.com/srava/swiftssh/internal/fuzzy"
+	"github.com/srava/swiftssh/internal/platform"
+	"github.com/srava/swiftssh/internal/ssh"
 	"github.com/srava/swiftssh/internal/state"
 )
 
@@ -16,6 +24,15 @@ const (
 	modeNormal mode = iota
 	modeSearch
 	modeEdit
+	// modePrompt is the explicit search prompt (Ctrl+F): arrows recall past
+	// queries instead of moving the cursor.
+	modePrompt
+	// modeConfirmDelete asks "y/n" before deleting pendingDelete's block.
+	modeConfirmDelete
+	// modeHelp shows the keybinding overlay opened with "?".
+	modeHelp
+	// modeGroupFilter lists the groups to restrict the list to (G).
+	modeGroupFilter
 )
 
 type editField int
@@ -36,6 +53,160 @@ type editForm struct {
 	fields      [fieldCount]string
 	activeField editField
 	statusMsg   string
+	hostnames   []string // known hostnames offered as completions for fieldHostname
+	diff        string   // pending change awaiting confirmation (with --confirm-edits)
+	adding      bool     // the host is new and is appended on save (quick add)
+	// confirmDiscard is set after Esc on a dirty form; a second Esc discards.
+	confirmDiscard bool
+}
+
+// newEditForm returns a form pre-filled from host.
+func newEditForm(host config.Host) *editForm {
+	form := &editForm{
+		original:    host,
+		activeField: fieldAlias,
+	}
+	form.fields[fieldAlias] = host.Alias
+	form.fields[fieldHostname] = host.Hostname
+	form.fields[fieldUser] = host.User
+	form.fields[fieldPort] = host.Port
+	form.fields[fieldIdentityFile] = strings.Join(host.Identities(), ", ")
+	form.fields[fieldGroups] = strings.Join(host.Groups, ", ")
+	return form
+}
+
+// dirty reports whether any field differs from the host the form was opened with.
+func (f *editForm) dirty() bool {
+	return f.fields != newEditForm(f.original).fields
+}
+
+// host returns the original host updated with the form's field values, or a
+// message describing why the fields are invalid.
+func (f *editForm) host() (config.Host, string) {
+	alias := strings.TrimSpace(f.fields[fieldAlias])
+	hostname := strings.TrimSpace(f.fields[fieldHostname])
+
+	if alias == "" {
+		return config.Host{}, "Alias cannot be empty."
+	}
+	if hostname == "" {
+		return config.Host{}, "Hostname cannot be empty."
+	}
+
+	port := strings.TrimSpace(f.fields[fieldPort])
+	if port == "" {
+		port = "22"
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 1 || n > 65535 {
+		return config.Host{}, "Port must be a number from 1 to 65535."
+	}
+
+	updated := f.original
+	updated.Alias = alias
+	updated.Hostname = hostname
+	updated.User = strings.TrimSpace(f.fields[fieldUser])
+	updated.Port = port
+	updated.IdentityFiles = splitList(f.fields[fieldIdentityFile])
+	updated.IdentityFile = ""
+	if len(updated.IdentityFiles) > 0 {
+		updated.IdentityFile = updated.IdentityFiles[0]
+	}
+	updated.Groups = splitList(f.fields[fieldGroups])
+	return updated, ""
+}
+
+// splitList splits a comma-separated field into its trimmed, non-empty items.
+func splitList(s string) []string {
+	var items []string
+	for _, item := range strings.Split(s, ",") {
+		item = strings.TrimSpace(item)
+		if item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// suggestHostname returns the best completion for prefix from candidates: the
+// shortest candidate that starts with prefix (case-insensitive) and is longer
+// than it, with ties broken alphabetically. Returns "" if there is none.
+func suggestHostname(prefix string, candidates []string) string {
+	if prefix == "" {
+		return ""
+	}
+	lp := strings.ToLower(prefix)
+	best := ""
+	for _, c := range candidates {
+		if len(c) <= len(prefix) || !strings.HasPrefix(strings.ToLower(c), lp) {
+			continue
+		}
+		if best == "" || len(c) < len(best) || (len(c) == len(best) && c < best) {
+			best = c
+		}
+	}
+	return best
+}
+
+// hostnameSuggestion returns the completion currently offered for the active
+// field, or "" if the active field is not the hostname or nothing matches.
+func (f *editForm) hostnameSuggestion() string {
+	if f.activeField != fieldHostname {
+		return ""
+	}
+	return suggestHostname(f.fields[fieldHostname], f.hostnames)
+}
+
+// sshExitMsg is emitted when an ssh session started from the list ends.
+type sshExitMsg struct {
+	err  error
+	host config.Host
+}
+
+// sftpExitMsg is emitted when an sftp session started with F ends.
+type sftpExitMsg struct {
+	alias string
+	err   error
+}
+
+// afterLocalMsg reports the outcome of a host's "# @after-local" command.
+type afterLocalMsg struct {
+	alias string
+	err   error
+}
+
+// quickAddPrompt reads a pasted "user@host:port" destination for quick add.
+type quickAddPrompt struct {
+	input     string
+	statusMsg string
+}
+
+// rawBlockView shows a host's block exactly as written in its config file.
+type rawBlockView struct {
+	alias  string
+	source string
+	first  int // 1-based line number of lines[0] in source
+	lines  []string
+	offset int // index of the first line in view
+}
+
+// masterStartedMsg reports the outcome of starting a master connection.
+type masterStartedMsg struct {
+	alias string
+	err   error
+}
+
+// autoConnectMsg fires once the search query has been left alone for the
+// auto-connect delay; it is stale if any key was pressed or any connection
+// made since, which bumps Model.autoConnectGen.
+type autoConnectMsg struct {
+	gen int
+}
+
+// identityPicker lists SSH keys to connect to host with.
+type identityPicker struct {
+	host   config.Host
+	keys   []string // private key paths
+	cursor int
 }
 
 // editSavedMsg is emitted after a successful in-place save.
@@ -55,68 +226,250 @@ type Model struct {
 	viewport    int
 	viewHeight  int
 	width       int
+	height      int // terminal rows; 0 until the first WindowSizeMsg
 	mode        mode
 	searchQuery string
 	state       *state.State
 	statePath   string
+	configPath  string // passed to ssh as -F; "" when the default config is in use
+	logPath     string // connection log; "" disables it
 	statusMsg   string
 	noFrequent  bool
-	edit        *editForm
+	sortMode    string // state.SortFrequency, SortAlpha, SortGroup, or SortRecent
+	noHistory   bool
+	clearSearch bool // drop the search query when an ssh session ends
+	// autoConnect is how long the query must rest on a single match before
+	// connecting to it; 0 disables auto-connect.
+	autoConnect time.Duration
+	// autoConnectGen counts keys and connects; a pending autoConnectMsg
+	// carries the count it was scheduled at.
+	autoConnectGen int
+	inline         bool // no alternate screen: fixed, short list height
+	scheme         *config.Scheme
+	matchMode      string // MatchFuzzy or MatchPrefix
+	// highlight draws the selected row: selectedStyle, or contrastStyle with
+	// NoReverse.
+	highlight   lipgloss.Style
+	showLegend  bool
+	showPreview bool
+	showUses    bool // USES column with each host's connection count
+	connectBy   string
+	enterEdits  bool
+	// confirmEdits shows a diff of the host block and asks before saving.
+	confirmEdits bool
+	limit        int // max hosts shown while not searching; 0 = unlimited
+	limitStep    int // amount "+" raises limit by
+	// domainSuffix is the domain most hostnames share; stripDomain hides it
+	// in the list display.
+	domainSuffix string
+	stripDomain  bool
+	showHidden   bool // list hosts marked Hidden (toggled with "H")
+	verbosity    int  // ssh -v level for the next connection only (cycled with "V")
+	history      *searchHistory
+	// showResolved appends each visible hostname's current IP (Ctrl+\).
+	showResolved bool
+	resolved     *resolveCache
+	resolver     Resolver
+	// lastConnectedAlias is the host most recently connected to in place; the
+	// cursor returns to it when the ssh session ends.
+	lastConnectedAlias string
+	// retryHost is the host whose in-place ssh just exited non-zero; the next
+	// key may be "r" to retry it or "i" to pick another key first.
+	retryHost *config.Host
+	// pendingDelete is the host Ctrl+D asked to delete, awaiting "y".
+	pendingDelete *config.Host
+	// activeGroup restricts the list to hosts in this group (picked with
+	// "G"); "" lists every group.
+	activeGroup string
+	groupCursor int // row in the group filter; 0 is "All"
+	// sessions tracks detached launches; shared across Model copies.
+	sessions      *ssh.Registry
+	showSessions  bool
+	sessionCursor int
+	// insecureKeys caches IdentityFile paths whose permissions ssh would reject.
+	insecureKeys map[string]bool
+	edit         *editForm
+	picker       *identityPicker
+	rawBlock     *rawBlockView
+	quickAdd     *quickAddPrompt
+	keyDir       string // where the identity picker looks for keys
+
+	// Environment hooks for detached launches; replaced in tests.
+	getenv func(string) string
+	goos   string
+	spawn  ssh.Runner
+	// runLocal runs a host's "# @after-local" command.
+	runLocal func(command string) error
+	copyText func(text string) error
+	// hasMaster reports whether a control socket is listening at path.
+	hasMaster func(path string) bool
+}
+
+// Options holds optional behaviour switches for NewWithOptions.
+type Options struct {
+	NoFrequent bool   // flat alphabetical order (skip frequency sort)
+	NoHistory  bool   // never record or persist connections; implies NoFrequent
+	Sort       string // state.SortFrequency (default), SortAlpha, SortGroup, or SortRecent
+	ConnectBy  string // "alias" (default) or "hostname"
+	Limit      int    // initially show at most this many hosts; "+" shows Limit more (0 = all)
+	Preview    bool   // show the ssh command Enter would run for the selected host
+	LogPath    string // append each connection to this audit log ("" = off)
+	// ConfigPath is passed to ssh with -F so aliases resolve against the config
+	// being shown. Leave empty when it is ssh's default config.
+	ConfigPath string
+	// ConfirmEdits shows a diff of each edit and asks before writing it.
+	ConfirmEdits bool
+	// ClearSearch clears the search query when an in-place ssh session ends.
+	// By default the query and its results are kept, so a neighbouring host
+	// is one keypress away.
+	ClearSearch bool
+	// EnterAction is "connect" (default) or "edit". With "edit", Enter opens the
+	// edit form and Ctrl+E connects, guarding against accidental connects.
+	EnterAction string
+	// Scheme, if set, derives an environment pseudo-group from each alias
+	// that follows it. It replaces the host's groups in the list, legend and
+	// search; the groups in the config are unchanged.
+	Scheme *config.Scheme
+	// Match is MatchFuzzy (default) or MatchPrefix, which keeps only hosts
+	// whose alias or hostname starts with the query.
+	Match string
+	// AutoConnect connects to the only host matching the search once typing
+	// pauses, without waiting for Enter. Hosts in a guarded group such as
+	// "prod" still need Enter.
+	AutoConnect bool
+	// NoReverse highlights the selected row with explicit high-contrast
+	// colors instead of reverse video.
+	NoReverse bool
+	// Inline is set when the program runs without the alternate screen. The
+	// list is then kept to inlineRows rows and padded to a fixed height, so
+	// the frame never jumps and the last one stays in the scrollback.
+	Inline bool
+	// ShowUses adds a USES column with each host's connection count.
+	ShowUses bool
 }
 
+// inlineRows caps the list height with Options.Inline.
+const inlineRows = 10
+
+// autoConnectDelay is the pause in typing after which AutoConnect connects.
+const autoConnectDelay = 600 * time.Millisecond
+
+// autoConnectGuardGroups are the groups (case-insensitive) whose hosts are
+// never auto-connected.
+var autoConnectGuardGroups = []string{"prod", "production", "danger"}
+
+// Search match modes for Options.Match.
+const (
+	MatchFuzzy  = "fuzzy"
+	MatchPrefix = "prefix"
+)
+
+// Enter actions for Options.EnterAction.
+const (
+	EnterActionConnect = "connect"
+	EnterActionEdit    = "edit"
+)
+
+// Connect-by modes for Options.ConnectBy.
+const (
+	ConnectByAlias    = "alias"
+	ConnectByHostname = "hostname"
+)
+
 // New creates a new Model. If noFrequent is true, hosts are sorted purely
 // alphabetically; otherwise frequent hosts bubble to the top.
 func New(hosts []config.Host, st *state.State, statePath string, noFrequent bool) Model {
-	var allHosts []config.Host
-	if noFrequent {
-		allHosts = make([]config.Host, len(hosts))
-		copy(allHosts, hosts)
-		sort.Slice(allHosts, func(i, j int) bool {
-			return strings.ToLower(allHosts[i].Alias) < strings.ToLower(allHosts[j].Alias)
-		})
-	} else {
-		// Get frequent hosts sorted by connection count (descending)
-		frequent := state.FrequentHosts(st, hosts, len(hosts))
-
-		// Build a set of frequent host IDs to exclude from remaining hosts
-		frequentSet := make(map[string]bool)
-		for _, h := range frequent {
-			frequentSet[h.Alias+"\x00"+h.SourceFile] = true
-		}
-
-		// Collect remaining hosts (not in frequent set)
-		var remaining []config.Host
-		for _, h := range hosts {
-			if !frequentSet[h.Alias+"\x00"+h.SourceFile] {
-				remaining = append(remaining, h)
-			}
-		}
+	return NewWithOptions(hosts, st, statePath, Options{NoFrequent: noFrequent})
+}
 
-		// Sort remaining alphabetically by alias (case-insensitive)
-		sort.Slice(remaining, func(i, j int) bool {
-			return strings.ToLower(remaining[i].Alias) < strings.ToLower(remaining[j].Alias)
-		})
+// NewWithOptions creates a new Model configured by opts.
+func NewWithOptions(hosts []config.Host, st *state.State, statePath string, opts Options) Model {
+	noFrequent := opts.NoFrequent || opts.NoHistory
+	sortMode := opts.Sort
+	if sortMode == "" {
+		sortMode = state.SortFrequency
+	}
 
-		allHosts = append(frequent, remaining...)
+	m := Model{
+		cursor:       0,
+		viewport:     0,
+		viewHeight:   20,
+		width:        80,
+		mode:         modeNormal,
+		searchQuery:  "",
+		state:        st,
+		statePath:    statePath,
+		logPath:      opts.LogPath,
+		configPath:   opts.ConfigPath,
+		noFrequent:   noFrequent,
+		sortMode:     sortMode,
+		noHistory:    opts.NoHistory,
+		clearSearch:  opts.ClearSearch,
+		scheme:       opts.Scheme,
+		matchMode:    opts.Match,
+		connectBy:    opts.ConnectBy,
+		enterEdits:   opts.EnterAction == EnterActionEdit,
+		limit:        opts.Limit,
+		limitStep:    opts.Limit,
+		confirmEdits: opts.ConfirmEdits,
+		showPreview:  opts.Preview,
+		showUses:     opts.ShowUses,
+		getenv:       os.Getenv,
+		goos:         runtime.GOOS,
+		spawn:        ssh.RunOutput,
+		runLocal:     ssh.RunLocal,
+		copyText:     platform.CopyToClipboard,
+		hasMaster:    ssh.MasterRunning,
+		history:      newSearchHistory(),
+		resolved:     newResolveCache(),
+		resolver:     net.DefaultResolver,
+		sessions:     ssh.NewRegistry(ssh.TmuxWindowAlive),
+		keyDir:       platform.SSHKeyDir(),
+		insecureKeys: make(map[string]bool),
+	}
+	if opts.AutoConnect {
+		m.autoConnect = autoConnectDelay
+	}
+	if opts.Inline {
+		m.inline = true
+		m.viewHeight = inlineRows
 	}
+	m.highlight = selectedStyle
+	if opts.NoReverse {
+		m.highlight = contrastStyle
+	}
+	m.allHosts = orderHosts(m, hosts)
+	hostnames := make([]string, len(m.allHosts))
+	for i, h := range m.allHosts {
+		checkIdentityPerms(&m, h)
+		hostnames[i] = h.Hostname
+	}
+	m.domainSuffix = commonDomainSuffix(hostnames)
+	m.filtered = m.listable()
+	return m
+}
 
-	// Initialize filtered list as a copy of all hosts
-	filtered := make([]config.Host, len(allHosts))
-	copy(filtered, allHosts)
+// orderHosts sorts hosts by m's sort mode. With noFrequent, connection counts
+// are ignored: frequency order becomes alphabetical and group order ranks
+// hosts within each group by alias.
+func orderHosts(m Model, hosts []config.Host) []config.Host {
+	if !m.noFrequent {
+		return state.OrderHostsBy(hosts, m.state, m.sortMode)
+	}
+	if m.sortMode == state.SortGroup {
+		return state.OrderHostsBy(hosts, nil, state.SortGroup)
+	}
+	return state.OrderHostsBy(hosts, m.state, state.SortAlpha)
+}
 
-	return Model{
-		allHosts:    allHosts,
-		filtered:    filtered,
-		cursor:      0,
-		viewport:    0,
-		viewHeight:  20,
-		width:       80,
-		mode:        modeNormal,
-		searchQuery: "",
-		state:       st,
-		statePath:   statePath,
-		noFrequent:  noFrequent,
+// checkIdentityPerms records whether h's IdentityFile has permissions ssh
+// would reject. Files that cannot be stat'ed are not flagged.
+func checkIdentityPerms(m *Model, h config.Host) {
+	if h.IdentityFile == "" {
+		return
 	}
+	ok, err := h.IdentityPermsOK()
+	m.insecureKeys[h.IdentityFile] = err == nil && !ok
 }
 
 // Init returns nil (no initial command).
@@ -129,71 +482,395 @@ func (m Model) Update(msg tea.Msg) (tea.Model, tea.Cmd) {
 	switch msg := msg.(type) {
 	case tea.WindowSizeMsg:
 		m.width = msg.Width
-		m.viewHeight = msg.Height - 4 // -1 title, -1 column header, -1 status bar, -1 margin
-		if m.viewHeight < 1 {
-			m.viewHeight = 1
-		}
-		return m, nil
+		m.height = msg.Height
+		resizeList(&m)
+		return m, resolveVisible(m)
 	case tea.KeyMsg:
 		newModel, cmd := handleKey(m, msg)
+		newModel.autoConnectGen++
+		if newModel.showResolved {
+			cmd = tea.Batch(cmd, resolveVisible(newModel))
+		}
+		if newModel.searchQuery != m.searchQuery {
+			cmd = tea.Batch(cmd, scheduleAutoConnect(newModel))
+		}
 		return newModel, cmd
-	case editSavedMsg:
-		if msg.index >= 0 && msg.index < len(m.allHosts) {
-			m.allHosts[msg.index] = msg.updated
-		}
-		// Shift LineStart for all hosts in the same file that appear after the saved block.
-		if msg.lineDelta != 0 {
-			for i := range m.allHosts {
-				if i != msg.index &&
-					m.allHosts[i].SourceFile == msg.sourceFile &&
-					m.allHosts[i].LineStart > msg.originalLineStart {
-					m.allHosts[i].LineStart += msg.lineDelta
-				}
-			}
+	case autoConnectMsg:
+		if host, ok := autoConnectTarget(m); ok && msg.gen == m.autoConnectGen {
+			m.history.push(m.searchQuery)
+			return connectHost(m, host)
 		}
+		return m, nil
+	case resolvedMsg:
+		m.resolved.store(msg)
+		return m, nil
+	case editSavedMsg:
+		applySavedHost(&m, msg)
 		m.edit = nil
 		m.mode = modeNormal
 		m.statusMsg = "Saved."
+		refilter(&m)
+		return m, nil
+	case sshExitMsg:
+		m.statusMsg = ""
+		// Re-rank so the just-used host floats up by its new count. The
+		// search query is kept unless --clear-search is set.
+		m.allHosts = orderHosts(m, m.allHosts)
+		if m.clearSearch {
+			m.searchQuery = ""
+			m.mode = modeNormal
+		}
+		applySearch(&m)
+		selectAlias(&m, m.lastConnectedAlias)
+		recordOutcome(m, msg.host.Alias, msg.err)
+		if msg.err != nil {
+			host := msg.host
+			m.retryHost = &host
+			m.statusMsg = "ssh to " + host.Alias + " failed: " + msg.err.Error() +
+				" | r: retry | i: retry with another key"
+			return m, nil
+		}
+		return m, runAfterLocal(m, msg.host)
+	case sftpExitMsg:
+		m.statusMsg = ""
+		m.allHosts = orderHosts(m, m.allHosts)
 		applySearch(&m)
+		selectAlias(&m, m.lastConnectedAlias)
+		if msg.err != nil {
+			m.statusMsg = "sftp to " + msg.alias + " failed: " + msg.err.Error()
+		}
+		return m, nil
+	case afterLocalMsg:
+		if msg.err != nil {
+			m.statusMsg = "@after-local for " + msg.alias + " failed: " + msg.err.Error()
+		}
+		return m, nil
+	case masterStartedMsg:
+		if msg.err != nil {
+			m.statusMsg = "Master connection to " + msg.alias + " failed: " + msg.err.Error()
+		} else {
+			m.statusMsg = "Master connection to " + msg.alias + " started; connects will reuse it."
+		}
 		return m, nil
 	}
 	return m, nil
 }
 
+// scheduleAutoConnect returns a command that asks for an auto-connect after
+// the delay, or nil if the current query would not auto-connect anyway.
+func scheduleAutoConnect(m Model) tea.Cmd {
+	if _, ok := autoConnectTarget(m); !ok {
+		return nil
+	}
+	gen := m.autoConnectGen
+	return tea.Tick(m.autoConnect, func(time.Time) tea.Msg {
+		return autoConnectMsg{gen: gen}
+	})
+}
+
+// autoConnectTarget returns the host to auto-connect to: the only match of a
+// search in progress, provided it is not in a guarded group.
+func autoConnectTarget(m Model) (config.Host, bool) {
+	if m.autoConnect == 0 || (m.mode != modeSearch && m.mode != modePrompt) || len(m.filtered) != 1 {
+		return config.Host{}, false
+	}
+	host := m.filtered[0]
+	// Check the config groups and, with --scheme, the derived environment.
+	for _, groups := range [][]string{host.Groups, m.groupsOf(host)} {
+		for _, g := range groups {
+			for _, guard := range autoConnectGuardGroups {
+				if strings.EqualFold(g, guard) {
+					return config.Host{}, false
+				}
+			}
+		}
+	}
+	return host, true
+}
+
+// applySavedHost replaces the saved host in m.allHosts and shifts LineStart for
+// the hosts after it in the same file.
+func applySavedHost(m *Model, msg editSavedMsg) {
+	if msg.index >= 0 && msg.index < len(m.allHosts) {
+		m.allHosts[msg.index] = msg.updated
+	}
+	checkIdentityPerms(m, msg.updated)
+	// Shift LineStart for all hosts in the same file that appear after the saved block.
+	if msg.lineDelta != 0 {
+		for i := range m.allHosts {
+			if i != msg.index &&
+				m.allHosts[i].SourceFile == msg.sourceFile &&
+				m.allHosts[i].LineStart > msg.originalLineStart {
+				m.allHosts[i].LineStart += msg.lineDelta
+			}
+		}
+	}
+}
+
+// removeDeletedHost drops deleted from m.allHosts and shifts LineStart for
+// the hosts after it in the same file.
+func removeDeletedHost(m *Model, deleted config.Host, lineDelta int) {
+	kept := m.allHosts[:0:0]
+	for _, h := range m.allHosts {
+		if h.SourceFile != deleted.SourceFile {
+			kept = append(kept, h)
+			continue
+		}
+		if h.LineStart == deleted.LineStart && h.Alias == deleted.Alias {
+			continue
+		}
+		if h.LineStart > deleted.LineStart {
+			h.LineStart += lineDelta
+		}
+		kept = append(kept, h)
+	}
+	m.allHosts = kept
+}
+
+// scrollToCursor adjusts the viewport so the cursor row is within it.
+func scrollToCursor(m *Model) {
+	if m.cursor < m.viewport {
+		m.viewport = m.cursor
+	} else if m.cursor >= m.viewport+m.viewHeight {
+		m.viewport = m.cursor - m.viewHeight + 1
+	}
+}
+
+// selectAlias moves the cursor to the visible host named alias, scrolling it
+// into view. The cursor is left unchanged if alias is not visible.
+func selectAlias(m *Model, alias string) {
+	for i := 0; i < m.visibleLen(); i++ {
+		if m.filtered[i].Alias != alias {
+			continue
+		}
+		m.cursor = i
+		scrollToCursor(m)
+		return
+	}
+}
+
+// resizeList fits viewHeight to the terminal height minus the lines around
+// the list. It does nothing before the terminal size is known.
+func resizeList(m *Model) {
+	if m.height == 0 {
+		return
+	}
+	m.viewHeight = m.height - 4 // -1 title, -1 column header, -1 status bar, -1 margin
+	if m.showPreview {
+		m.viewHeight--
+	}
+	if len(favorites(*m)) > 0 {
+		m.viewHeight--
+	}
+	if m.inline {
+		m.viewHeight = min(m.viewHeight, inlineRows)
+	}
+	if m.viewHeight < 1 {
+		m.viewHeight = 1
+	}
+	scrollToCursor(m)
+}
+
+// refilter rebuilds m.filtered from m.allHosts and the current query while
+// keeping the selection: the cursor follows the selected host (matched by
+// alias and source file) if it is still visible, otherwise it stays at the
+// same index clamped to the new list, or 0 if the list is empty.
+func refilter(m *Model) {
+	var selected config.Host
+	hadSelection := m.cursor < m.visibleLen()
+	if hadSelection {
+		selected = m.filtered[m.cursor]
+	}
+	prevCursor, prevViewport := m.cursor, m.viewport
+
+	applySearch(m)
+
+	n := m.visibleLen()
+	m.cursor, m.viewport = 0, 0
+	if n == 0 {
+		return
+	}
+	m.cursor = min(prevCursor, n-1)
+	if hadSelection {
+		for i := 0; i < n; i++ {
+			if m.filtered[i].Alias == selected.Alias && m.filtered[i].SourceFile == selected.SourceFile {
+				m.cursor = i
+				break
+			}
+		}
+	}
+	m.viewport = min(prevViewport, m.cursor)
+	scrollToCursor(m)
+}
+
+// visibleLen returns how many entries of m.filtered are displayed. The limit
+// only applies to the unfiltered list; search results are always shown in full.
+func (m Model) visibleLen() int {
+	if m.limit > 0 && m.searchQuery == "" && m.limit < len(m.filtered) {
+		return m.limit
+	}
+	return len(m.filtered)
+}
+
+// hiddenCount returns how many filtered hosts are hidden by the display limit.
+func (m Model) hiddenCount() int {
+	return len(m.filtered) - m.visibleLen()
+}
+
+// listable returns m.allHosts without Hidden hosts, unless they have been
+// revealed, and without hosts outside the active group filter. When nothing
+// is left out it returns m.allHosts itself rather than a copy, so callers
+// must not modify the result.
+func (m Model) listable() []config.Host {
+	if (m.showHidden || !m.hasHiddenHosts()) && m.activeGroup == "" {
+		return m.allHosts
+	}
+	hosts := make([]config.Host, 0, len(m.allHosts))
+	for _, h := range m.allHosts {
+		if h.Hidden && !m.showHidden {
+			continue
+		}
+		if m.activeGroup != "" && !m.inGroup(h, m.activeGroup) {
+			continue
+		}
+		hosts = append(hosts, h)
+	}
+	return hosts
+}
+
+// inGroup reports whether h is in group (case-insensitive), counting groups
+// derived from the --scheme.
+func (m Model) inGroup(h config.Host, group string) bool {
+	for _, g := range m.groupsOf(h) {
+		if strings.EqualFold(g, group) {
+			return true
+		}
+	}
+	return false
+}
+
+// hasHiddenHosts reports whether any host is marked Hidden.
+func (m Model) hasHiddenHosts() bool {
+	for _, h := range m.allHosts {
+		if h.Hidden {
+			return true
+		}
+	}
+	return false
+}
+
+// groupsOf returns the groups shown for h: its environment when h's alias
+// follows the --scheme naming convention, otherwise its configured groups.
+func (m Model) groupsOf(h config.Host) []string {
+	if m.scheme != nil {
+		if env, ok := m.scheme.Environment(h.Alias); ok {
+			return []string{env}
+		}
+	}
+	return h.Groups
+}
+
 // applySearch filters m.allHosts using m.searchQuery and updates m.filtered.
-// Resets cursor and viewport to 0.
+// Hidden hosts are left out unless revealed, as are hosts outside the active
+// group filter. Resets cursor and viewport to 0.
 func applySearch(m *Model) {
+	pool := m.listable()
 	if m.searchQuery == "" {
-		m.filtered = make([]config.Host, len(m.allHosts))
-		copy(m.filtered, m.allHosts)
+		m.filtered = pool
+		m.cursor = 0
+		m.viewport = 0
+		return
+	}
+	if m.matchMode == MatchPrefix {
+		m.filtered = prefixMatches(pool, m.searchQuery)
 		m.cursor = 0
 		m.viewport = 0
 		return
 	}
 
 	// Build searchable strings: "alias hostname group1 group2 ..."
-	targets := make([]string, len(m.allHosts))
-	for i, h := range m.allHosts {
-		targets[i] = h.Alias + " " + h.Hostname + " " + strings.Join(h.Groups, " ")
+	targets := make([]string, len(pool))
+	for i, h := range pool {
+		targets[i] = h.Alias + " " + h.Hostname + " " + strings.Join(m.groupsOf(h), " ")
 	}
 
 	matches := fuzzy.Find(m.searchQuery, targets)
 	m.filtered = make([]config.Host, len(matches))
 	for i, match := range matches {
-		m.filtered[i] = m.allHosts[match.Index]
+		m.filtered[i] = pool[match.Index]
+	}
+
+	// No subsequence match: fall back to near-misses on alias/hostname so
+	// typos like "pord" still find "prod".
+	if len(matches) == 0 {
+		names := make([]string, len(pool))
+		for i, h := range pool {
+			names[i] = h.Alias + " " + h.Hostname
+		}
+		for _, i := range typos.FuzzyWithTypos(m.searchQuery, names) {
+			m.filtered = append(m.filtered, pool[i])
+		}
 	}
 
 	m.cursor = 0
 	m.viewport = 0
 }
 
+// prefixMatches returns the hosts in pool whose alias or hostname starts with
+// query, ignoring case, in pool order.
+func prefixMatches(pool []config.Host, query string) []config.Host {
+	query = strings.ToLower(query)
+	var matched []config.Host
+	for _, h := range pool {
+		if strings.HasPrefix(strings.ToLower(h.Alias), query) ||
+			strings.HasPrefix(strings.ToLower(h.Hostname), query) {
+			matched = append(matched, h)
+		}
+	}
+	return matched
+}
+
 // View renders the current TUI display.
 func (m Model) View() string {
 	if m.mode == modeEdit {
 		return renderEditForm(m)
 	}
+	if m.mode == modeHelp {
+		return renderHelp(m)
+	}
+	if m.mode == modeGroupFilter {
+		return renderGroupFilter(m)
+	}
+	if m.showSessions {
+		return renderSessions(m)
+	}
+	if m.picker != nil {
+		return renderPicker(m)
+	}
+	if m.rawBlock != nil {
+		return renderRawBlock(m)
+	}
+	if m.quickAdd != nil {
+		return renderQuickAdd(m)
+	}
 	header := renderHeader(m)
+	if bar := renderFavorites(m); bar != "" {
+		header += "\n" + bar
+	}
 	list := renderList(m)
+	if m.inline {
+		// Keep the frame the same height whatever the filter shows, so the
+		// inline renderer never leaves stale lines behind.
+		if pad := m.viewHeight + 1 - strings.Count(list, "\n") - 1; pad > 0 {
+			list += strings.Repeat("\n", pad)
+		}
+	}
 	statusBar := renderStatusBar(m)
+	if preview := renderPreview(m); preview != "" {
+		statusBar = preview + "\n" + statusBar
+	}
+	if m.showLegend {
+		statusBar = renderLegend(m) + "\n" + statusBar
+	}
 	return header + "\n" + list + "\n" + statusBar
 }
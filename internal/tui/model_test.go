@@ -1,10 +1,24 @@
 package tui
 
 import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
 	"testing"
+	"time"
+	"unicode/utf8"
 
 	tea "github.com/charmbracelet/bubbletea"
+	"github.com/charmbracelet/lipgloss"
+	"github.com/srava/swiftssh/internal/audit"
 	"github.com/srava/swiftssh/internal/config"
+	"github.com/srava/swiftssh/internal/platform"
+	"github.com/srava/swiftssh/internal/ssh"
 	"github.com/srava/swiftssh/internal/state"
 )
 
@@ -570,6 +584,70 @@ func TestEditMode_TextInput(t *testing.T) {
 	}
 }
 
+// TestEditMode_CtrlRRevertsActiveField tests that Ctrl+R restores only the
+// active field to its original value, leaving other edits in place.
+func TestEditMode_CtrlRRevertsActiveField(t *testing.T) {
+	hosts := makeHostsWithLine("alpha")
+	st := makeState(make(map[string]int))
+	m := New(hosts, st, "/tmp/state.json", false)
+	m = pressCtrlE(m)
+	origHostname := m.edit.fields[fieldHostname]
+
+	// Edit the alias, then the hostname.
+	m = pressCtrlU(m)
+	m = pressKey(m, "renamed")
+	m = pressSpecialKey(m, tea.KeyDown)
+	m = pressCtrlU(m)
+	m = pressKey(m, "10.9.9.9")
+
+	m = pressSpecialKey(m, tea.KeyCtrlR)
+	if m.edit.fields[fieldHostname] != origHostname {
+		t.Errorf("after Ctrl+R: expected hostname %q, got %q", origHostname, m.edit.fields[fieldHostname])
+	}
+	if m.edit.fields[fieldAlias] != "renamed" {
+		t.Errorf("Ctrl+R should leave other fields edited, alias = %q", m.edit.fields[fieldAlias])
+	}
+	if m.mode != modeEdit {
+		t.Errorf("Ctrl+R should keep the form open, mode = %v", m.mode)
+	}
+
+	m = pressSpecialKey(m, tea.KeyUp)
+	m = pressSpecialKey(m, tea.KeyCtrlR)
+	if m.edit.dirty() {
+		t.Error("form should be clean after reverting every edited field")
+	}
+}
+
+// TestEditMode_IdentityFileList tests that the IdentityFile field shows every
+// key comma-separated and saves the edited list back in order.
+func TestEditMode_IdentityFileList(t *testing.T) {
+	hosts := makeHostsWithLine("alpha")
+	hosts[0].IdentityFile = "~/.ssh/a"
+	hosts[0].IdentityFiles = []string{"~/.ssh/a", "~/.ssh/b"}
+	st := makeState(make(map[string]int))
+	m := New(hosts, st, "/tmp/state.json", false)
+	m = pressCtrlE(m)
+
+	if got := m.edit.fields[fieldIdentityFile]; got != "~/.ssh/a, ~/.ssh/b" {
+		t.Errorf("IdentityFile field = %q; want both keys", got)
+	}
+
+	m.edit.fields[fieldIdentityFile] = " ~/.ssh/c , ~/.ssh/a,, "
+	h, msg := m.edit.host()
+	if msg != "" {
+		t.Fatalf("unexpected validation error: %s", msg)
+	}
+	if h.IdentityFile != "~/.ssh/c" || strings.Join(h.IdentityFiles, "|") != "~/.ssh/c|~/.ssh/a" {
+		t.Errorf("saved IdentityFile=%q IdentityFiles=%q", h.IdentityFile, h.IdentityFiles)
+	}
+
+	m.edit.fields[fieldIdentityFile] = ""
+	h, _ = m.edit.host()
+	if h.Identities() != nil {
+		t.Errorf("cleared field should leave no keys, got %q", h.Identities())
+	}
+}
+
 // TestEditMode_ValidationEmptyAlias tests that saving with an empty alias shows an error.
 func TestEditMode_ValidationEmptyAlias(t *testing.T) {
 	hosts := makeHostsWithLine("alpha")
@@ -836,3 +914,2474 @@ func TestNormalMode_EscQuits(t *testing.T) {
 		t.Errorf("Expected tea.QuitMsg, got %T", msg)
 	}
 }
+
+// TestNoHistory_ConnectDoesNotTouchState verifies that with NoHistory set,
+// connecting neither increments counts nor creates the state file.
+func TestNoHistory_ConnectDoesNotTouchState(t *testing.T) {
+	hosts := makeHosts("alpha", "beta")
+	st := makeState(map[string]int{"beta": 3})
+	statePath := filepath.Join(t.TempDir(), "swiftssh", "state.json")
+	m := NewWithOptions(hosts, st, statePath, Options{NoHistory: true})
+
+	_, cmd := connectToSelected(m)
+	if cmd == nil {
+		t.Fatal("expected an ssh exec command, got nil")
+	}
+
+	if st.Connections["alpha"] != 0 {
+		t.Errorf("expected alpha count to stay 0, got %d", st.Connections["alpha"])
+	}
+	if _, err := os.Stat(statePath); !os.IsNotExist(err) {
+		t.Errorf("expected no state file at %s, stat err = %v", statePath, err)
+	}
+}
+
+// TestNoHistory_ImpliesAlphabeticalOrder verifies NoHistory ignores connection counts.
+func TestNoHistory_ImpliesAlphabeticalOrder(t *testing.T) {
+	hosts := makeHosts("gamma", "alpha", "beta")
+	st := makeState(map[string]int{"gamma": 10})
+	m := NewWithOptions(hosts, st, "/tmp/state.json", Options{NoHistory: true})
+
+	if m.allHosts[0].Alias != "alpha" {
+		t.Errorf("expected allHosts[0]=alpha, got %s", m.allHosts[0].Alias)
+	}
+	if !m.noFrequent {
+		t.Error("expected NoHistory to imply noFrequent")
+	}
+}
+
+// TestEditMode_TabNavigation tests that Tab/Shift+Tab cycle fields and wrap like ↓/↑.
+func TestEditMode_TabNavigation(t *testing.T) {
+	hosts := makeHostsWithLine("alpha")
+	st := makeState(make(map[string]int))
+	m := New(hosts, st, "/tmp/state.json", false)
+	m = pressCtrlE(m)
+
+	m = pressSpecialKey(m, tea.KeyTab)
+	if m.edit.activeField != fieldHostname {
+		t.Errorf("after Tab: expected fieldHostname, got %d", m.edit.activeField)
+	}
+
+	m = pressSpecialKey(m, tea.KeyShiftTab)
+	if m.edit.activeField != fieldAlias {
+		t.Errorf("after Shift+Tab: expected fieldAlias, got %d", m.edit.activeField)
+	}
+
+	// Shift+Tab wraps from the first field to the last
+	m = pressSpecialKey(m, tea.KeyShiftTab)
+	if m.edit.activeField != fieldCount-1 {
+		t.Errorf("after Shift+Tab wrap: expected last field, got %d", m.edit.activeField)
+	}
+
+	// Tab wraps from the last field back to the first
+	m = pressSpecialKey(m, tea.KeyTab)
+	if m.edit.activeField != fieldAlias {
+		t.Errorf("after Tab wrap: expected fieldAlias, got %d", m.edit.activeField)
+	}
+}
+
+// TestConnectDetached_SpawnsInTmux verifies Ctrl+O launches via the detected
+// multiplexer, records the connection, and keeps the TUI running.
+func TestConnectDetached_SpawnsInTmux(t *testing.T) {
+	hosts := makeHosts("alpha")
+	st := makeState(make(map[string]int))
+	m := New(hosts, st, filepath.Join(t.TempDir(), "state.json"), false)
+	m.getenv = func(k string) string {
+		if k == "TMUX" {
+			return "/tmp/tmux-1000/default,1,0"
+		}
+		return ""
+	}
+	m.goos = "linux"
+	var launched []string
+	m.spawn = func(name string, args ...string) (string, error) {
+		launched = append([]string{name}, args...)
+		return "@1\n", nil
+	}
+
+	newModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlO})
+	m = newModel.(Model)
+
+	if cmd != nil {
+		t.Error("expected no blocking exec command for a detached launch")
+	}
+	if len(launched) == 0 || launched[0] != "tmux" {
+		t.Fatalf("expected tmux to be launched, got %v", launched)
+	}
+	if st.Connections["alpha"] != 1 {
+		t.Errorf("expected connection to be recorded, got count %d", st.Connections["alpha"])
+	}
+}
+
+// TestConnectDetached_UntrackedLauncherNotListed verifies that a screen window,
+// which cannot be followed once opened, is not added to the sessions panel.
+func TestConnectDetached_UntrackedLauncherNotListed(t *testing.T) {
+	m := New(makeHosts("alpha"), makeState(make(map[string]int)), filepath.Join(t.TempDir(), "state.json"), false)
+	m.getenv = func(k string) string {
+		if k == "STY" {
+			return "1234.pts-0"
+		}
+		return ""
+	}
+	m.goos = "linux"
+	m.spawn = func(string, ...string) (string, error) { return "", nil }
+
+	m = pressSpecialKey(m, tea.KeyCtrlO)
+	if len(m.sessions.List()) != 0 {
+		t.Errorf("expected no tracked session for screen, got %v", m.sessions.List())
+	}
+	if !strings.Contains(m.statusMsg, "screen") {
+		t.Errorf("statusMsg = %q; want it to name screen", m.statusMsg)
+	}
+}
+
+// TestConnectDetached_FallsBackWithoutLauncher verifies Ctrl+O falls back to a
+// blocking connect with a status hint when no launcher is detected.
+func TestConnectDetached_FallsBackWithoutLauncher(t *testing.T) {
+	hosts := makeHosts("alpha")
+	st := makeState(make(map[string]int))
+	m := New(hosts, st, filepath.Join(t.TempDir(), "state.json"), false)
+	m.getenv = func(string) string { return "" }
+	m.goos = "linux"
+	m.spawn = func(string, ...string) (string, error) {
+		t.Error("spawn should not be called without a launcher")
+		return "", nil
+	}
+
+	newModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlO})
+	m = newModel.(Model)
+
+	if cmd == nil {
+		t.Error("expected blocking exec command as fallback")
+	}
+	if m.statusMsg == "" {
+		t.Error("expected a status hint about the fallback")
+	}
+}
+
+// TestTruncateStr_MultibyteSafe verifies truncation never splits a rune and
+// measures display width rather than bytes.
+func TestTruncateStr_MultibyteSafe(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		maxW int
+		want string
+	}{
+		{"fits unchanged", "héllo", 5, "héllo"},
+		{"ascii truncated", "production", 5, "prod…"},
+		{"cut would split é", "caféteria", 4, "caf…"},
+		{"wide runes", "サーバー本番", 5, "サー…"},
+		{"width one", "ñandú", 1, "…"},
+		{"zero width", "abc", 0, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := truncateStr(tt.in, tt.maxW)
+			if !utf8.ValidString(got) {
+				t.Fatalf("truncateStr(%q, %d) produced invalid UTF-8: %q", tt.in, tt.maxW, got)
+			}
+			if got != tt.want {
+				t.Errorf("truncateStr(%q, %d) = %q; want %q", tt.in, tt.maxW, got, tt.want)
+			}
+		})
+	}
+}
+
+// TestPadRight_DisplayWidth verifies padding counts display cells, not bytes.
+func TestPadRight_DisplayWidth(t *testing.T) {
+	if got := padRight("caf…", 6); got != "caf…  " {
+		t.Errorf("padRight = %q; want %q", got, "caf…  ")
+	}
+}
+
+// TestLegend_ListsDistinctGroupsSorted verifies the legend shows each group once,
+// in alphabetical order, and that Ctrl+G toggles it.
+func TestLegend_ListsDistinctGroupsSorted(t *testing.T) {
+	hosts := []config.Host{
+		{Alias: "a", Hostname: "a.example.com", Port: "22", Groups: []string{"Work", "DevOps"}},
+		{Alias: "b", Hostname: "b.example.com", Port: "22", Groups: []string{"home", "Work"}},
+		{Alias: "c", Hostname: "c.example.com", Port: "22", Groups: []string{"DevOps"}},
+		{Alias: "d", Hostname: "d.example.com", Port: "22"},
+	}
+	m := New(hosts, makeState(make(map[string]int)), "/tmp/state.json", false)
+
+	got := legendGroups(m)
+	want := []string{"DevOps", "home", "Work"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("legendGroups = %v; want %v", got, want)
+	}
+
+	if strings.Contains(m.View(), "Groups:") {
+		t.Error("legend should be hidden by default")
+	}
+	newModel, _ := m.Update(tea.KeyMsg{Type: tea.KeyCtrlG})
+	m = newModel.(Model)
+	view := m.View()
+	for _, g := range want {
+		if strings.Count(view, " "+g) < 1 {
+			t.Errorf("expected legend to mention %q", g)
+		}
+	}
+	legend := renderLegend(m)
+	if strings.Count(legend, "Work") != 1 {
+		t.Errorf("expected Work exactly once in legend, got %q", legend)
+	}
+}
+
+// TestGroupColor_Deterministic verifies the same group always maps to the same color.
+func TestGroupColor_Deterministic(t *testing.T) {
+	if groupColor("Work") != groupColor("Work") {
+		t.Error("groupColor should be deterministic")
+	}
+	if groupColor("Work") != groupColor("work") {
+		t.Error("groupColor should be case-insensitive")
+	}
+}
+
+// TestConnectArgs_ConnectByMode verifies that hostname mode targets user@hostname
+// and passes the IdentityFile, while the default alias mode targets the alias.
+func TestConnectArgs_ConnectByMode(t *testing.T) {
+	hosts := []config.Host{{Alias: "prod", Hostname: "10.0.0.5", User: "deploy", Port: "22", IdentityFile: "/keys/id_prod"}}
+	st := makeState(make(map[string]int))
+
+	byAlias := New(hosts, st, "/tmp/state.json", false)
+	if args := connectArgs(byAlias, byAlias.filtered[0]); args[len(args)-1] != "prod" {
+		t.Errorf("alias mode: expected target 'prod', got %v", args)
+	}
+
+	byHostname := NewWithOptions(hosts, st, "/tmp/state.json", Options{ConnectBy: ConnectByHostname})
+	args := connectArgs(byHostname, byHostname.filtered[0])
+	if args[len(args)-1] != "deploy@10.0.0.5" {
+		t.Errorf("hostname mode: expected target 'deploy@10.0.0.5', got %v", args)
+	}
+	for _, a := range args {
+		if a == "prod" {
+			t.Errorf("hostname mode should not target the alias, got %v", args)
+		}
+	}
+	if len(args) < 2 || args[0] != "-i" || args[1] != "/keys/id_prod" {
+		t.Errorf("hostname mode: expected -i /keys/id_prod, got %v", args)
+	}
+}
+
+// TestRowMarker_InsecureIdentityFile verifies rows whose key is group/other
+// readable are flagged with "!".
+func TestRowMarker_InsecureIdentityFile(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("permission bits are not enforced on Windows")
+	}
+	dir := t.TempDir()
+	openKey := filepath.Join(dir, "open")
+	safeKey := filepath.Join(dir, "safe")
+	if err := os.WriteFile(openKey, []byte("k"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Chmod(openKey, 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(safeKey, []byte("k"), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	hosts := []config.Host{
+		{Alias: "a-open", Hostname: "a", Port: "22", IdentityFile: openKey},
+		{Alias: "b-safe", Hostname: "b", Port: "22", IdentityFile: safeKey},
+	}
+	m := New(hosts, makeState(make(map[string]int)), "/tmp/state.json", false)
+
+	if got := rowMarker(m, m.filtered[0]); got != "!" {
+		t.Errorf("expected '!' marker for insecure key, got %q", got)
+	}
+	if got := rowMarker(m, m.filtered[1]); got != " " {
+		t.Errorf("expected no marker for safe key, got %q", got)
+	}
+	if row := renderRow(m, 0, 10, 10, 4, 0); !strings.Contains(row, ">!") {
+		t.Errorf("expected selected insecure row to start with '>!', got %q", row)
+	}
+}
+
+// TestSuggestHostname tests completion selection across prefixes.
+func TestSuggestHostname(t *testing.T) {
+	candidates := []string{"web1.example.com", "web10.example.com", "db.example.com", "Web2.example.com"}
+	tests := []struct {
+		prefix string
+		want   string
+	}{
+		{"", ""},
+		{"web", "Web2.example.com"}, // shortest; tie with web1 broken alphabetically ("W" < "w")
+		{"web1", "web1.example.com"},
+		{"web10", "web10.example.com"},
+		{"DB", "db.example.com"},
+		{"db.example.com", ""}, // already complete
+		{"cache", ""},
+	}
+	for _, tt := range tests {
+		if got := suggestHostname(tt.prefix, candidates); got != tt.want {
+			t.Errorf("suggestHostname(%q) = %q; want %q", tt.prefix, got, tt.want)
+		}
+	}
+}
+
+// TestSuggestionHint tests the ghosted completion text, including matches
+// that differ from the typed value in case or byte length.
+func TestSuggestionHint(t *testing.T) {
+	tests := []struct {
+		value, suggestion, want string
+	}{
+		{"web", "web1.example.com", "1.example.com"},
+		{"WEB", "web1.example.com", " → web1.example.com"},
+		{"İ", "i̇zmir.example.com", " → i̇zmir.example.com"},
+		{"much-longer-than-it", "much", " → much"},
+	}
+	for _, tt := range tests {
+		if got := suggestionHint(tt.value, tt.suggestion); got != tt.want {
+			t.Errorf("suggestionHint(%q, %q) = %q; want %q", tt.value, tt.suggestion, got, tt.want)
+		}
+	}
+}
+
+// TestEditMode_TabAcceptsHostnameSuggestion verifies the ghosted suggestion is
+// rendered and Tab accepts it instead of moving to the next field.
+func TestEditMode_TabAcceptsHostnameSuggestion(t *testing.T) {
+	hosts := makeHostsWithLine("alpha", "beta")
+	m := New(hosts, makeState(make(map[string]int)), "/tmp/state.json", false)
+	m = pressCtrlE(m)
+	m = pressSpecialKey(m, tea.KeyDown) // Hostname field
+	m = pressCtrlU(m)
+	m = pressKey(m, "b")
+
+	if view := m.View(); !strings.Contains(view, "eta.example.com") {
+		t.Errorf("expected ghosted completion in view, got:\n%s", view)
+	}
+
+	m = pressSpecialKey(m, tea.KeyTab)
+	if m.edit.fields[fieldHostname] != "beta.example.com" {
+		t.Errorf("expected Tab to accept suggestion, got %q", m.edit.fields[fieldHostname])
+	}
+	if m.edit.activeField != fieldHostname {
+		t.Errorf("expected to stay on Hostname after accepting, got %d", m.edit.activeField)
+	}
+
+	// With nothing left to complete, Tab navigates as usual.
+	m = pressSpecialKey(m, tea.KeyTab)
+	if m.edit.activeField != fieldUser {
+		t.Errorf("expected Tab to advance to User, got %d", m.edit.activeField)
+	}
+}
+
+// TestEnterActionEdit_EnterOpensEditForm verifies that with EnterAction=edit,
+// Enter opens the edit form instead of connecting and Ctrl+E connects.
+func TestEnterActionEdit_EnterOpensEditForm(t *testing.T) {
+	hosts := makeHostsWithLine("alpha")
+	st := makeState(make(map[string]int))
+	m := NewWithOptions(hosts, st, filepath.Join(t.TempDir(), "state.json"), Options{EnterAction: EnterActionEdit})
+
+	newModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = newModel.(Model)
+	if cmd != nil {
+		t.Error("Enter should not return a connect command in edit mode")
+	}
+	if m.mode != modeEdit {
+		t.Errorf("expected modeEdit after Enter, got %d", m.mode)
+	}
+	if st.Connections["alpha"] != 0 {
+		t.Error("Enter should not record a connection")
+	}
+
+	m = pressSpecialKey(m, tea.KeyEsc)
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyCtrlE})
+	if cmd == nil {
+		t.Error("expected Ctrl+E to connect when Enter edits")
+	}
+	if st.Connections["alpha"] != 1 {
+		t.Errorf("expected Ctrl+E to record a connection, got %d", st.Connections["alpha"])
+	}
+}
+
+// TestLimit_ShowsMoreRowAndExpands verifies that --limit truncates the list
+// with a "more" row, that "+" widens the window, and that search still
+// matches hosts beyond the limit.
+func TestLimit_ShowsMoreRowAndExpands(t *testing.T) {
+	hosts := makeHosts("h01", "h02", "h03", "h04", "h05", "h06", "h07", "h08", "h09", "h10", "h11", "h12")
+	m := NewWithOptions(hosts, makeState(make(map[string]int)), "/tmp/state.json", Options{Limit: 5})
+	m.viewHeight = 20
+
+	view := renderList(m)
+	for _, alias := range []string{"h01", "h05"} {
+		if !strings.Contains(view, alias) {
+			t.Errorf("expected %q in limited view", alias)
+		}
+	}
+	if strings.Contains(view, "h06") {
+		t.Error("h06 should be hidden by the limit")
+	}
+	if !strings.Contains(view, "… and 7 more (press +)") {
+		t.Errorf("expected more row, got:\n%s", view)
+	}
+	if got := strings.Count(view, "\n") + 1; got != 7 {
+		t.Errorf("expected header, 5 hosts and the more row, got %d lines", got)
+	}
+
+	for i := 0; i < 10; i++ {
+		m = pressSpecialKey(m, tea.KeyDown)
+	}
+	if m.cursor >= 5 {
+		t.Errorf("cursor should stay within the visible window, got %d", m.cursor)
+	}
+
+	m = pressKey(m, "+")
+	if m.mode != modeNormal {
+		t.Fatal("+ should expand the list, not start a search")
+	}
+	view = renderList(m)
+	if !strings.Contains(view, "h10") || !strings.Contains(view, "… and 2 more") {
+		t.Errorf("expected window of 10 after +, got:\n%s", view)
+	}
+
+	m = pressKey(m, "+")
+	if strings.Contains(renderList(m), "more (press +)") {
+		t.Error("more row should disappear once all hosts are shown")
+	}
+
+	m = NewWithOptions(hosts, makeState(make(map[string]int)), "/tmp/state.json", Options{Limit: 5})
+	for _, r := range "h12" {
+		m = pressKey(m, string(r))
+	}
+	if len(m.filtered) != 1 || m.filtered[0].Alias != "h12" {
+		t.Fatalf("search should match hosts hidden by the limit, got %v", m.filtered)
+	}
+	if strings.Contains(renderList(m), "more (press +)") {
+		t.Error("search results should not be truncated")
+	}
+}
+
+func TestCommonDomainSuffix(t *testing.T) {
+	cases := []struct {
+		name      string
+		hostnames []string
+		want      string
+	}{
+		{"majority shares suffix", []string{"web.example.com", "db.prod.example.com", "pi.local"}, ".example.com"},
+		{"longest majority suffix", []string{"a.prod.example.com", "b.prod.example.com", "c.dev.example.com"}, ".prod.example.com"},
+		{"no majority", []string{"web.example.com", "db.other.org"}, ""},
+		{"case-insensitive", []string{"Web.Example.COM", "db.example.com"}, ".example.com"},
+		{"ips ignored", []string{"10.0.0.1", "192.168.1.5", "web.example.com"}, ".example.com"},
+		{"bare domain is not stripped to nothing", []string{"example.com", "example.com"}, ""},
+		{"empty", nil, ""},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := commonDomainSuffix(tc.hostnames); got != tc.want {
+				t.Errorf("commonDomainSuffix(%v) = %q, want %q", tc.hostnames, got, tc.want)
+			}
+		})
+	}
+}
+
+// TestToggleDomainStrip_DisplayOnly verifies Ctrl+T abbreviates hostnames in
+// the list while leaving the host data untouched.
+func TestToggleDomainStrip_DisplayOnly(t *testing.T) {
+	hosts := []config.Host{
+		{Alias: "web", Hostname: "web.example.com"},
+		{Alias: "db", Hostname: "db.example.com"},
+		{Alias: "pi", Hostname: "pi.local"},
+	}
+	m := NewWithOptions(hosts, makeState(make(map[string]int)), "/tmp/state.json", Options{})
+
+	if strings.Contains(renderList(m), "web…") {
+		t.Fatal("hostnames should be shown in full by default")
+	}
+
+	m = pressSpecialKey(m, tea.KeyCtrlT)
+	view := renderList(m)
+	if !strings.Contains(view, "web…") || strings.Contains(view, "web.example.com") {
+		t.Errorf("expected stripped hostname, got:\n%s", view)
+	}
+	if !strings.Contains(view, "pi.local") {
+		t.Error("hostnames without the suffix should be shown in full")
+	}
+	for _, h := range m.allHosts {
+		if h.Alias == "web" && h.Hostname != "web.example.com" {
+			t.Errorf("stripping must not modify host data, got %q", h.Hostname)
+		}
+	}
+
+	m = pressSpecialKey(m, tea.KeyCtrlT)
+	if !strings.Contains(renderList(m), "web.example.com") {
+		t.Error("second Ctrl+T should restore full hostnames")
+	}
+}
+
+// TestPreview_ReflectsSelectedHost verifies the command preview shows the
+// selected host's port, user and identity and follows the cursor.
+func TestPreview_ReflectsSelectedHost(t *testing.T) {
+	hosts := []config.Host{
+		{Alias: "alpha", Hostname: "10.0.0.1", User: "alice", Port: "2222"},
+		{Alias: "beta", Hostname: "10.0.0.2", User: "bob", IdentityFile: "/keys/id_beta"},
+	}
+	st := makeState(make(map[string]int))
+
+	off := NewWithOptions(hosts, st, "/tmp/state.json", Options{})
+	if strings.Contains(off.View(), "$ ssh") {
+		t.Error("preview should be hidden unless enabled")
+	}
+
+	m := NewWithOptions(hosts, st, "/tmp/state.json", Options{Preview: true})
+	if got, want := renderPreview(m), "$ ssh -p 2222 -l alice alpha"; !strings.Contains(got, want) {
+		t.Errorf("expected preview %q, got %q", want, got)
+	}
+
+	m = pressSpecialKey(m, tea.KeyDown)
+	if got, want := renderPreview(m), "$ ssh -l bob beta"; !strings.Contains(got, want) {
+		t.Errorf("expected preview to follow cursor: want %q, got %q", want, got)
+	}
+
+	m = NewWithOptions(hosts, st, "/tmp/state.json", Options{Preview: true, ConnectBy: ConnectByHostname})
+	m = pressSpecialKey(m, tea.KeyDown)
+	if got, want := renderPreview(m), "$ ssh -i /keys/id_beta bob@10.0.0.2"; !strings.Contains(got, want) {
+		t.Errorf("expected identity in hostname-mode preview: want %q, got %q", want, got)
+	}
+	if !strings.Contains(m.View(), "$ ssh") {
+		t.Error("View should include the preview line")
+	}
+}
+
+// TestSSHExit_CursorReturnsToLastConnected verifies that when the ssh session
+// ends the list is re-ranked and the cursor sits on the host just used.
+func TestSSHExit_CursorReturnsToLastConnected(t *testing.T) {
+	hosts := makeHosts("alpha", "beta", "gamma")
+	st := makeState(map[string]int{"alpha": 5})
+	m := New(hosts, st, filepath.Join(t.TempDir(), "state.json"), false)
+
+	m = pressSpecialKey(m, tea.KeyDown)
+	m = pressSpecialKey(m, tea.KeyDown)
+	if m.filtered[m.cursor].Alias != "gamma" {
+		t.Fatalf("expected cursor on gamma, got %s", m.filtered[m.cursor].Alias)
+	}
+	newModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = newModel.(Model)
+	if cmd == nil {
+		t.Fatal("expected Enter to return a connect command")
+	}
+
+	newModel, _ = m.Update(sshExitMsg{})
+	m = newModel.(Model)
+
+	if got := m.filtered[m.cursor].Alias; got != "gamma" {
+		t.Errorf("expected cursor on gamma after ssh exit, got %s", got)
+	}
+	if m.cursor != 1 {
+		t.Errorf("expected gamma to float up to index 1, got %d", m.cursor)
+	}
+}
+
+func TestStartsSearch(t *testing.T) {
+	cases := []struct {
+		name  string
+		runes []rune
+		want  bool
+	}{
+		{"letter", []rune("j"), true},
+		{"digit", []rune("7"), true},
+		{"pasted phrase", []rune("web 01"), true},
+		{"space", []rune(" "), false},
+		{"tab", []rune("\t"), false},
+		{"control", []rune("\x1b"), false},
+		{"letter then control", []rune("a\x07"), false},
+		{"empty", nil, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			if got := startsSearch(tc.runes); got != tc.want {
+				t.Errorf("startsSearch(%q) = %v, want %v", string(tc.runes), got, tc.want)
+			}
+		})
+	}
+}
+
+// TestNormalMode_SpaceDoesNotStartSearch verifies a lone space is ignored
+// while a printable letter still enters search mode.
+func TestNormalMode_SpaceDoesNotStartSearch(t *testing.T) {
+	m := New(makeHosts("alpha", "beta"), makeState(make(map[string]int)), "/tmp/state.json", false)
+
+	for _, msg := range []tea.KeyMsg{
+		{Type: tea.KeyRunes, Runes: []rune{' '}},
+		{Type: tea.KeySpace, Runes: []rune{' '}},
+	} {
+		newModel, _ := m.Update(msg)
+		if got := newModel.(Model); got.mode != modeNormal || got.searchQuery != "" {
+			t.Errorf("space (%v) should not start a search, got mode %d query %q", msg.Type, got.mode, got.searchQuery)
+		}
+	}
+
+	m = pressKey(m, "b")
+	if m.mode != modeSearch || m.searchQuery != "b" {
+		t.Errorf("expected 'b' to start search, got mode %d query %q", m.mode, m.searchQuery)
+	}
+}
+
+// TestConnect_WritesConnectionLog verifies connecting appends a parseable
+// log line, and that an unwritable log never blocks the connection.
+func TestConnect_WritesConnectionLog(t *testing.T) {
+	dir := t.TempDir()
+	hosts := []config.Host{{Alias: "prod", Hostname: "10.0.0.1", User: "deploy", IdentityFile: "/keys/id"}}
+	logPath := filepath.Join(dir, "connections.log")
+	m := NewWithOptions(hosts, makeState(make(map[string]int)), filepath.Join(dir, "state.json"), Options{LogPath: logPath})
+
+	if _, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter}); cmd == nil {
+		t.Fatal("expected a connect command")
+	}
+	entries, err := audit.ReadLog(logPath)
+	if err != nil || len(entries) != 1 {
+		t.Fatalf("expected 1 log entry, got %d (err %v)", len(entries), err)
+	}
+	if e := entries[0]; e.Alias != "prod" || e.Hostname != "10.0.0.1" || e.User != "deploy" || e.IdentityFile != "/keys/id" || e.Time.IsZero() {
+		t.Errorf("unexpected log entry: %+v", e)
+	}
+
+	blocker := filepath.Join(dir, "not-a-dir")
+	if err := os.WriteFile(blocker, nil, 0600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	m = NewWithOptions(hosts, makeState(make(map[string]int)), filepath.Join(dir, "state.json"), Options{LogPath: filepath.Join(blocker, "connections.log")})
+	if _, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter}); cmd == nil {
+		t.Error("a failing connection log must not prevent connecting")
+	}
+}
+
+// TestSearch_TypoFallback verifies a query with no subsequence match still
+// finds near-miss aliases, while subsequence matches skip the fallback.
+func TestSearch_TypoFallback(t *testing.T) {
+	m := New(makeHosts("prod", "staging", "pi"), makeState(make(map[string]int)), "/tmp/state.json", false)
+
+	for _, r := range "pord" {
+		m = pressKey(m, string(r))
+	}
+	if len(m.filtered) != 1 || m.filtered[0].Alias != "prod" {
+		t.Errorf("expected typo fallback to find prod, got %v", m.filtered)
+	}
+
+	m = pressSpecialKey(m, tea.KeyEsc)
+	for _, r := range "pi" {
+		m = pressKey(m, string(r))
+	}
+	for _, h := range m.filtered {
+		if h.Alias == "staging" {
+			t.Errorf("subsequence results should not include typo matches, got %v", m.filtered)
+		}
+	}
+}
+
+// TestSessionsPanel_TracksAndKillsDetachedLaunch verifies Ctrl+O registers the
+// tmux window it opened, Ctrl+A lists it, Enter focuses that window, and
+// Ctrl+K closes and forgets it.
+func TestSessionsPanel_TracksAndKillsDetachedLaunch(t *testing.T) {
+	m := New(makeHosts("alpha"), makeState(make(map[string]int)), filepath.Join(t.TempDir(), "state.json"), false)
+	m.getenv = func(k string) string {
+		if k == "TMUX" {
+			return "/tmp/tmux-1000/default,1,0"
+		}
+		return ""
+	}
+	m.goos = "linux"
+	open := map[string]bool{"@7": true}
+	m.sessions = ssh.NewRegistry(func(id string) bool { return open[id] })
+	var ran [][]string
+	m.spawn = func(name string, args ...string) (string, error) {
+		ran = append(ran, append([]string{name}, args...))
+		return "@7\n", nil
+	}
+
+	m = pressSpecialKey(m, tea.KeyCtrlO)
+	m = pressSpecialKey(m, tea.KeyCtrlA)
+	if !m.showSessions {
+		t.Fatal("expected Ctrl+A to open the sessions panel")
+	}
+	view := m.View()
+	if !strings.Contains(view, "alpha") || !strings.Contains(view, "@7") {
+		t.Errorf("expected alpha with its window ID in the panel, got:\n%s", view)
+	}
+
+	m = pressSpecialKey(m, tea.KeyEnter)
+	if last := ran[len(ran)-1]; strings.Join(last, " ") != "tmux select-window -t @7" {
+		t.Errorf("expected Enter to focus the tmux window, got %v", last)
+	}
+
+	m = pressSpecialKey(m, tea.KeyCtrlA)
+	m = pressSpecialKey(m, tea.KeyCtrlK)
+	if last := ran[len(ran)-1]; strings.Join(last, " ") != "tmux kill-window -t @7" {
+		t.Errorf("expected Ctrl+K to kill the tmux window, got %v", last)
+	}
+	if len(m.sessions.List()) != 0 {
+		t.Errorf("expected killed session to be removed, got %v", m.sessions.List())
+	}
+
+	// A window closed from inside tmux drops out of the panel.
+	m = pressSpecialKey(m, tea.KeyEsc)
+	m = pressSpecialKey(m, tea.KeyCtrlO)
+	delete(open, "@7")
+	m = pressSpecialKey(m, tea.KeyCtrlA)
+	if view := m.View(); strings.Contains(view, "@7") {
+		t.Errorf("expected the closed window to be pruned, got:\n%s", view)
+	}
+
+	m = pressSpecialKey(m, tea.KeyEsc)
+	if m.showSessions || m.mode != modeNormal {
+		t.Error("Esc should close the panel without quitting")
+	}
+}
+
+// TestIdentityPicker_SaveWritesIdentityFile verifies Ctrl+S in the picker
+// writes the chosen key to the config and to allHosts, then connects.
+func TestIdentityPicker_SaveWritesIdentityFile(t *testing.T) {
+	dir := t.TempDir()
+	keyDir := filepath.Join(dir, "keys")
+	if err := os.MkdirAll(keyDir, 0700); err != nil {
+		t.Fatalf("MkdirAll failed: %v", err)
+	}
+	key := filepath.Join(keyDir, "id_work")
+	for _, p := range []string{key, key + ".pub"} {
+		if err := os.WriteFile(p, []byte("key"), 0600); err != nil {
+			t.Fatalf("WriteFile failed: %v", err)
+		}
+	}
+	configPath := filepath.Join(dir, "config")
+	if err := os.WriteFile(configPath, []byte("Host alpha\n    Hostname alpha.example.com\n\nHost beta\n    Hostname beta.example.com\n"), 0600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	hosts, err := config.Parse(configPath)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+
+	m := New(hosts, makeState(make(map[string]int)), filepath.Join(dir, "state.json"), false)
+	m.keyDir = keyDir
+
+	m = pressSpecialKey(m, tea.KeyCtrlK)
+	if m.picker == nil || len(m.picker.keys) != 1 {
+		t.Fatalf("expected picker with one key, got %+v", m.picker)
+	}
+	if !strings.Contains(m.View(), "id_work") {
+		t.Errorf("expected key in picker view, got:\n%s", m.View())
+	}
+
+	newModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlS})
+	m = newModel.(Model)
+	if cmd == nil {
+		t.Error("expected Ctrl+S to also connect")
+	}
+	if m.picker != nil {
+		t.Error("picker should close after saving")
+	}
+	if !strings.Contains(m.statusMsg, "Saved IdentityFile") {
+		t.Errorf("expected save confirmation, got %q", m.statusMsg)
+	}
+
+	content, _ := os.ReadFile(configPath)
+	if !strings.Contains(string(content), `IdentityFile "`+key+`"`) {
+		t.Errorf("expected IdentityFile in config, got:\n%s", content)
+	}
+	for _, h := range m.allHosts {
+		if h.Alias == "alpha" && h.IdentityFile != key {
+			t.Errorf("expected allHosts alpha IdentityFile %q, got %q", key, h.IdentityFile)
+		}
+		if h.Alias == "beta" && h.LineStart != 5 {
+			t.Errorf("expected beta LineStart shifted to 5, got %d", h.LineStart)
+		}
+	}
+}
+
+// TestView_TinyTerminalKeepsSelectionVisible verifies that at height 3 the
+// selected host row is still rendered after the terminal shrinks.
+func TestView_TinyTerminalKeepsSelectionVisible(t *testing.T) {
+	m := New(makeHosts("h1", "h2", "h3", "h4", "h5", "h6"), makeState(make(map[string]int)), "/tmp/state.json", false)
+	for i := 0; i < 4; i++ {
+		m = pressSpecialKey(m, tea.KeyDown)
+	}
+
+	newModel, _ := m.Update(tea.WindowSizeMsg{Width: 80, Height: 3})
+	m = newModel.(Model)
+
+	if m.viewHeight < 1 {
+		t.Fatalf("viewHeight must stay at least 1, got %d", m.viewHeight)
+	}
+	list := renderList(m)
+	if !strings.Contains(list, ">") || !strings.Contains(list, "h5") {
+		t.Errorf("expected selected host h5 to be visible, got:\n%s", list)
+	}
+
+	m.viewport = 0 // stale viewport must not hide the cursor either
+	if !strings.Contains(renderList(m), "h5") {
+		t.Error("renderList should scroll to the cursor when the viewport is stale")
+	}
+}
+
+// TestEditMode_SystemHostIsReadOnly verifies hosts from the system config
+// cannot be opened in the editor.
+func TestEditMode_SystemHostIsReadOnly(t *testing.T) {
+	hosts := []config.Host{{Alias: "bastion", Hostname: "bastion.corp", SourceFile: "/etc/ssh/ssh_config", LineStart: 3, ReadOnly: true}}
+	m := New(hosts, makeState(make(map[string]int)), "/tmp/state.json", false)
+
+	m = pressSpecialKey(m, tea.KeyCtrlE)
+	if m.mode != modeNormal || m.edit != nil {
+		t.Error("read-only host must not open the edit form")
+	}
+	if !strings.Contains(m.statusMsg, "read-only system config") {
+		t.Errorf("expected a read-only message, got %q", m.statusMsg)
+	}
+}
+
+// TestEditMode_MultiPatternBlockKeepsSiblings verifies that saving an edit to
+// one alias of a multi-pattern Host line neither rewrites the shared block nor
+// replaces a sibling's entry in the list.
+func TestEditMode_MultiPatternBlockKeepsSiblings(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config")
+	original := "Host web1 web2\n    Hostname web.example.com\n"
+	if err := os.WriteFile(configPath, []byte(original), 0600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	hosts, err := config.Parse(configPath)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	m := New(hosts, makeState(make(map[string]int)), filepath.Join(dir, "state.json"), false)
+	for i, h := range m.filtered {
+		if h.Alias == "web2" {
+			m.cursor = i
+		}
+	}
+
+	m = pressCtrlE(m)
+	m.edit.fields[fieldUser] = "deploy"
+	m = pressSpecialKey(m, tea.KeyEnter)
+
+	if m.edit == nil || !strings.Contains(m.edit.statusMsg, "Save failed") {
+		t.Fatalf("expected the save to be refused, got %+v", m.edit)
+	}
+	if content, _ := os.ReadFile(configPath); string(content) != original {
+		t.Errorf("config must not change, got:\n%s", content)
+	}
+	if got := aliasesOf(m.allHosts); got != "web1,web2" {
+		t.Errorf("allHosts = %s; want web1,web2", got)
+	}
+}
+
+// TestConfirmEdits_ShowsDiffBeforeSaving verifies that with ConfirmEdits the
+// first Enter only shows a diff, Esc returns to the form, and confirming saves.
+func TestConfirmEdits_ShowsDiffBeforeSaving(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "config")
+	original := "Host alpha\n    Hostname old.example.com\n"
+	if err := os.WriteFile(configPath, []byte(original), 0600); err != nil {
+		t.Fatalf("WriteFile failed: %v", err)
+	}
+	hosts, err := config.Parse(configPath)
+	if err != nil {
+		t.Fatalf("Parse failed: %v", err)
+	}
+	m := NewWithOptions(hosts, makeState(make(map[string]int)), filepath.Join(dir, "state.json"), Options{ConfirmEdits: true})
+
+	m = pressSpecialKey(m, tea.KeyCtrlE)
+	m.edit.fields[fieldHostname] = "new.example.com"
+	m = pressSpecialKey(m, tea.KeyEnter)
+
+	if m.edit == nil || m.edit.diff == "" {
+		t.Fatal("expected a pending diff after the first Enter")
+	}
+	view := m.View()
+	if !strings.Contains(view, "- ") || !strings.Contains(view, "old.example.com") || !strings.Contains(view, "new.example.com") {
+		t.Errorf("expected diff of the hostname change, got:\n%s", view)
+	}
+	if content, _ := os.ReadFile(configPath); string(content) != original {
+		t.Error("config must not change before confirmation")
+	}
+
+	m = pressSpecialKey(m, tea.KeyEsc)
+	if m.mode != modeEdit || m.edit.diff != "" {
+		t.Fatal("Esc should return to the form without saving")
+	}
+
+	m = pressSpecialKey(m, tea.KeyEnter)
+	next, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+	m = next.(Model)
+	if cmd != nil || m.mode != modeEdit || m.edit.diff != "" {
+		t.Fatal("Ctrl+C should return to the form like Esc, not quit")
+	}
+
+	m = pressSpecialKey(m, tea.KeyEnter)
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune{'y'}})
+	if cmd == nil {
+		t.Fatal("expected confirming to save")
+	}
+	if content, _ := os.ReadFile(configPath); !strings.Contains(string(content), "new.example.com") {
+		t.Errorf("expected saved hostname, got:\n%s", content)
+	}
+}
+
+// TestAddHostModel_EditAndConfirm tests that the --interactive form edits the
+// synthesized host and returns it on Enter.
+func TestAddHostModel_EditAndConfirm(t *testing.T) {
+	h := config.Host{Alias: "root-10.0.0.9", Hostname: "10.0.0.9", User: "root", Port: "22"}
+	var m tea.Model = newAddHostModel(h)
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlU})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("db")})
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+
+	am := m.(addHostModel)
+	if !am.confirmed {
+		t.Fatal("expected Enter to confirm the host")
+	}
+	if am.result.Alias != "db" || am.result.Hostname != "10.0.0.9" {
+		t.Errorf("result = %+v; want alias db for 10.0.0.9", am.result)
+	}
+	if cmd == nil {
+		t.Error("expected Enter to quit the form")
+	}
+}
+
+// TestAddHostModel_EscDeclines tests that Esc leaves the host unconfirmed and
+// that an invalid form is not confirmed.
+func TestAddHostModel_EscDeclines(t *testing.T) {
+	h := config.Host{Alias: "web", Hostname: "10.0.0.2", Port: "22"}
+	var m tea.Model = newAddHostModel(h)
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyCtrlU})
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	if am := m.(addHostModel); am.confirmed || am.form.statusMsg == "" {
+		t.Error("expected empty alias to be rejected with a status message")
+	}
+
+	m, _ = m.Update(tea.KeyMsg{Type: tea.KeyEsc})
+	if am := m.(addHostModel); am.confirmed || am.cancelled {
+		t.Error("expected Esc to leave the host unconfirmed without cancelling")
+	}
+}
+
+// TestAddHostModel_CtrlCCancels tests that Ctrl+C marks the form cancelled so
+// the caller does not connect.
+func TestAddHostModel_CtrlCCancels(t *testing.T) {
+	var m tea.Model = newAddHostModel(config.Host{Alias: "web", Hostname: "10.0.0.2", Port: "22"})
+	m, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+	if am := m.(addHostModel); !am.cancelled || am.confirmed {
+		t.Errorf("cancelled = %v, confirmed = %v; want cancelled only", am.cancelled, am.confirmed)
+	}
+	if cmd == nil {
+		t.Error("expected Ctrl+C to quit the form")
+	}
+}
+
+// TestHiddenHosts_ExcludedUntilToggled tests that hosts marked Hidden are left
+// out of the list and search until H reveals them.
+func TestHiddenHosts_ExcludedUntilToggled(t *testing.T) {
+	hosts := makeHosts("alpha", "node1", "beta")
+	hosts[1].Hidden = true
+	m := New(hosts, makeState(map[string]int{}), "/tmp/state.json", true)
+
+	if len(m.filtered) != 2 {
+		t.Fatalf("expected 2 listed hosts, got %d", len(m.filtered))
+	}
+	for _, h := range m.filtered {
+		if h.Alias == "node1" {
+			t.Fatal("hidden host should not be listed")
+		}
+	}
+
+	m = pressKey(m, "n")
+	m = pressKey(m, "o")
+	if len(m.filtered) != 0 {
+		t.Errorf("search should not find hidden hosts, got %d results", len(m.filtered))
+	}
+	m = pressSpecialKey(m, tea.KeyEsc)
+
+	m = pressKey(m, "H")
+	if m.mode != modeNormal || len(m.filtered) != 3 {
+		t.Fatalf("expected H to reveal all 3 hosts, got %d (mode %d)", len(m.filtered), m.mode)
+	}
+
+	m = pressKey(m, "H")
+	if len(m.filtered) != 2 {
+		t.Errorf("expected H again to re-hide, got %d hosts", len(m.filtered))
+	}
+}
+
+// TestHiddenHosts_HStartsSearchWhenNoneHidden tests that H still starts a
+// search when there is nothing hidden to reveal.
+func TestHiddenHosts_HStartsSearchWhenNoneHidden(t *testing.T) {
+	m := New(makeHosts("alpha", "beta"), makeState(map[string]int{}), "/tmp/state.json", true)
+	m = pressKey(m, "H")
+	if m.mode != modeSearch || m.searchQuery != "H" {
+		t.Errorf("expected H to start a search, got mode %d query %q", m.mode, m.searchQuery)
+	}
+}
+
+// TestConnectArgs_CustomConfigPassesF verifies that a custom config path is
+// passed to ssh with -F in both connect-by modes, and omitted by default.
+func TestConnectArgs_CustomConfigPassesF(t *testing.T) {
+	hosts := []config.Host{{Alias: "prod", Hostname: "10.0.0.5", Port: "22"}}
+	st := makeState(make(map[string]int))
+
+	for _, by := range []string{ConnectByAlias, ConnectByHostname} {
+		m := NewWithOptions(hosts, st, "/tmp/state.json", Options{ConnectBy: by, ConfigPath: "/tmp/other"})
+		args := connectArgs(m, m.filtered[0])
+		if len(args) < 2 || args[0] != "-F" || args[1] != "/tmp/other" {
+			t.Errorf("%s mode: expected leading -F /tmp/other, got %v", by, args)
+		}
+	}
+
+	m := NewWithOptions(hosts, st, "/tmp/state.json", Options{})
+	for _, a := range connectArgs(m, m.filtered[0]) {
+		if a == "-F" {
+			t.Errorf("default config should not pass -F, got %v", connectArgs(m, m.filtered[0]))
+		}
+	}
+}
+
+// TestEditMode_CtrlCOnDirtyFormAsksToDiscard tests that Ctrl+C in a form with
+// unsaved changes asks for confirmation instead of quitting, and a second
+// press discards the edit without quitting.
+func TestEditMode_CtrlCOnDirtyFormAsksToDiscard(t *testing.T) {
+	m := New(makeHostsWithLine("alpha", "beta"), makeState(make(map[string]int)), "/tmp/state.json", false)
+	m = pressCtrlE(m)
+	m = pressKey(m, "x")
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+	m = updated.(Model)
+	if cmd != nil {
+		t.Fatal("Ctrl+C in a dirty edit form should not quit")
+	}
+	if m.mode != modeEdit || m.edit == nil || !m.edit.confirmDiscard {
+		t.Fatal("expected the form to stay open awaiting discard confirmation")
+	}
+	if m.edit.statusMsg == "" {
+		t.Error("expected a status message explaining how to discard")
+	}
+
+	updated, cmd = m.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+	m = updated.(Model)
+	if cmd != nil {
+		t.Error("second Ctrl+C should discard, not quit")
+	}
+	if m.mode != modeNormal || m.edit != nil {
+		t.Error("expected second Ctrl+C to discard the edit")
+	}
+}
+
+// TestEditMode_CtrlCOnCleanFormCloses tests that Ctrl+C closes an unchanged
+// form straight away, and that typing after the discard prompt keeps editing.
+func TestEditMode_CtrlCOnCleanFormCloses(t *testing.T) {
+	m := New(makeHostsWithLine("alpha"), makeState(make(map[string]int)), "/tmp/state.json", false)
+	m = pressCtrlE(m)
+	m = pressSpecialKey(m, tea.KeyCtrlC)
+	if m.mode != modeNormal {
+		t.Fatal("expected Ctrl+C on a clean form to close it")
+	}
+
+	m = pressCtrlE(m)
+	m = pressKey(m, "x")
+	m = pressSpecialKey(m, tea.KeyEsc)
+	m = pressKey(m, "y")
+	if m.edit == nil || m.edit.confirmDiscard {
+		t.Fatal("typing after the prompt should cancel the pending discard")
+	}
+	if m.edit.fields[fieldAlias] != "alphaxy" {
+		t.Errorf("alias = %q; want alphaxy", m.edit.fields[fieldAlias])
+	}
+}
+
+// TestNormalMode_CtrlCQuits tests that Ctrl+C still quits from the list.
+func TestNormalMode_CtrlCQuits(t *testing.T) {
+	m := New(makeHosts("alpha"), makeState(make(map[string]int)), "/tmp/state.json", false)
+	_, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlC})
+	if cmd == nil {
+		t.Fatal("expected a quit command")
+	}
+	if _, ok := cmd().(tea.QuitMsg); !ok {
+		t.Error("expected Ctrl+C in normal mode to return tea.Quit")
+	}
+}
+
+// TestConnectArgs_InheritedIdentityPassed verifies that an IdentityFile
+// inherited from a group default is passed with -i in alias mode.
+func TestConnectArgs_InheritedIdentityPassed(t *testing.T) {
+	hosts := []config.Host{{
+		Alias: "api", Hostname: "api.corp", User: "deploy", Port: "22",
+		IdentityFile: "/keys/work",
+		Inherited:    map[string]string{"User": "deploy", "IdentityFile": "/keys/work"},
+	}}
+	m := New(hosts, makeState(make(map[string]int)), "/tmp/state.json", false)
+	got := strings.Join(connectArgs(m, m.filtered[0]), " ")
+	if got != "-i /keys/work -l deploy api" {
+		t.Errorf("connectArgs = %q; want %q", got, "-i /keys/work -l deploy api")
+	}
+}
+
+// TestAfterLocal_RunsAfterSSHLaunch verifies a host's @after-local command runs
+// only after ssh has been launched, and that failures reach the status bar.
+func TestAfterLocal_RunsAfterSSHLaunch(t *testing.T) {
+	hosts := makeHosts("tunnel")
+	hosts[0].AfterLocal = "open http://localhost:8080"
+	m := New(hosts, makeState(make(map[string]int)), filepath.Join(t.TempDir(), "state.json"), false)
+	m.getenv = func(k string) string {
+		if k == "TMUX" {
+			return "/tmp/tmux-1000/default,1,0"
+		}
+		return ""
+	}
+	m.goos = "linux"
+	var events []string
+	m.spawn = func(name string, args ...string) (string, error) {
+		events = append(events, "spawn "+name)
+		return "@1\n", nil
+	}
+	m.runLocal = func(command string) error {
+		events = append(events, "local "+command)
+		return errors.New("no browser")
+	}
+
+	newModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlO})
+	m = newModel.(Model)
+	if cmd == nil {
+		t.Fatal("expected a command running @after-local")
+	}
+	newModel, _ = m.Update(cmd())
+	m = newModel.(Model)
+
+	want := []string{"spawn tmux", "local open http://localhost:8080"}
+	if strings.Join(events, "|") != strings.Join(want, "|") {
+		t.Errorf("events = %v; want %v", events, want)
+	}
+	if !strings.Contains(m.statusMsg, "no browser") {
+		t.Errorf("expected failure in status bar, got %q", m.statusMsg)
+	}
+}
+
+// TestAfterLocal_SkippedWhenSSHFails verifies that @after-local runs when an
+// in-place ssh exits cleanly and not when it fails.
+func TestAfterLocal_SkippedWhenSSHFails(t *testing.T) {
+	host := config.Host{Alias: "tunnel", Hostname: "bastion", AfterLocal: "open x"}
+	m := New([]config.Host{host}, makeState(make(map[string]int)), "/tmp/state.json", false)
+	ran := 0
+	m.runLocal = func(string) error { ran++; return nil }
+
+	if _, cmd := m.Update(sshExitMsg{err: errors.New("exit status 255"), host: host}); cmd != nil {
+		t.Error("expected no @after-local command after ssh failed")
+	}
+
+	_, cmd := m.Update(sshExitMsg{host: host})
+	if cmd == nil {
+		t.Fatal("expected an @after-local command after ssh succeeded")
+	}
+	cmd()
+	if ran != 1 {
+		t.Errorf("expected the local command to run once, ran %d times", ran)
+	}
+}
+
+// TestSearchHistory_PushDedupesAndBounds tests that repeated queries move to
+// the front, blanks are ignored, and the history is bounded.
+func TestSearchHistory_PushDedupesAndBounds(t *testing.T) {
+	h := newSearchHistory()
+	h.push("prod")
+	h.push("db")
+	h.push("  ")
+	h.push("prod")
+	if strings.Join(h.entries, ",") != "prod,db" {
+		t.Errorf("entries = %v; want [prod db]", h.entries)
+	}
+
+	for i := 0; i < searchHistoryLimit+10; i++ {
+		h.push(string(rune('a'+i%26)) + strings.Repeat("x", i))
+	}
+	if len(h.entries) != searchHistoryLimit {
+		t.Errorf("expected history capped at %d, got %d", searchHistoryLimit, len(h.entries))
+	}
+}
+
+// TestSearchHistory_RecallOrder tests that older walks from most recent to
+// oldest and newer walks back to a fresh query.
+func TestSearchHistory_RecallOrder(t *testing.T) {
+	h := newSearchHistory()
+	for _, q := range []string{"one", "two", "three"} {
+		h.push(q)
+	}
+
+	var got []string
+	for {
+		q, ok := h.older()
+		if !ok {
+			break
+		}
+		got = append(got, q)
+	}
+	if strings.Join(got, ",") != "three,two,one" {
+		t.Errorf("older() order = %v; want [three two one]", got)
+	}
+
+	q, _ := h.newer()
+	if q != "two" {
+		t.Errorf("newer() = %q; want two", q)
+	}
+	h.newer()
+	if q, ok := h.newer(); !ok || q != "" {
+		t.Errorf("stepping past the newest should give a fresh query, got %q, %v", q, ok)
+	}
+	if _, ok := h.newer(); ok {
+		t.Error("newer() with nothing recalled should return false")
+	}
+}
+
+// TestPromptMode_ArrowsRecallHistory tests that Ctrl+F opens a prompt where
+// ↑/↓ recall earlier queries instead of moving the cursor.
+func TestPromptMode_ArrowsRecallHistory(t *testing.T) {
+	m := NewWithOptions(makeHosts("alpha", "beta", "gamma"), makeState(make(map[string]int)), filepath.Join(t.TempDir(), "state.json"), Options{NoFrequent: true, NoHistory: true})
+
+	// A query used to connect from type-to-search is remembered.
+	m = pressKey(m, "b")
+	newModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = newModel.(Model)
+	if cmd == nil || m.lastConnectedAlias != "beta" {
+		t.Fatalf("expected Enter to connect to beta, got cmd=%v last=%q", cmd != nil, m.lastConnectedAlias)
+	}
+	m = pressSpecialKey(m, tea.KeyEsc)
+
+	m = pressSpecialKey(m, tea.KeyCtrlF)
+	if m.mode != modePrompt {
+		t.Fatalf("expected modePrompt after Ctrl+F, got %d", m.mode)
+	}
+	m = pressKey(m, "gam")
+	m = pressSpecialKey(m, tea.KeyEnter)
+	if m.mode != modeSearch || m.searchQuery != "gam" {
+		t.Fatalf("expected Enter to keep the query in search mode, got mode %d query %q", m.mode, m.searchQuery)
+	}
+	m = pressSpecialKey(m, tea.KeyEsc)
+
+	m = pressSpecialKey(m, tea.KeyCtrlF)
+	m = pressSpecialKey(m, tea.KeyUp)
+	if m.searchQuery != "gam" || m.cursor != 0 {
+		t.Errorf("first ↑ should recall 'gam' without moving the cursor, got %q (cursor %d)", m.searchQuery, m.cursor)
+	}
+	if len(m.filtered) != 1 || m.filtered[0].Alias != "gamma" {
+		t.Errorf("recalled query should filter the list, got %d hosts", len(m.filtered))
+	}
+	m = pressSpecialKey(m, tea.KeyUp)
+	if m.searchQuery != "b" {
+		t.Errorf("second ↑ should recall 'b', got %q", m.searchQuery)
+	}
+	m = pressSpecialKey(m, tea.KeyDown)
+	m = pressSpecialKey(m, tea.KeyDown)
+	if m.searchQuery != "" {
+		t.Errorf("↓ past the newest should clear the query, got %q", m.searchQuery)
+	}
+
+	m = pressSpecialKey(m, tea.KeyEsc)
+	if m.mode != modeNormal {
+		t.Errorf("expected Esc to leave the prompt, got mode %d", m.mode)
+	}
+}
+
+// fakeResolver answers lookups from a map and records what was asked.
+type fakeResolver struct {
+	addrs   map[string]string
+	lookups []string
+}
+
+func (r *fakeResolver) LookupHost(_ context.Context, host string) ([]string, error) {
+	r.lookups = append(r.lookups, host)
+	if ip, ok := r.addrs[host]; ok {
+		return []string{ip}, nil
+	}
+	return nil, errors.New("no such host")
+}
+
+// runBatch executes cmd, expanding a tea.BatchMsg, and feeds every resulting
+// message back into m.
+func runBatch(m Model, cmd tea.Cmd) Model {
+	if cmd == nil {
+		return m
+	}
+	msg := cmd()
+	if batch, ok := msg.(tea.BatchMsg); ok {
+		for _, c := range batch {
+			m = runBatch(m, c)
+		}
+		return m
+	}
+	updated, _ := m.Update(msg)
+	return updated.(Model)
+}
+
+// TestResolveToggle_ShowsIPAndSkipsLiterals verifies Ctrl+\ resolves visible
+// hostnames through the injected resolver, shows the IP, and leaves IP
+// literals alone.
+func TestResolveToggle_ShowsIPAndSkipsLiterals(t *testing.T) {
+	hosts := []config.Host{
+		{Alias: "web", Hostname: "web.example.com", Port: "22"},
+		{Alias: "pi", Hostname: "192.168.1.2", Port: "22"},
+		{Alias: "gone", Hostname: "gone.example.com", Port: "22"},
+	}
+	m := New(hosts, makeState(make(map[string]int)), "/tmp/state.json", true)
+	r := &fakeResolver{addrs: map[string]string{"web.example.com": "203.0.113.7"}}
+	m.resolver = r
+
+	updated, cmd := m.Update(tea.KeyMsg{Type: tea.KeyCtrlBackslash})
+	m = updated.(Model)
+	if !m.showResolved {
+		t.Fatal("expected Ctrl+\\ to turn resolution on")
+	}
+	if !strings.Contains(m.View(), "web.example.com (…)") {
+		t.Error("expected a pending marker while lookups are in flight")
+	}
+	m = runBatch(m, cmd)
+
+	for _, h := range r.lookups {
+		if h == "192.168.1.2" {
+			t.Error("IP literal should not be looked up")
+		}
+	}
+	if len(r.lookups) != 2 {
+		t.Errorf("expected 2 lookups, got %v", r.lookups)
+	}
+	view := m.View()
+	if !strings.Contains(view, "web.example.com (203.0.113.7)") {
+		t.Errorf("expected resolved IP in view:\n%s", view)
+	}
+	if !strings.Contains(view, "gone.example.com (?)") {
+		t.Errorf("expected failed lookup marker in view:\n%s", view)
+	}
+
+	// Fresh results are cached: moving the cursor triggers no new lookups.
+	r.lookups = nil
+	_, cmd = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	m = runBatch(m, cmd)
+	if len(r.lookups) != 0 {
+		t.Errorf("expected cached results to be reused, got lookups %v", r.lookups)
+	}
+}
+
+// TestCopyCommand_FallsBackToStatus verifies Ctrl+Y copies the ssh command and,
+// when no clipboard tool exists, shows it in the status bar with a hint.
+func TestCopyCommand_FallsBackToStatus(t *testing.T) {
+	hosts := []config.Host{{Alias: "prod", Hostname: "10.0.0.5", User: "deploy", Port: "2222"}}
+	m := New(hosts, makeState(make(map[string]int)), "/tmp/state.json", false)
+
+	var copied string
+	m.copyText = func(text string) error { copied = text; return nil }
+	m = pressSpecialKey(m, tea.KeyCtrlY)
+	want := sshCommandLine(m, hosts[0])
+	if copied != want {
+		t.Errorf("copied %q; want %q", copied, want)
+	}
+
+	m.copyText = func(string) error { return platform.ErrNoClipboard }
+	m = pressSpecialKey(m, tea.KeyCtrlY)
+	if !strings.Contains(m.statusMsg, "No clipboard tool found") || !strings.Contains(m.statusMsg, want) {
+		t.Errorf("statusMsg = %q; want hint and command", m.statusMsg)
+	}
+}
+
+// TestVerbosity_CyclesAndAppliesToNextConnection verifies "V" cycles the ssh
+// debug level, shows it in the status bar, prepends it to the ssh args, and
+// resets after one connection.
+func TestVerbosity_CyclesAndAppliesToNextConnection(t *testing.T) {
+	hosts := makeHosts("alpha")
+	st := makeState(make(map[string]int))
+	m := New(hosts, st, filepath.Join(t.TempDir(), "state.json"), false)
+	m.getenv = func(k string) string {
+		if k == "TMUX" {
+			return "/tmp/tmux-1000/default,1,0"
+		}
+		return ""
+	}
+	m.goos = "linux"
+	var launched []string
+	m.spawn = func(name string, args ...string) (string, error) {
+		launched = append([]string{name}, args...)
+		return "@1\n", nil
+	}
+
+	m = pressKey(m, "V")
+	m = pressKey(m, "V")
+	if m.mode != modeNormal {
+		t.Fatalf("V should not start a search, mode = %v", m.mode)
+	}
+	if args := connectArgs(m, hosts[0]); args[0] != "-vv" {
+		t.Errorf("connectArgs = %v; want -vv first", args)
+	}
+	if bar := renderStatusBar(m); !strings.Contains(bar, "verbose -vv") {
+		t.Errorf("status bar %q should show the verbosity", bar)
+	}
+
+	m = pressSpecialKey(m, tea.KeyCtrlO)
+	if !strings.Contains(strings.Join(launched, " "), "ssh -vv") {
+		t.Errorf("expected -vv in launched command, got %v", launched)
+	}
+	if m.verbosity != 0 {
+		t.Errorf("verbosity = %d after connecting; want 0", m.verbosity)
+	}
+
+	for i := 0; i < 4; i++ {
+		m = pressKey(m, "V")
+	}
+	if m.verbosity != 0 {
+		t.Errorf("verbosity = %d after a full cycle; want 0", m.verbosity)
+	}
+
+	// Every other way of connecting also uses up the verbosity.
+	m.verbosity = 2
+	if next, _ := connectWithIdentity(m, hosts[0], "/keys/other"); next.verbosity != 0 {
+		t.Errorf("verbosity = %d after connecting with another key; want 0", next.verbosity)
+	}
+	m.filtered = []config.Host{{Alias: "web", Hostname: "10.0.0.1", Port: "22", ExtraLines: []string{"ControlPath /tmp/cm-%n"}}}
+	m.cursor = 0
+	m.hasMaster = func(string) bool { return false }
+	if next, _ := startMaster(m); next.verbosity != 0 {
+		t.Errorf("verbosity = %d after starting a master; want 0", next.verbosity)
+	}
+}
+
+// TestEditMode_PortAcceptsDigitsOnly verifies that letters typed into the Port
+// field are dropped with a hint, digits are capped at five, and saving still
+// rejects out-of-range ports.
+func TestEditMode_PortAcceptsDigitsOnly(t *testing.T) {
+	hosts := makeHostsWithLine("alpha")
+	st := makeState(make(map[string]int))
+	m := New(hosts, st, "/tmp/state.json", false)
+	m = pressCtrlE(m)
+	m.edit.activeField = fieldPort
+	m = pressCtrlU(m)
+
+	m = pressKey(m, "2a2")
+	if m.edit.fields[fieldPort] != "22" {
+		t.Errorf("expected letters dropped, got Port=%q", m.edit.fields[fieldPort])
+	}
+	if !strings.Contains(m.edit.statusMsg, "digits only") {
+		t.Errorf("expected digits-only hint, got %q", m.edit.statusMsg)
+	}
+
+	m = pressKey(m, "8")
+	if m.edit.statusMsg != "" {
+		t.Errorf("expected hint cleared after a valid digit, got %q", m.edit.statusMsg)
+	}
+	m = pressKey(m, "4567")
+	if m.edit.fields[fieldPort] != "22845" {
+		t.Errorf("expected Port capped at 5 digits, got %q", m.edit.fields[fieldPort])
+	}
+
+	m = pressCtrlU(m)
+	m = pressKey(m, "70000")
+	m = pressSpecialKey(m, tea.KeyEnter)
+	if m.edit == nil || !strings.Contains(m.edit.statusMsg, "1 to 65535") {
+		t.Fatalf("expected save to reject port 70000, got form=%v", m.edit)
+	}
+
+	// Other fields stay unrestricted.
+	m.edit.activeField = fieldUser
+	m = pressKey(m, "ops1")
+	if !strings.HasSuffix(m.edit.fields[fieldUser], "ops1") {
+		t.Errorf("expected User to accept letters and digits, got %q", m.edit.fields[fieldUser])
+	}
+}
+
+// TestPreview_ShowsTokenExpansion verifies the preview shows both the raw and
+// expanded IdentityFile when it contains percent tokens, and nothing extra
+// when it does not.
+func TestPreview_ShowsTokenExpansion(t *testing.T) {
+	home := t.TempDir()
+	t.Setenv("HOME", home)
+	t.Setenv("USERPROFILE", home)
+
+	hosts := []config.Host{
+		{Alias: "tok", Hostname: "tok.example.com", IdentityFile: "%d/.ssh/id_%h", Port: "22"},
+		{Alias: "plain", Hostname: "plain.example.com", IdentityFile: "~/.ssh/id_rsa", Port: "22"},
+	}
+	st := makeState(make(map[string]int))
+	m := NewWithOptions(hosts, st, "/tmp/state.json", Options{Preview: true, NoFrequent: true})
+	selectAlias(&m, "tok")
+
+	preview := renderPreview(m)
+	want := "IdentityFile: %d/.ssh/id_%h → " + home + "/.ssh/id_tok.example.com"
+	if !strings.Contains(preview, want) {
+		t.Errorf("preview %q should contain %q", preview, want)
+	}
+
+	selectAlias(&m, "plain")
+	if preview := renderPreview(m); strings.Contains(preview, "→") {
+		t.Errorf("preview without tokens should not show an expansion, got %q", preview)
+	}
+}
+
+// TestNewWithOptions_SortGroup verifies Options.Sort orders the list by group
+// and, with NoFrequent, ignores counts within each group.
+func TestNewWithOptions_SortGroup(t *testing.T) {
+	hosts := []config.Host{
+		{Alias: "solo"},
+		{Alias: "web", Groups: []string{"Work"}},
+		{Alias: "db", Groups: []string{"Work"}},
+		{Alias: "pi", Groups: []string{"Home"}},
+	}
+	st := makeState(map[string]int{"web": 3, "solo": 9})
+
+	for _, tc := range []struct {
+		noFrequent bool
+		want       []string
+	}{
+		{false, []string{"pi", "web", "db", "solo"}},
+		{true, []string{"pi", "db", "web", "solo"}},
+	} {
+		m := NewWithOptions(hosts, st, "/tmp/state.json", Options{Sort: state.SortGroup, NoFrequent: tc.noFrequent})
+		var got []string
+		for _, h := range m.filtered {
+			got = append(got, h.Alias)
+		}
+		if strings.Join(got, " ") != strings.Join(tc.want, " ") {
+			t.Errorf("NoFrequent=%v: order %v; want %v", tc.noFrequent, got, tc.want)
+		}
+	}
+}
+
+// TestCycleSort_StepsThroughModes verifies Ctrl+S re-sorts allHosts through
+// frequency, alphabetical, group, and recent order and resets the cursor.
+func TestCycleSort_StepsThroughModes(t *testing.T) {
+	hosts := []config.Host{
+		{Alias: "solo"},
+		{Alias: "web", Groups: []string{"Work"}},
+		{Alias: "db", Groups: []string{"Work"}},
+		{Alias: "pi", Groups: []string{"Home"}},
+	}
+	st := makeState(map[string]int{"web": 3, "solo": 9})
+	st.LastConnected = map[string]int64{"pi": 200, "web": 100}
+	m := NewWithOptions(hosts, st, "/tmp/state.json", Options{})
+
+	aliases := func(m Model) string {
+		var got []string
+		for _, h := range m.allHosts {
+			got = append(got, h.Alias)
+		}
+		return strings.Join(got, " ")
+	}
+	if got := aliases(m); got != "solo web db pi" {
+		t.Fatalf("initial frequency order %q", got)
+	}
+
+	for _, want := range []struct{ mode, order string }{
+		{state.SortAlpha, "db pi solo web"},
+		{state.SortGroup, "pi web db solo"},
+		{state.SortRecent, "pi web db solo"},
+		{state.SortFrequency, "solo web db pi"},
+	} {
+		m = pressSpecialKey(m, tea.KeyDown)
+		m = pressSpecialKey(m, tea.KeyCtrlS)
+		if m.sortMode != want.mode {
+			t.Errorf("sort mode %q; want %q", m.sortMode, want.mode)
+		}
+		if got := aliases(m); got != want.order {
+			t.Errorf("%s order %q; want %q", want.mode, got, want.order)
+		}
+		if m.cursor != 0 || m.viewport != 0 {
+			t.Errorf("%s: cursor %d viewport %d; want both 0", want.mode, m.cursor, m.viewport)
+		}
+	}
+}
+
+// TestRefilter_PreservesSelection verifies the cursor follows the selected
+// host across a rebuild of the list, clamps when that host is gone, and
+// resets to 0 when nothing is left.
+func TestRefilter_PreservesSelection(t *testing.T) {
+	st := makeState(make(map[string]int))
+	m := New(makeHosts("alpha", "bravo", "charlie", "delta", "echo"), st, "/tmp/state.json", true)
+	m.viewHeight = 2
+	selectAlias(&m, "delta")
+
+	t.Run("host still present", func(t *testing.T) {
+		m := m
+		m.allHosts = makeHosts("aardvark", "alpha", "bravo", "charlie", "delta", "echo")
+		refilter(&m)
+		if got := m.filtered[m.cursor].Alias; got != "delta" {
+			t.Errorf("cursor on %q; want delta", got)
+		}
+		if m.cursor < m.viewport || m.cursor >= m.viewport+m.viewHeight {
+			t.Errorf("cursor %d outside viewport %d..%d", m.cursor, m.viewport, m.viewport+m.viewHeight)
+		}
+	})
+
+	t.Run("host removed clamps", func(t *testing.T) {
+		m := m
+		m.allHosts = makeHosts("alpha", "bravo")
+		refilter(&m)
+		if m.cursor != 1 || m.filtered[m.cursor].Alias != "bravo" {
+			t.Errorf("cursor = %d; want clamped to last host", m.cursor)
+		}
+		if m.viewport > m.cursor {
+			t.Errorf("viewport %d past cursor %d", m.viewport, m.cursor)
+		}
+	})
+
+	t.Run("empty result", func(t *testing.T) {
+		m := m
+		m.allHosts = nil
+		refilter(&m)
+		if m.cursor != 0 || m.viewport != 0 {
+			t.Errorf("cursor=%d viewport=%d; want 0, 0", m.cursor, m.viewport)
+		}
+	})
+}
+
+// TestSSHFailure_OffersRetry verifies a failed in-place ssh leaves a retry
+// prompt, that "r" connects to the same host again, and that a clean exit
+// stays silent.
+func TestSSHFailure_OffersRetry(t *testing.T) {
+	hosts := makeHosts("alpha", "beta")
+	st := makeState(make(map[string]int))
+	m := New(hosts, st, filepath.Join(t.TempDir(), "state.json"), true)
+
+	newModel, _ := m.Update(sshExitMsg{host: hosts[1]})
+	m = newModel.(Model)
+	if m.statusMsg != "" || m.retryHost != nil {
+		t.Fatalf("clean exit should be silent, got status %q", m.statusMsg)
+	}
+
+	newModel, _ = m.Update(sshExitMsg{err: errors.New("exit status 255"), host: hosts[1]})
+	m = newModel.(Model)
+	if !strings.Contains(m.statusMsg, "beta failed") || !strings.Contains(m.statusMsg, "r: retry") {
+		t.Errorf("expected retry prompt, got %q", m.statusMsg)
+	}
+
+	newModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("r")})
+	m = newModel.(Model)
+	if cmd == nil {
+		t.Fatal("expected r to return a connect command")
+	}
+	if st.Connections["beta"] != 1 || m.lastConnectedAlias != "beta" {
+		t.Errorf("expected retry to connect to beta, got counts %v", st.Connections)
+	}
+	if m.retryHost != nil || m.mode != modeNormal {
+		t.Error("expected the retry offer to be consumed without starting a search")
+	}
+}
+
+// TestSSHFailure_RetryWithAnotherKey verifies "i" opens the key picker for the
+// failed host, and any other key dismisses the offer.
+func TestSSHFailure_RetryWithAnotherKey(t *testing.T) {
+	hosts := makeHosts("alpha", "beta")
+	m := New(hosts, makeState(make(map[string]int)), "/tmp/state.json", true)
+	m.keyDir = t.TempDir()
+	for _, name := range []string{"id_work", "id_work.pub"} {
+		if err := os.WriteFile(filepath.Join(m.keyDir, name), []byte("k"), 0600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	failed := func(m Model) Model {
+		newModel, _ := m.Update(sshExitMsg{err: errors.New("exit status 255"), host: hosts[1]})
+		return newModel.(Model)
+	}
+
+	m = pressKey(failed(m), "i")
+	if m.picker == nil || m.picker.host.Alias != "beta" {
+		t.Fatalf("expected picker for beta, got %+v", m.picker)
+	}
+
+	m.picker = nil
+	m = pressSpecialKey(failed(m), tea.KeyDown)
+	if m.retryHost != nil || m.statusMsg != "" {
+		t.Errorf("expected other keys to dismiss the retry offer, status %q", m.statusMsg)
+	}
+}
+
+// TestFavorites_BarAndNumberKeys verifies the favorites bar lists pinned hosts
+// in pin order, alt+number keys connect to them, and unused ones connect nowhere.
+func TestFavorites_BarAndNumberKeys(t *testing.T) {
+	hosts := makeHosts("alpha", "bastion", "db", "prod")
+	st := makeState(make(map[string]int))
+	st.Pinned = []string{"prod", "gone", "db", "bastion"}
+	m := New(hosts, st, filepath.Join(t.TempDir(), "state.json"), true)
+
+	bar := renderFavorites(m)
+	if !strings.Contains(bar, "1:prod  2:db  3:bastion") {
+		t.Errorf("favorites bar = %q; want pinned chips in order", bar)
+	}
+
+	newModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("2"), Alt: true})
+	m = newModel.(Model)
+	if cmd == nil || m.lastConnectedAlias != "db" || st.Connections["db"] != 1 {
+		t.Errorf("expected alt+2 to connect to db, got last=%q counts=%v", m.lastConnectedAlias, st.Connections)
+	}
+
+	newModel, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("4"), Alt: true})
+	m = newModel.(Model)
+	if cmd != nil || m.lastConnectedAlias != "db" || st.Connections["prod"] != 0 {
+		t.Errorf("expected an unused alt+number not to connect, got last=%q counts=%v", m.lastConnectedAlias, st.Connections)
+	}
+}
+
+// TestFavorites_DigitStartsSearch verifies that with a host pinned, typing a
+// digit starts a search (e.g. for "10.0.") instead of connecting to favorite 1.
+func TestFavorites_DigitStartsSearch(t *testing.T) {
+	st := makeState(make(map[string]int))
+	st.Pinned = []string{"prod"}
+	m := New(makeHosts("alpha", "prod"), st, filepath.Join(t.TempDir(), "state.json"), true)
+
+	newModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("1")})
+	m = newModel.(Model)
+	if m.mode != modeSearch || m.searchQuery != "1" {
+		t.Errorf("expected 1 to start a search, mode=%v query=%q", m.mode, m.searchQuery)
+	}
+	if cmd != nil || m.lastConnectedAlias != "" || st.Connections["prod"] != 0 {
+		t.Errorf("expected no connection, got last=%q counts=%v", m.lastConnectedAlias, st.Connections)
+	}
+}
+
+// TestFavorites_CtrlPTogglesPin verifies Ctrl+P pins and unpins the selected
+// host, and the bar disappears when nothing is pinned.
+func TestFavorites_CtrlPTogglesPin(t *testing.T) {
+	st := makeState(make(map[string]int))
+	m := New(makeHosts("alpha", "beta"), st, filepath.Join(t.TempDir(), "state.json"), true)
+	if renderFavorites(m) != "" {
+		t.Fatal("expected no favorites bar without pins")
+	}
+
+	m = moveCursorDown(m)
+	m = pressSpecialKey(m, tea.KeyCtrlP)
+	if len(st.Pinned) != 1 || st.Pinned[0] != "beta" {
+		t.Fatalf("expected beta pinned, got %v", st.Pinned)
+	}
+	if !strings.Contains(renderFavorites(m), "1:beta") {
+		t.Errorf("expected bar to show beta, got %q", renderFavorites(m))
+	}
+
+	m = pressSpecialKey(m, tea.KeyCtrlP)
+	if len(st.Pinned) != 0 || renderFavorites(m) != "" {
+		t.Errorf("expected beta unpinned and bar hidden, got %v", st.Pinned)
+	}
+}
+
+// TestSearch_StickyAcrossSession verifies the search query and results survive
+// the end of an ssh session by default, and are cleared with ClearSearch.
+func TestSearch_StickyAcrossSession(t *testing.T) {
+	hosts := makeHosts("web1", "web2", "db")
+
+	for _, clear := range []bool{false, true} {
+		st := makeState(make(map[string]int))
+		m := NewWithOptions(hosts, st, filepath.Join(t.TempDir(), "state.json"), Options{ClearSearch: clear})
+		m = pressKey(m, "web")
+		selectAlias(&m, "web2")
+		newModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+		m = newModel.(Model)
+		if cmd == nil {
+			t.Fatal("expected Enter to connect")
+		}
+
+		newModel, _ = m.Update(sshExitMsg{host: hosts[1]})
+		m = newModel.(Model)
+
+		if clear {
+			if m.searchQuery != "" || m.mode != modeNormal || len(m.filtered) != 3 {
+				t.Errorf("ClearSearch: query=%q mode=%v filtered=%d; want cleared", m.searchQuery, m.mode, len(m.filtered))
+			}
+		} else {
+			if m.searchQuery != "web" || m.mode != modeSearch || len(m.filtered) != 2 {
+				t.Errorf("sticky: query=%q mode=%v filtered=%d; want web/search/2", m.searchQuery, m.mode, len(m.filtered))
+			}
+		}
+		if got := m.filtered[m.cursor].Alias; got != "web2" {
+			t.Errorf("clear=%v: cursor on %q; want web2", clear, got)
+		}
+	}
+}
+
+func TestScheme_EnvironmentReplacesGroups(t *testing.T) {
+	scheme, err := config.ParseScheme("svc-env-region")
+	if err != nil {
+		t.Fatal(err)
+	}
+	hosts := []config.Host{
+		{Alias: "api-prod-us", Hostname: "10.0.0.1", Port: "22", Groups: []string{"web"}},
+		{Alias: "db-staging-eu", Hostname: "10.0.0.2", Port: "22"},
+		{Alias: "bastion", Hostname: "10.0.0.3", Port: "22", Groups: []string{"infra"}},
+	}
+	m := NewWithOptions(hosts, makeState(make(map[string]int)), "/tmp/state.json", Options{NoFrequent: true, Scheme: &scheme})
+
+	got := legendGroups(m)
+	want := []string{"infra", "prod", "staging"}
+	if strings.Join(got, ",") != strings.Join(want, ",") {
+		t.Errorf("legendGroups = %v; want %v", got, want)
+	}
+
+	m = pressKey(m, "/")
+	for _, r := range "staging" {
+		m = pressKey(m, string(r))
+	}
+	if len(m.filtered) != 1 || m.filtered[0].Alias != "db-staging-eu" {
+		t.Errorf("search staging matched %v; want only db-staging-eu", m.filtered)
+	}
+	if len(m.allHosts[0].Groups) != 1 || m.allHosts[0].Groups[0] != "web" {
+		t.Errorf("configured groups changed to %v", m.allHosts[0].Groups)
+	}
+}
+
+func TestStartMaster_OnlyWhenSocketAbsent(t *testing.T) {
+	hosts := []config.Host{
+		{Alias: "web", Hostname: "10.0.0.1", Port: "22", ExtraLines: []string{"ControlPath /tmp/cm-%n"}},
+		{Alias: "db", Hostname: "10.0.0.2", Port: "22"},
+	}
+	m := NewWithOptions(hosts, makeState(make(map[string]int)), "/tmp/state.json", Options{NoFrequent: true})
+	var checked string
+	running := false
+	m.hasMaster = func(path string) bool { checked = path; return running }
+	selectAlias(&m, "web")
+
+	next, cmd := handleKey(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("M")})
+	if cmd == nil || checked != "/tmp/cm-web" {
+		t.Errorf("socket absent: cmd = %v, checked %q; want a master started for /tmp/cm-web", cmd, checked)
+	}
+	if next.mode != modeNormal {
+		t.Errorf("M started a search")
+	}
+
+	running = true
+	next, cmd = handleKey(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("M")})
+	if cmd != nil || !strings.Contains(next.statusMsg, "already running") {
+		t.Errorf("socket present: cmd = %v, status %q; want no new master", cmd, next.statusMsg)
+	}
+
+	selectAlias(&m, "db")
+	next, cmd = handleKey(m, tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("M")})
+	if cmd != nil || !strings.Contains(next.statusMsg, "no ControlPath") {
+		t.Errorf("no ControlPath: cmd = %v, status %q", cmd, next.statusMsg)
+	}
+}
+
+func TestSearch_PrefixModeExcludesMidStringMatches(t *testing.T) {
+	hosts := []config.Host{
+		{Alias: "prod", Hostname: "10.0.0.1", Port: "22"},
+		{Alias: "superproxy", Hostname: "10.0.0.2", Port: "22"},
+		{Alias: "db", Hostname: "Primary.example.com", Port: "22"},
+	}
+	search := func(match string) []string {
+		m := NewWithOptions(hosts, makeState(make(map[string]int)), "/tmp/state.json", Options{NoFrequent: true, Match: match})
+		m = pressKey(m, "p")
+		m = pressKey(m, "r")
+		var aliases []string
+		for _, h := range m.filtered {
+			aliases = append(aliases, h.Alias)
+		}
+		sort.Strings(aliases)
+		return aliases
+	}
+
+	if got := strings.Join(search(MatchFuzzy), ","); got != "db,prod,superproxy" {
+		t.Errorf("fuzzy matches = %s; want db,prod,superproxy", got)
+	}
+	if got := strings.Join(search(MatchPrefix), ","); got != "db,prod" {
+		t.Errorf("prefix matches = %s; want db,prod", got)
+	}
+
+	m := NewWithOptions(hosts, makeState(make(map[string]int)), "/tmp/state.json", Options{Match: MatchPrefix})
+	if !strings.Contains(m.View(), "[prefix]") {
+		t.Error("header does not show prefix mode")
+	}
+}
+
+func TestRawBlock_ShowsSourceLines(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config")
+	content := "Host alpha\n    Hostname alpha.example.com\n\n# @group Work\nHost beta\n    Hostname beta.example.com\n    ForwardAgent yes\n"
+	if err := os.WriteFile(configPath, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	hosts, err := config.Parse(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewWithOptions(hosts, makeState(make(map[string]int)), "/tmp/state.json", Options{NoFrequent: true})
+	selectAlias(&m, "beta")
+
+	m = pressKey(m, "R")
+	if m.rawBlock == nil {
+		t.Fatalf("R did not open the panel; status %q", m.statusMsg)
+	}
+	want := []string{"# @group Work", "Host beta", "    Hostname beta.example.com", "    ForwardAgent yes"}
+	if strings.Join(m.rawBlock.lines, "\n") != strings.Join(want, "\n") || m.rawBlock.first != 4 {
+		t.Errorf("panel lines (from %d):\n%s\nwant (from 4):\n%s", m.rawBlock.first, strings.Join(m.rawBlock.lines, "\n"), strings.Join(want, "\n"))
+	}
+	if view := m.View(); !strings.Contains(view, "    7     ForwardAgent yes") {
+		t.Errorf("view missing numbered line:\n%s", view)
+	}
+
+	m = pressSpecialKey(m, tea.KeyEsc)
+	if m.rawBlock != nil {
+		t.Error("Esc did not close the panel")
+	}
+
+	if err := os.WriteFile(configPath, []byte("Host other\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	m = pressKey(m, "R")
+	if m.rawBlock != nil || !strings.Contains(m.statusMsg, "Cannot show the block for beta") {
+		t.Errorf("stale LineStart: panel %v, status %q", m.rawBlock, m.statusMsg)
+	}
+}
+
+func TestQuickAdd_ParsesPasteAndAppendsHost(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config")
+	if err := os.WriteFile(configPath, []byte("Host web\n    Hostname web.example.com\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+	hosts, _ := config.Parse(configPath)
+	m := NewWithOptions(hosts, makeState(make(map[string]int)), "/tmp/state.json", Options{NoFrequent: true, ConfigPath: configPath})
+
+	m = pressKey(m, "A")
+	m = pressKey(m, "deploy@10.0.0.5:99999")
+	m = pressSpecialKey(m, tea.KeyEnter)
+	if m.quickAdd == nil || !strings.Contains(m.quickAdd.statusMsg, "port") {
+		t.Fatalf("invalid port accepted: %+v", m.quickAdd)
+	}
+
+	m = pressCtrlU(m)
+	m = pressKey(m, "deploy@10.0.0.5:2222")
+	m = pressSpecialKey(m, tea.KeyEnter)
+	if m.mode != modeEdit || !m.edit.adding || m.edit.fields[fieldAlias] != "deploy-10.0.0.5" || m.edit.fields[fieldPort] != "2222" {
+		t.Fatalf("expected add form for deploy-10.0.0.5:2222, got mode %v form %+v", m.mode, m.edit)
+	}
+
+	m = pressSpecialKey(m, tea.KeyEnter)
+	if m.mode != modeNormal || m.filtered[m.cursor].Alias != "deploy-10.0.0.5" {
+		t.Fatalf("host not added and selected; status %q", m.statusMsg)
+	}
+	if m.filtered[m.cursor].LineStart == 0 {
+		t.Error("added host has no line position")
+	}
+	saved, _ := config.Parse(configPath)
+	if len(saved) != 2 || saved[1].User != "deploy" || saved[1].Port != "2222" {
+		t.Errorf("config after quick add: %+v", saved)
+	}
+}
+
+// TestQuickAdd_AliasCollision verifies that a generated alias already used by
+// another host gets a suffix, and that typing a taken alias is refused.
+func TestQuickAdd_AliasCollision(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config")
+	content := "Host deploy-10.0.0.5\n    Hostname old.example.com\n"
+	if err := os.WriteFile(configPath, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	hosts, _ := config.Parse(configPath)
+	m := NewWithOptions(hosts, makeState(make(map[string]int)), "/tmp/state.json", Options{NoFrequent: true, ConfigPath: configPath})
+
+	m = pressKey(m, "A")
+	m = pressKey(m, "deploy@10.0.0.5")
+	m = pressSpecialKey(m, tea.KeyEnter)
+	if m.mode != modeEdit || m.edit.fields[fieldAlias] != "deploy-10.0.0.5-2" {
+		t.Fatalf("expected the suffixed alias deploy-10.0.0.5-2, got %+v", m.edit)
+	}
+
+	m.edit.fields[fieldAlias] = "deploy-10.0.0.5"
+	m = pressSpecialKey(m, tea.KeyEnter)
+	if m.mode != modeEdit || !strings.Contains(m.edit.statusMsg, "already in the config") {
+		t.Fatalf("expected a taken alias to be refused, got mode %v status %q", m.mode, m.statusMsg)
+	}
+	if data, _ := os.ReadFile(configPath); string(data) != content {
+		t.Errorf("config changed:\n%s", data)
+	}
+}
+
+func TestRenderList_ColumnsSizedToVisibleRows(t *testing.T) {
+	hosts := makeHosts("a1", "a2", "a3", "z-very-long-alias-far-below")
+	m := NewWithOptions(hosts, makeState(make(map[string]int)), "/tmp/state.json", Options{NoFrequent: true})
+	m.viewHeight = 2
+
+	lines := strings.Split(renderList(m), "\n")
+	if len(lines) != 3 {
+		t.Fatalf("expected header + 2 rows, got %d lines:\n%s", len(lines), strings.Join(lines, "\n"))
+	}
+	// The long alias is off screen, so ALIAS is only as wide as its label.
+	if !strings.Contains(lines[0], "ALIAS  HOSTNAME") {
+		t.Errorf("header not sized to visible rows: %q", lines[0])
+	}
+	col := strings.Index(lines[0], "HOSTNAME")
+	for _, row := range lines[1:] {
+		if strings.Index(row, ".example.com") != col+2 { // alias "a1" + hostname "a1.example.com"
+			t.Errorf("row not aligned with header:\n%s\n%s", lines[0], row)
+		}
+	}
+}
+
+func makeManyHosts(n int) []config.Host {
+	aliases := make([]string, n)
+	for i := range aliases {
+		aliases[i] = fmt.Sprintf("host-%05d", i)
+	}
+	return makeHosts(aliases...)
+}
+
+// BenchmarkRenderList_10kHosts measures one frame of the list; its cost
+// depends on the rows on screen, not the number of hosts.
+func BenchmarkRenderList_10kHosts(b *testing.B) {
+	m := NewWithOptions(makeManyHosts(10000), makeState(make(map[string]int)), "/tmp/state.json", Options{NoFrequent: true})
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		_ = renderList(m)
+	}
+}
+
+// BenchmarkApplySearch_EmptyQuery_10kHosts measures clearing the search,
+// which reuses the host list instead of copying it.
+func BenchmarkApplySearch_EmptyQuery_10kHosts(b *testing.B) {
+	m := NewWithOptions(makeManyHosts(10000), makeState(make(map[string]int)), "/tmp/state.json", Options{NoFrequent: true})
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		applySearch(&m)
+	}
+}
+
+func TestLastError_RecordedOnFailureAndClearedOnSuccess(t *testing.T) {
+	statePath := filepath.Join(t.TempDir(), "state.json")
+	hosts := makeHosts("prod", "web")
+	m := NewWithOptions(hosts, makeState(make(map[string]int)), statePath, Options{NoFrequent: true})
+	m.lastConnectedAlias = "prod"
+
+	updated, _ := m.Update(sshExitMsg{err: errors.New("exit status 255"), host: hosts[0]})
+	m = updated.(Model)
+	if got := m.state.LastError["prod"]; got != "exit status 255" {
+		t.Fatalf("LastError[prod] = %q; want exit status 255", got)
+	}
+	saved, _ := state.Load(statePath)
+	if saved.LastError["prod"] != "exit status 255" {
+		t.Errorf("failure not saved: %v", saved.LastError)
+	}
+
+	m.statusMsg = ""
+	m.retryHost = nil
+	view := m.View()
+	if !strings.Contains(view, ">xprod") || !strings.Contains(view, "last session failed: exit status 255") {
+		t.Errorf("marker or detail missing for prod:\n%s", view)
+	}
+	if strings.Contains(view, " !web") {
+		t.Errorf("web marked without an error:\n%s", view)
+	}
+
+	updated, _ = m.Update(sshExitMsg{host: hosts[0]})
+	m = updated.(Model)
+	if _, ok := m.state.LastError["prod"]; ok {
+		t.Error("successful session did not clear the error")
+	}
+	saved, _ = state.Load(statePath)
+	if len(saved.LastError) != 0 {
+		t.Errorf("cleared error still saved: %v", saved.LastError)
+	}
+}
+
+// TestConfirmDelete_YesRemovesHostAndShiftsLines verifies Ctrl+D then "y"
+// deletes the block from the config and allHosts, and moves later hosts up.
+func TestConfirmDelete_YesRemovesHostAndShiftsLines(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config")
+	content := "Host alpha\n    Hostname alpha.example.com\n\n# @group Work\nHost beta\n    Hostname beta.example.com\n\nHost gamma\n    Hostname gamma.example.com\n"
+	if err := os.WriteFile(configPath, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	hosts, err := config.Parse(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewWithOptions(hosts, makeState(make(map[string]int)), "/tmp/state.json", Options{NoFrequent: true})
+	selectAlias(&m, "beta")
+
+	m = pressSpecialKey(m, tea.KeyCtrlD)
+	if m.mode != modeConfirmDelete {
+		t.Fatalf("Ctrl+D did not ask for confirmation; status %q", m.statusMsg)
+	}
+	if view := m.View(); !strings.Contains(view, "Delete host beta? (y/n)") {
+		t.Errorf("view missing delete prompt:\n%s", view)
+	}
+
+	m = pressKey(m, "y")
+	if m.mode != modeNormal {
+		t.Errorf("expected normal mode after y, got %v", m.mode)
+	}
+	got, _ := os.ReadFile(configPath)
+	want := "Host alpha\n    Hostname alpha.example.com\n\nHost gamma\n    Hostname gamma.example.com\n"
+	if string(got) != want {
+		t.Errorf("config after delete:\n%s\nwant:\n%s", got, want)
+	}
+
+	var aliases []string
+	for _, h := range m.allHosts {
+		aliases = append(aliases, h.Alias)
+		if h.Alias == "gamma" && h.LineStart != 4 {
+			t.Errorf("expected gamma LineStart shifted to 4, got %d", h.LineStart)
+		}
+	}
+	if strings.Join(aliases, ",") != "alpha,gamma" {
+		t.Errorf("allHosts after delete = %v; want [alpha gamma]", aliases)
+	}
+	if len(m.filtered) != 2 {
+		t.Errorf("expected 2 filtered hosts, got %d", len(m.filtered))
+	}
+
+	// The shifted LineStart must still locate gamma's block.
+	selectAlias(&m, "gamma")
+	m = pressKey(m, "R")
+	if m.rawBlock == nil || m.rawBlock.lines[0] != "Host gamma" {
+		t.Errorf("gamma's block not found after delete; status %q", m.statusMsg)
+	}
+}
+
+// TestConfirmDelete_NoAndEscCancel verifies "n" and Esc leave the config alone.
+func TestConfirmDelete_NoAndEscCancel(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config")
+	content := "Host alpha\n    Hostname alpha.example.com\n"
+	if err := os.WriteFile(configPath, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	hosts, err := config.Parse(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := New(hosts, makeState(make(map[string]int)), "/tmp/state.json", false)
+
+	for _, cancel := range []func(Model) Model{
+		func(m Model) Model { return pressKey(m, "n") },
+		func(m Model) Model { return pressSpecialKey(m, tea.KeyEsc) },
+	} {
+		m = pressSpecialKey(m, tea.KeyCtrlD)
+		m = pressKey(m, "x") // ignored while the prompt is open
+		if m.mode != modeConfirmDelete {
+			t.Fatalf("expected prompt to stay open, got mode %v", m.mode)
+		}
+		m = cancel(m)
+		if m.mode != modeNormal || m.pendingDelete != nil {
+			t.Errorf("expected cancel to return to normal mode, got mode %v", m.mode)
+		}
+	}
+	if got, _ := os.ReadFile(configPath); string(got) != content {
+		t.Errorf("config changed after cancelling:\n%s", got)
+	}
+	if len(m.allHosts) != 1 {
+		t.Errorf("expected alpha to remain, got %d hosts", len(m.allHosts))
+	}
+}
+
+// TestConfirmDelete_MultiPatternBlockKeepsSiblings verifies that deleting one
+// alias of a multi-pattern Host line is refused and keeps both hosts.
+func TestConfirmDelete_MultiPatternBlockKeepsSiblings(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "config")
+	content := "Host web1 web2\n    Hostname web.example.com\n"
+	if err := os.WriteFile(configPath, []byte(content), 0600); err != nil {
+		t.Fatal(err)
+	}
+	hosts, err := config.Parse(configPath)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := New(hosts, makeState(make(map[string]int)), "/tmp/state.json", false)
+
+	m = pressSpecialKey(m, tea.KeyCtrlD)
+	if view := m.View(); !strings.Contains(view, "Delete host "+m.pendingDelete.Alias+"? (y/n)") {
+		t.Errorf("view missing delete prompt:\n%s", view)
+	}
+	m = pressKey(m, "y")
+
+	if !strings.HasPrefix(m.statusMsg, "Delete failed") {
+		t.Errorf("expected the delete to be refused, got status %q", m.statusMsg)
+	}
+	if got, _ := os.ReadFile(configPath); string(got) != content {
+		t.Errorf("config must not change, got:\n%s", got)
+	}
+	if got := aliasesOf(m.allHosts); got != "web1,web2" {
+		t.Errorf("allHosts = %s; want web1,web2", got)
+	}
+}
+
+// runAutoConnectTimer runs cmd, the command returned for a search keystroke,
+// and returns the autoConnectMsg it produces, if any.
+func runAutoConnectTimer(cmd tea.Cmd) (autoConnectMsg, bool) {
+	if cmd == nil {
+		return autoConnectMsg{}, false
+	}
+	msg := cmd()
+	if batch, ok := msg.(tea.BatchMsg); ok {
+		for _, c := range batch {
+			if c == nil {
+				continue
+			}
+			if ac, ok := c().(autoConnectMsg); ok {
+				return ac, true
+			}
+		}
+		return autoConnectMsg{}, false
+	}
+	ac, ok := msg.(autoConnectMsg)
+	return ac, ok
+}
+
+// TestAutoConnect_UniqueMatchConnectsAfterDelay verifies that with
+// AutoConnect a lone match is connected once the delay passes, and that a
+// query changed in the meantime cancels it.
+func TestAutoConnect_UniqueMatchConnectsAfterDelay(t *testing.T) {
+	hosts := makeHosts("alpha", "beta")
+	m := NewWithOptions(hosts, makeState(make(map[string]int)), filepath.Join(t.TempDir(), "state.json"), Options{AutoConnect: true, NoHistory: true})
+	m.autoConnect = time.Millisecond
+
+	newModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("b")})
+	m = newModel.(Model)
+	if len(m.filtered) != 1 {
+		t.Fatalf("expected one match for %q, got %d", m.searchQuery, len(m.filtered))
+	}
+	msg, ok := runAutoConnectTimer(cmd)
+	if !ok {
+		t.Fatal("expected a lone match to schedule an auto-connect")
+	}
+
+	newModel, cmd = m.Update(msg)
+	m = newModel.(Model)
+	if cmd == nil || m.lastConnectedAlias != "beta" {
+		t.Errorf("expected auto-connect to beta, got cmd=%v last=%q", cmd != nil, m.lastConnectedAlias)
+	}
+
+	// A timer from before the connect is ignored.
+	m.lastConnectedAlias = ""
+	if _, cmd := m.Update(autoConnectMsg{gen: msg.gen}); cmd != nil {
+		t.Error("expected a stale auto-connect to do nothing")
+	}
+}
+
+// TestAutoConnect_StaleAfterKeyOrConnect verifies that a pending auto-connect
+// is dropped when a key is pressed or a connection is made before it fires,
+// even if the query ends up the same.
+func TestAutoConnect_StaleAfterKeyOrConnect(t *testing.T) {
+	hosts := makeHosts("alpha", "beta")
+	m := NewWithOptions(hosts, makeState(make(map[string]int)), filepath.Join(t.TempDir(), "state.json"), Options{AutoConnect: true, NoHistory: true})
+	m.autoConnect = time.Millisecond
+
+	newModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("b")})
+	m = newModel.(Model)
+	pending, ok := runAutoConnectTimer(cmd)
+	if !ok {
+		t.Fatal("expected a lone match to schedule an auto-connect")
+	}
+
+	// Enter connects before the timer fires; the query is unchanged after.
+	newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyEnter})
+	m = newModel.(Model)
+	m.lastConnectedAlias = ""
+	if _, ok := autoConnectTarget(m); !ok {
+		t.Fatal("expected beta to still be the lone match")
+	}
+	if _, cmd := m.Update(pending); cmd != nil {
+		t.Error("expected the auto-connect to be dropped after Enter connected")
+	}
+
+	// A key that leaves the query alone also makes it stale.
+	m.mode = modeSearch
+	newModel, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("e")})
+	m = newModel.(Model)
+	pending, _ = runAutoConnectTimer(cmd)
+	newModel, _ = m.Update(tea.KeyMsg{Type: tea.KeyDown})
+	if _, cmd := newModel.Update(pending); cmd != nil {
+		t.Error("expected the auto-connect to be dropped after another key")
+	}
+}
+
+// TestAutoConnect_GuardedAndDisabled verifies a lone match in a prod group is
+// never auto-connected, nor is anything when the option is off.
+func TestAutoConnect_GuardedAndDisabled(t *testing.T) {
+	hosts := makeHosts("alpha", "beta")
+	hosts[1].Groups = []string{"Prod"}
+	m := NewWithOptions(hosts, makeState(make(map[string]int)), filepath.Join(t.TempDir(), "state.json"), Options{AutoConnect: true, NoHistory: true})
+	m.autoConnect = time.Millisecond
+
+	newModel, cmd := m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("b")})
+	m = newModel.(Model)
+	if _, ok := runAutoConnectTimer(cmd); ok {
+		t.Error("expected no auto-connect for a prod host")
+	}
+	if _, cmd := m.Update(autoConnectMsg{gen: m.autoConnectGen}); cmd != nil {
+		t.Error("expected a prod host to need Enter")
+	}
+
+	m = NewWithOptions(makeHosts("alpha", "beta"), makeState(make(map[string]int)), filepath.Join(t.TempDir(), "state.json"), Options{NoHistory: true})
+	newModel, cmd = m.Update(tea.KeyMsg{Type: tea.KeyRunes, Runes: []rune("b")})
+	if _, ok := runAutoConnectTimer(cmd); ok {
+		t.Error("expected no auto-connect without the option")
+	}
+	if _, cmd := newModel.Update(autoConnectMsg{gen: newModel.(Model).autoConnectGen}); cmd != nil {
+		t.Error("expected no auto-connect without the option")
+	}
+}
+
+// TestNoReverse_SelectedRowUsesContrastColors verifies that NoReverse swaps
+// the reverse-video highlight for explicit colors.
+func TestNoReverse_SelectedRowUsesContrastColors(t *testing.T) {
+	m := New(makeHosts("alpha", "beta"), makeState(make(map[string]int)), "/tmp/state.json", false)
+	if !m.highlight.GetReverse() {
+		t.Error("expected reverse video by default")
+	}
+
+	m = NewWithOptions(makeHosts("alpha", "beta"), makeState(make(map[string]int)), "/tmp/state.json", Options{NoReverse: true})
+	if m.highlight.GetReverse() {
+		t.Error("expected no reverse video with NoReverse")
+	}
+	if m.highlight.GetBackground() != lipgloss.Color("11") || m.highlight.GetForeground() != lipgloss.Color("0") {
+		t.Errorf("expected black on bright yellow, got fg %v bg %v", m.highlight.GetForeground(), m.highlight.GetBackground())
+	}
+}
+
+// TestInline_FixedHeightFrame verifies that inline mode caps the list height
+// and pads the frame so its height does not change as the filter narrows.
+func TestInline_FixedHeightFrame(t *testing.T) {
+	m := NewWithOptions(makeManyHosts(30), makeState(make(map[string]int)), "/tmp/state.json", Options{Inline: true})
+	newModel, _ := m.Update(tea.WindowSizeMsg{Width: 80, Height: 50})
+	m = newModel.(Model)
+	if m.viewHeight != inlineRows {
+		t.Fatalf("viewHeight %d; want %d", m.viewHeight, inlineRows)
+	}
+
+	full := strings.Count(m.View(), "\n")
+	m = pressKey(m, m.filtered[3].Alias)
+	if len(m.filtered) >= inlineRows {
+		t.Fatalf("expected the search to narrow the list, got %d hosts", len(m.filtered))
+	}
+	if got := strings.Count(m.View(), "\n"); got != full {
+		t.Errorf("frame height changed from %d to %d lines while filtering", full, got)
+	}
+}
+
+// TestHelp_ToggleOverlay verifies that "?" opens the help overlay, any key
+// closes it without acting, and the overlay lists the keybindings.
+func TestHelp_ToggleOverlay(t *testing.T) {
+	m := New(makeHosts("alpha", "beta"), makeState(make(map[string]int)), "/tmp/state.json", false)
+	newModel, _ := m.Update(tea.WindowSizeMsg{Width: 100, Height: 60})
+	m = newModel.(Model)
+
+	m = pressKey(m, "?")
+	if m.mode != modeHelp {
+		t.Fatalf("expected modeHelp after ?, got %v", m.mode)
+	}
+	view := m.View()
+	for _, want := range []string{"ctrl+e", "edit the selected host", "ctrl+r"} {
+		if !strings.Contains(view, want) {
+			t.Errorf("help view missing %q:\n%s", want, view)
+		}
+	}
+
+	m = pressSpecialKey(m, tea.KeyDown)
+	if m.mode != modeNormal {
+		t.Errorf("expected any key to close help, got mode %v", m.mode)
+	}
+	if m.cursor != 0 {
+		t.Errorf("closing help should not move the cursor, got %d", m.cursor)
+	}
+}
+
+// TestSFTPArgs verifies that F builds sftp arguments with the uppercase port
+// flag and the custom config, passing only an inherited IdentityFile.
+func TestSFTPArgs(t *testing.T) {
+	m := NewWithOptions(makeHosts("alpha"), makeState(make(map[string]int)), "/tmp/state.json", Options{ConfigPath: "/tmp/cfg"})
+	host := config.Host{Alias: "web", User: "deploy", Port: "2222", IdentityFile: "/keys/own"}
+
+	if got := strings.Join(sftpArgs(m, host), " "); got != "-F /tmp/cfg -P 2222 deploy@web" {
+		t.Errorf("sftpArgs = %q", got)
+	}
+
+	host.Inherited = map[string]string{"IdentityFile": "/keys/group"}
+	host.IdentityFile = "/keys/group"
+	if got := strings.Join(sftpArgs(m, host), " "); got != "-F /tmp/cfg -i /keys/group -P 2222 deploy@web" {
+		t.Errorf("sftpArgs with inherited key = %q", got)
+	}
+}
+
+// TestGroupFilter_RestrictsAndClears verifies that picking a group with G
+// lists only hosts tagged with it, searches stay inside it, and choosing
+// "All" or pressing Esc restores the full list.
+func TestGroupFilter_RestrictsAndClears(t *testing.T) {
+	hosts := makeHosts("api", "db", "home", "nas")
+	hosts[0].Groups = []string{"Work"}
+	hosts[1].Groups = []string{"Work", "DB"}
+	hosts[2].Groups = []string{"Personal"}
+	m := New(hosts, makeState(make(map[string]int)), "/tmp/state.json", false)
+
+	m = pressKey(m, "G")
+	if m.mode != modeGroupFilter {
+		t.Fatalf("expected modeGroupFilter, got %v", m.mode)
+	}
+	// Rows: All, DB, Personal, Work.
+	for i := 0; i < 3; i++ {
+		m = pressSpecialKey(m, tea.KeyDown)
+	}
+	m = pressSpecialKey(m, tea.KeyEnter)
+
+	if m.mode != modeNormal || m.activeGroup != "Work" {
+		t.Fatalf("expected Work filter in normal mode, got group %q mode %v", m.activeGroup, m.mode)
+	}
+	if got := aliasesOf(m.filtered); got != "api,db" {
+		t.Errorf("filtered = %s; want api,db", got)
+	}
+	if !strings.Contains(renderStatusBar(m), "in Work") {
+		t.Errorf("status bar should show the active group: %q", renderStatusBar(m))
+	}
+
+	m = pressKey(m, "d")
+	if got := aliasesOf(m.filtered); got != "db" {
+		t.Errorf("search within group = %s; want db", got)
+	}
+	m = pressSpecialKey(m, tea.KeyEsc)
+
+	// Reopening starts on the active group; Esc clears it.
+	m = pressKey(m, "G")
+	if m.groupCursor != 3 {
+		t.Errorf("expected cursor on Work (3), got %d", m.groupCursor)
+	}
+	m = pressSpecialKey(m, tea.KeyEsc)
+	if m.activeGroup != "" || len(m.filtered) != 4 {
+		t.Errorf("Esc should clear the filter, got group %q and %d hosts", m.activeGroup, len(m.filtered))
+	}
+
+	// So does picking "All".
+	m.activeGroup = "Personal"
+	refilter(&m)
+	m = pressKey(m, "G")
+	m.groupCursor = 0
+	m = pressSpecialKey(m, tea.KeyEnter)
+	if m.activeGroup != "" || len(m.filtered) != 4 {
+		t.Errorf("All should clear the filter, got group %q and %d hosts", m.activeGroup, len(m.filtered))
+	}
+}
+
+// aliasesOf joins the aliases of hosts with commas.
+func aliasesOf(hosts []config.Host) string {
+	aliases := make([]string, len(hosts))
+	for i, h := range hosts {
+		aliases[i] = h.Alias
+	}
+	return strings.Join(aliases, ",")
+}
+
+// TestUsesColumn verifies that the USES column shows each host's connection
+// count right-aligned, "-" for none, and stays aligned on the selected row.
+func TestUsesColumn(t *testing.T) {
+	st := makeState(map[string]int{"alpha": 12, "beta": 3})
+	m := NewWithOptions(makeHosts("alpha", "beta", "gamma"), st, "/tmp/state.json", Options{ShowUses: true})
+	newModel, _ := m.Update(tea.WindowSizeMsg{Width: 120, Height: 20})
+	m = newModel.(Model)
+
+	lines := strings.Split(renderList(m), "\n")
+	if !strings.Contains(lines[0], "USES") {
+		t.Fatalf("header missing USES: %q", lines[0])
+	}
+	col := strings.Index(lines[0], "USES") + len("USES")
+	for i, want := range map[int]string{1: "12", 2: "3", 3: "-"} {
+		// No groups, so the count ends the row.
+		plain := strings.TrimRight(lines[i], " ")
+		if !strings.HasSuffix(plain, " "+want) || len(plain) != col {
+			t.Errorf("row %d: expected %q right-aligned under USES (col %d): %q", i, want, col, plain)
+		}
+	}
+
+	m = NewWithOptions(makeHosts("alpha"), st, "/tmp/state.json", Options{})
+	if strings.Contains(renderList(m), "USES") {
+		t.Error("USES column should be off by default")
+	}
+}
@@ -0,0 +1,116 @@
+package tui
+
+import (
+	"context"
+	"net"
+	"time"
+
+	tea "github.com/charmbracelet/bubbletea"
+)
+
+// Resolver looks up the addresses of a hostname. net.DefaultResolver
+// satisfies it; tests inject a fake.
+type Resolver interface {
+	LookupHost(ctx context.Context, host string) ([]string, error)
+}
+
+const (
+	resolveTTL     = time.Minute     // how long a lookup result is reused
+	resolveTimeout = 2 * time.Second // per-lookup deadline
+	resolveWorkers = 8               // max lookups in flight
+)
+
+// resolvedEntry is the cached result of resolving one hostname.
+type resolvedEntry struct {
+	ip     string // first address; "" if the lookup failed
+	expiry time.Time
+}
+
+// resolveCache holds lookup results by hostname. It is shared across Model
+// copies and only touched from Update; the lookups themselves run in tea.Cmds
+// limited to resolveWorkers at a time by sem.
+type resolveCache struct {
+	entries map[string]resolvedEntry
+	pending map[string]bool
+	sem     chan struct{}
+	now     func() time.Time
+}
+
+func newResolveCache() *resolveCache {
+	return &resolveCache{
+		entries: make(map[string]resolvedEntry),
+		pending: make(map[string]bool),
+		sem:     make(chan struct{}, resolveWorkers),
+		now:     time.Now,
+	}
+}
+
+// resolvedMsg carries the outcome of one lookup back to Update.
+type resolvedMsg struct {
+	hostname string
+	ip       string
+}
+
+// resolveVisible returns a command resolving every on-screen hostname that is
+// not an IP literal and has no fresh or in-flight lookup, or nil if there is
+// nothing to do.
+func resolveVisible(m Model) tea.Cmd {
+	if !m.showResolved {
+		return nil
+	}
+	c := m.resolved
+	var cmds []tea.Cmd
+	end := min(m.viewport+m.viewHeight, m.visibleLen())
+	for i := m.viewport; i < end; i++ {
+		hn := m.filtered[i].Hostname
+		if hn == "" || net.ParseIP(hn) != nil || c.pending[hn] {
+			continue
+		}
+		if e, ok := c.entries[hn]; ok && c.now().Before(e.expiry) {
+			continue
+		}
+		c.pending[hn] = true
+		cmds = append(cmds, lookupCmd(m.resolver, c.sem, hn))
+	}
+	if len(cmds) == 0 {
+		return nil
+	}
+	return tea.Batch(cmds...)
+}
+
+// lookupCmd resolves hostname once a worker slot is free.
+func lookupCmd(r Resolver, sem chan struct{}, hostname string) tea.Cmd {
+	return func() tea.Msg {
+		sem <- struct{}{}
+		defer func() { <-sem }()
+		ctx, cancel := context.WithTimeout(context.Background(), resolveTimeout)
+		defer cancel()
+		msg := resolvedMsg{hostname: hostname}
+		if addrs, err := r.LookupHost(ctx, hostname); err == nil && len(addrs) > 0 {
+			msg.ip = addrs[0]
+		}
+		return msg
+	}
+}
+
+// store records a finished lookup.
+func (c *resolveCache) store(msg resolvedMsg) {
+	delete(c.pending, msg.hostname)
+	c.entries[msg.hostname] = resolvedEntry{ip: msg.ip, expiry: c.now().Add(resolveTTL)}
+}
+
+// annotation returns the text shown after hostname while resolution is on:
+// the resolved IP, "?" if the lookup failed, "…" while it is in flight, or ""
+// for IP literals and hostnames not yet looked up.
+func (c *resolveCache) annotation(hostname string) string {
+	if e, ok := c.entries[hostname]; ok {
+		if e.ip == "" {
+			return "?"
+		}
+		return e.ip
+	}
+	if c.pending[hostname] {
+		return "…"
+	}
+	return ""
+}
@@ -2,57 +2,101 @@ package tui
 
 import (
 	"fmt"
+	"hash/fnv"
+	"net"
+	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/charmbracelet/lipgloss"
+	"github.com/mattn/go-runewidth"
 	"github.com/srava/swiftssh/internal/config"
+	"github.com/srava/swiftssh/internal/ssh"
 )
 
 var (
 	titleStyle    = lipgloss.NewStyle().Bold(true)
 	selectedStyle = lipgloss.NewStyle().Reverse(true)
+	// contrastStyle replaces selectedStyle with --no-reverse: fixed black on
+	// bright yellow reads well on both dark and light terminals.
+	contrastStyle = lipgloss.NewStyle().Foreground(lipgloss.Color("0")).Background(lipgloss.Color("11")).Bold(true)
 	dimStyle      = lipgloss.NewStyle().Faint(true)
-	tagStyle      = lipgloss.NewStyle().Foreground(lipgloss.Color("240"))
 	statusStyle   = lipgloss.NewStyle().Faint(true)
+	addedStyle    = lipgloss.NewStyle().Foreground(lipgloss.Color("2"))
+	removedStyle  = lipgloss.NewStyle().Foreground(lipgloss.Color("1"))
 )
 
-// padRight pads s with spaces on the right to exactly width characters.
-// If s is already width or longer, it is returned as-is.
+// groupPalette holds ANSI color indices so group colors follow the terminal theme.
+var groupPalette = []lipgloss.Color{"1", "2", "3", "4", "5", "6", "9", "10", "11", "12", "13", "14"}
+
+// groupColor deterministically maps a group name to a palette color.
+func groupColor(name string) lipgloss.Color {
+	h := fnv.New32a()
+	h.Write([]byte(strings.ToLower(name)))
+	return groupPalette[h.Sum32()%uint32(len(groupPalette))]
+}
+
+// renderGroups renders each group as a colored "[name]" tag.
+func renderGroups(groups []string) string {
+	parts := make([]string, len(groups))
+	for i, g := range groups {
+		parts[i] = lipgloss.NewStyle().Foreground(groupColor(g)).Render("[" + g + "]")
+	}
+	return strings.Join(parts, " ")
+}
+
+// padRight pads s with spaces on the right to exactly width display cells.
+// If s is already width or wider, it is returned as-is.
 func padRight(s string, width int) string {
-	if len(s) >= width {
+	w := runewidth.StringWidth(s)
+	if w >= width {
+		return s
+	}
+	return s + strings.Repeat(" ", width-w)
+}
+
+// padLeft right-aligns s in width display cells.
+func padLeft(s string, width int) string {
+	w := runewidth.StringWidth(s)
+	if w >= width {
 		return s
 	}
-	return s + strings.Repeat(" ", width-len(s))
+	return strings.Repeat(" ", width-w) + s
 }
 
-// truncateStr truncates s to at most maxW bytes, appending "…" if truncated.
+// truncateStr truncates s to at most maxW display cells, appending "…" if
+// truncated. It never splits a rune, so the result is always valid UTF-8.
 func truncateStr(s string, maxW int) string {
 	if maxW <= 0 {
 		return ""
 	}
-	if len(s) <= maxW {
+	if runewidth.StringWidth(s) <= maxW {
 		return s
 	}
-	if maxW == 1 {
-		return s[:1]
-	}
-	return s[:maxW-1] + "~" // use ~ to stay single-byte safe
+	return runewidth.Truncate(s, maxW, "…")
 }
 
 // colWidths computes per-column widths from the host list, floored at the
-// header label widths and capped at reasonable maximums.
-func colWidths(hosts []config.Host) (aliasW, hostW, userW int) {
+// header label widths and capped at reasonable maximums. usesW is 0 when the
+// USES column is off.
+func colWidths(m Model, hosts []config.Host) (aliasW, hostW, userW, usesW int) {
 	aliasW = len("ALIAS")
 	hostW = len("HOSTNAME")
 	userW = len("USER")
+	if m.showUses {
+		usesW = len("USES")
+	}
 	for _, h := range hosts {
-		if n := len(h.Alias); n > aliasW {
+		if m.showUses {
+			usesW = max(usesW, len(usesLabel(m, h)))
+		}
+		if n := runewidth.StringWidth(h.Alias); n > aliasW {
 			aliasW = n
 		}
-		if n := len(h.Hostname); n > hostW {
+		if n := runewidth.StringWidth(m.displayHostname(h)); n > hostW {
 			hostW = n
 		}
-		if n := len(h.User); n > userW {
+		if n := runewidth.StringWidth(h.User); n > userW {
 			userW = n
 		}
 	}
@@ -72,89 +116,431 @@ func colWidths(hosts []config.Host) (aliasW, hostW, userW int) {
 // renderHeader returns the header line for the TUI.
 func renderHeader(m Model) string {
 	header := titleStyle.Render("SwiftSSH")
+	if m.matchMode == MatchPrefix {
+		header += " " + dimStyle.Render("[prefix]")
+	}
 	switch m.mode {
 	case modeSearch:
 		header += "  " + m.searchQuery + "█"
+	case modePrompt:
+		header += "  / " + m.searchQuery + "█  " + dimStyle.Render("↑/↓ history · Enter done")
 	case modeNormal:
 		header += "  " + dimStyle.Render("Type to search")
 	}
 	return header
 }
 
+// renderFavorites returns the favorites bar ("★ 1:prod  2:db"), or "" when
+// nothing is pinned.
+func renderFavorites(m Model) string {
+	favs := favorites(m)
+	if len(favs) == 0 {
+		return ""
+	}
+	chips := make([]string, len(favs))
+	for i, h := range favs {
+		chips[i] = fmt.Sprintf("%d:%s", i+1, h.Alias)
+	}
+	return dimStyle.Render(truncateStr("★ "+strings.Join(chips, "  "), m.width))
+}
+
 // renderList returns the column-aligned, scrollable list of hosts.
 func renderList(m Model) string {
 	if len(m.filtered) == 0 {
 		return dimStyle.Render("  No hosts found.")
 	}
 
-	aliasW, hostW, userW := colWidths(m.filtered)
+	// Render from a viewport that contains the cursor even if the stored one is
+	// stale, so the selected host is never scrolled out of a short terminal.
+	m.viewHeight = max(m.viewHeight, 1)
+	scrollToCursor(&m)
+	end := min(m.viewport+m.viewHeight, m.visibleLen())
+
+	// Size columns to the rows on screen only, so a frame costs the same for
+	// ten hosts as for ten thousand.
+	aliasW, hostW, userW, usesW := colWidths(m, m.filtered[m.viewport:end])
 
 	// Column header row (always visible, above the scrolling viewport)
 	headerStr := "  " +
 		padRight("ALIAS", aliasW) + "  " +
 		padRight("HOSTNAME", hostW) + "  " +
-		padRight("USER", userW) + "  " +
-		"GROUPS"
+		padRight("USER", userW) + "  "
+	if usesW > 0 {
+		headerStr += padLeft("USES", usesW) + "  "
+	}
+	headerStr += "GROUPS"
 	rows := []string{dimStyle.Render(headerStr)}
-
-	end := min(m.viewport+m.viewHeight, len(m.filtered))
 	for i := m.viewport; i < end; i++ {
-		rows = append(rows, renderRow(m, i, aliasW, hostW, userW))
+		rows = append(rows, renderRow(m, i, aliasW, hostW, userW, usesW))
+	}
+	if hidden := m.hiddenCount(); hidden > 0 && end == m.visibleLen() {
+		rows = append(rows, dimStyle.Render(fmt.Sprintf("  … and %d more (press +)", hidden)))
 	}
 
 	return strings.Join(rows, "\n")
 }
 
 // renderRow returns the rendered display for a single host at index i.
-// Column widths must be passed in so all rows share the same alignment.
-func renderRow(m Model, i, aliasW, hostW, userW int) string {
+// Column widths must be passed in so all rows share the same alignment; a
+// usesW of 0 leaves out the USES column.
+func renderRow(m Model, i, aliasW, hostW, userW, usesW int) string {
 	h := m.filtered[i]
 	isSelected := i == m.cursor
 
 	alias := padRight(truncateStr(h.Alias, aliasW), aliasW)
-	hostname := padRight(truncateStr(h.Hostname, hostW), hostW)
+	hostname := padRight(truncateStr(m.displayHostname(h), hostW), hostW)
 	user := h.User
 	if user == "" {
 		user = "-"
 	}
 	userStr := padRight(truncateStr(user, userW), userW)
+	uses := ""
+	if usesW > 0 {
+		uses = "  " + padLeft(usesLabel(m, h), usesW)
+	}
 
 	var groupParts []string
-	for _, g := range h.Groups {
+	displayGroups := m.groupsOf(h)
+	for _, g := range displayGroups {
 		groupParts = append(groupParts, "["+g+"]")
 	}
 	groups := strings.Join(groupParts, " ")
 
-	prefix := "  "
+	marker := rowMarker(m, h)
+	prefix := " " + marker
 	if isSelected {
-		prefix = "> "
+		prefix = ">" + marker
+	} else if marker != " " {
+		prefix = " " + removedStyle.Render(marker)
 	}
 
 	if isSelected {
-		// Render plain text so selectedStyle (reverse video) works cleanly
-		row := prefix + alias + "  " + hostname + "  " + userStr
+		// Render plain text so the highlight style works cleanly
+		row := prefix + alias + "  " + hostname + "  " + userStr + uses
 		if groups != "" {
 			row += "  " + groups
 		}
-		return selectedStyle.Render(row)
+		return m.highlight.Render(row)
 	}
 
 	// Non-selected: dim secondary columns, color group tags
-	row := prefix + alias + "  " + dimStyle.Render(hostname) + "  " + dimStyle.Render(userStr)
+	row := prefix + alias + "  " + dimStyle.Render(hostname) + "  " + dimStyle.Render(userStr+uses)
 	if groups != "" {
-		row += "  " + tagStyle.Render(groups)
+		row += "  " + renderGroups(displayGroups)
 	}
 	return row
 }
 
+// usesLabel returns h's connection count for the USES column, or "-" for
+// none.
+func usesLabel(m Model, h config.Host) string {
+	if m.state == nil || m.state.Connections[h.Alias] == 0 {
+		return "-"
+	}
+	return strconv.Itoa(m.state.Connections[h.Alias])
+}
+
+// displayHostname returns h's hostname as shown in the list: with the common
+// domain suffix replaced by "…" when stripping is on. The config is unchanged.
+// With resolution on (Ctrl+\), the current IP follows in parentheses.
+func (m Model) displayHostname(h config.Host) string {
+	name := h.Hostname
+	if m.stripDomain && m.domainSuffix != "" {
+		n := len(h.Hostname) - len(m.domainSuffix)
+		if n > 0 && strings.EqualFold(h.Hostname[n:], m.domainSuffix) {
+			name = h.Hostname[:n] + "…"
+		}
+	}
+	if m.showResolved {
+		if ip := m.resolved.annotation(h.Hostname); ip != "" {
+			name += " (" + ip + ")"
+		}
+	}
+	return name
+}
+
+// commonDomainSuffix returns the longest domain suffix (with its leading dot,
+// e.g. ".example.com") shared by more than half of the hostnames, or "" if
+// there is none. IP addresses are ignored, suffixes must have at least two
+// labels, and every matching hostname must keep at least one label.
+func commonDomainSuffix(hostnames []string) string {
+	counts := make(map[string]int)
+	total := 0
+	for _, hn := range hostnames {
+		if hn == "" || net.ParseIP(hn) != nil {
+			continue
+		}
+		total++
+		labels := strings.Split(strings.ToLower(hn), ".")
+		for i := 1; i <= len(labels)-2; i++ {
+			counts["."+strings.Join(labels[i:], ".")]++
+		}
+	}
+
+	best := ""
+	for suffix, n := range counts {
+		if n*2 <= total {
+			continue
+		}
+		if len(suffix) > len(best) || (len(suffix) == len(best) && suffix < best) {
+			best = suffix
+		}
+	}
+	return best
+}
+
+// rowMarker returns a single-cell marker flagging a problem with h, or a space.
+// "!" means the host's IdentityFile permissions are too open for ssh; "x"
+// means its last ssh session failed. The key problem wins when both apply.
+func rowMarker(m Model, h config.Host) string {
+	switch {
+	case m.insecureKeys[h.IdentityFile]:
+		return "!"
+	case lastError(m, h) != "":
+		return "x"
+	}
+	return " "
+}
+
+// lastError returns why h's last ssh session failed, or "" if it did not.
+func lastError(m Model, h config.Host) string {
+	if m.state == nil {
+		return ""
+	}
+	return m.state.LastError[h.Alias]
+}
+
+// legendGroups returns the distinct group names across all hosts, as shown
+// in the list, sorted case-insensitively.
+func legendGroups(m Model) []string {
+	seen := make(map[string]bool)
+	var groups []string
+	for _, h := range m.allHosts {
+		for _, g := range m.groupsOf(h) {
+			if !seen[g] {
+				seen[g] = true
+				groups = append(groups, g)
+			}
+		}
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return strings.ToLower(groups[i]) < strings.ToLower(groups[j])
+	})
+	return groups
+}
+
+// renderLegend returns a single line mapping each group to its color swatch.
+func renderLegend(m Model) string {
+	groups := legendGroups(m)
+	if len(groups) == 0 {
+		return dimStyle.Render("No groups.")
+	}
+	parts := make([]string, len(groups))
+	for i, g := range groups {
+		parts[i] = lipgloss.NewStyle().Foreground(groupColor(g)).Render("■") + " " + g
+	}
+	return dimStyle.Render("Groups: ") + strings.Join(parts, "  ")
+}
+
+// renderPicker renders the identity picker opened with Ctrl+K.
+func renderPicker(m Model) string {
+	p := m.picker
+	var sb strings.Builder
+	sb.WriteString(titleStyle.Render("Identity for " + p.host.Alias))
+	sb.WriteString("\n\n")
+	for i, key := range p.keys {
+		row := "  " + padRight(ssh.KeyLabel(key), 24) + "  " + key
+		if i == p.cursor {
+			row = m.highlight.Render(">" + row[1:])
+		}
+		sb.WriteString(row)
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n")
+	sb.WriteString(statusStyle.Render("Enter: connect with key | Ctrl+S: save to config & connect | Esc: cancel"))
+	return sb.String()
+}
+
+// renderGroupFilter renders the group list opened with G.
+func renderGroupFilter(m Model) string {
+	var sb strings.Builder
+	sb.WriteString(titleStyle.Render("Filter by group"))
+	sb.WriteString("\n\n")
+	for i, g := range append([]string{"All"}, legendGroups(m)...) {
+		row := "  " + g
+		if i > 0 {
+			row = "  " + lipgloss.NewStyle().Foreground(groupColor(g)).Render("■") + " " + g
+		}
+		if i == m.groupCursor {
+			row = m.highlight.Render("> " + g)
+		}
+		sb.WriteString(row)
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n")
+	sb.WriteString(statusStyle.Render("Enter: filter | Esc: show all"))
+	return sb.String()
+}
+
+// helpBoxStyle frames the help overlay.
+var helpBoxStyle = lipgloss.NewStyle().Border(lipgloss.RoundedBorder()).Padding(0, 2)
+
+// renderHelp renders the help overlay opened with "?", built from
+// helpSections and centered in the terminal once its size is known.
+func renderHelp(m Model) string {
+	width := 0
+	for _, s := range helpSections {
+		for _, k := range s.keys {
+			width = max(width, runewidth.StringWidth(k.key))
+		}
+	}
+
+	var sb strings.Builder
+	sb.WriteString(titleStyle.Render("Keys"))
+	for _, s := range helpSections {
+		sb.WriteString("\n\n" + dimStyle.Render(s.title))
+		for _, k := range s.keys {
+			sb.WriteString("\n" + padRight(k.key, width) + "  " + k.desc)
+		}
+	}
+	sb.WriteString("\n\n" + statusStyle.Render("Press any key to close"))
+
+	box := helpBoxStyle.Render(sb.String())
+	if m.width == 0 || m.height == 0 {
+		return box
+	}
+	return lipgloss.Place(m.width, m.height, lipgloss.Center, lipgloss.Center, box)
+}
+
+// renderSessions renders the active-sessions panel opened with Ctrl+A.
+func renderSessions(m Model) string {
+	sessions := m.sessions.List()
+	var sb strings.Builder
+	sb.WriteString(titleStyle.Render("Active sessions"))
+	sb.WriteString("\n\n")
+	if len(sessions) == 0 {
+		sb.WriteString(dimStyle.Render("  No active sessions. Ctrl+O inside tmux opens one in a new window."))
+		sb.WriteString("\n")
+	}
+	for i, s := range sessions {
+		row := fmt.Sprintf("  %s  %-7s %s  %s",
+			padRight(truncateStr(s.Alias, 20), 20), s.ID, s.Started.Format("15:04"), s.Launcher)
+		if i == m.sessionCursor {
+			row = m.highlight.Render(">" + row[1:])
+		}
+		sb.WriteString(row)
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n")
+	if m.statusMsg != "" {
+		sb.WriteString(statusStyle.Render(m.statusMsg))
+		sb.WriteString("\n")
+	}
+	sb.WriteString(statusStyle.Render("Enter: focus | Ctrl+K: kill | Esc: back"))
+	return sb.String()
+}
+
+// renderRawBlock renders the raw block panel opened with R: the host's lines
+// as they are in its config file, numbered, scrolled by ↑/↓.
+func renderRawBlock(m Model) string {
+	v := m.rawBlock
+	var sb strings.Builder
+	sb.WriteString(titleStyle.Render(v.alias))
+	sb.WriteString("  " + dimStyle.Render(v.source))
+	sb.WriteString("\n\n")
+	end := min(v.offset+m.viewHeight, len(v.lines))
+	for i := v.offset; i < end; i++ {
+		sb.WriteString(dimStyle.Render(fmt.Sprintf("%5d ", v.first+i)))
+		sb.WriteString(truncateStr(v.lines[i], m.width-6))
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n")
+	sb.WriteString(statusStyle.Render("↑/↓: scroll | Esc: back"))
+	return sb.String()
+}
+
+// renderQuickAdd renders the quick-add prompt opened with A.
+func renderQuickAdd(m Model) string {
+	p := m.quickAdd
+	var sb strings.Builder
+	sb.WriteString(titleStyle.Render("Quick add"))
+	sb.WriteString("\n\n  " + p.input + "█\n\n")
+	if p.statusMsg != "" {
+		sb.WriteString(statusStyle.Render(p.statusMsg))
+	} else {
+		sb.WriteString(statusStyle.Render("Paste user@host:port (optionally /key) | Enter: review | Esc: cancel"))
+	}
+	return sb.String()
+}
+
+// renderPreview returns the dim "$ ssh ..." line for the selected host, or ""
+// when the preview is off or nothing is selected.
+func renderPreview(m Model) string {
+	if !m.showPreview || len(m.filtered) == 0 {
+		return ""
+	}
+	host := m.filtered[m.cursor]
+	lines := []string{dimStyle.Render("$ " + sshCommandLine(m, host))}
+	for _, line := range tokenExpansions(host) {
+		lines = append(lines, dimStyle.Render(line))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// tokenExpansions returns "Keyword: raw → expanded" for each of host's
+// IdentityFile and ProxyCommand values that contains percent tokens, so the
+// preview shows what ssh will actually use.
+func tokenExpansions(host config.Host) []string {
+	var out []string
+	add := func(keyword, raw string) {
+		if !config.HasTokens(raw) {
+			return
+		}
+		expanded, err := config.ExpandTokens(raw, host)
+		if err != nil {
+			expanded = "(" + err.Error() + ")"
+		}
+		out = append(out, keyword+": "+raw+" → "+expanded)
+	}
+	add("IdentityFile", host.IdentityFile)
+	if proxy, ok := host.Directive("ProxyCommand"); ok {
+		add("ProxyCommand", proxy)
+	}
+	return out
+}
+
+// sshCommandLine returns the shell-quoted ssh command Enter runs for host.
+func sshCommandLine(m Model, host config.Host) string {
+	return ssh.ShellJoin(append([]string{"ssh"}, connectArgs(m, host)...))
+}
+
 // renderStatusBar returns the status bar display.
 func renderStatusBar(m Model) string {
+	if m.mode == modeConfirmDelete {
+		return statusStyle.Render("Delete host " + m.pendingDelete.Alias + "? (y/n)")
+	}
 	if m.statusMsg != "" {
 		return statusStyle.Render(m.statusMsg)
 	}
+	hint := "Enter: connect | Ctrl+E: edit"
+	if m.enterEdits {
+		hint = "Enter: edit | Ctrl+E: connect"
+	}
+	if len(m.filtered) > 0 {
+		if reason := lastError(m, m.filtered[m.cursor]); reason != "" {
+			hint = "x last session failed: " + reason + " | " + hint
+		}
+	}
+	if m.verbosity > 0 {
+		hint = "verbose -" + strings.Repeat("v", m.verbosity) + " (next connection) | " + hint
+	}
+	count := fmt.Sprintf("%d hosts", len(m.filtered))
+	if m.activeGroup != "" {
+		count += " in " + m.activeGroup + " (G to change)"
+	}
 	return statusStyle.Render(fmt.Sprintf(
-		"%d hosts | Enter: connect | Ctrl+E: edit | esc: quit",
-		len(m.filtered),
+		"%s | %s | ?: help | esc: quit",
+		count, hint,
 	))
 }
 
@@ -168,12 +554,58 @@ var fieldLabels = [fieldCount]string{
 	fieldGroups:       "Groups        ",
 }
 
+// suggestionHint returns the ghosted text shown after value for the hostname
+// suggestion s: the rest of s when it extends value byte for byte, otherwise
+// all of s, since the match is case-insensitive ("WEB" suggests
+// "web1.example.com").
+func suggestionHint(value, s string) string {
+	if rest, ok := strings.CutPrefix(s, value); ok {
+		return rest
+	}
+	return " → " + s
+}
+
+// renderConfirmDiff renders the pending edit as a diff awaiting confirmation.
+func renderConfirmDiff(form *editForm) string {
+	var sb strings.Builder
+	sb.WriteString(titleStyle.Render("Save changes to " + form.original.Alias + "?"))
+	sb.WriteString("\n\n")
+	for _, line := range strings.Split(form.diff, "\n") {
+		switch {
+		case strings.HasPrefix(line, "+"):
+			line = addedStyle.Render(line)
+		case strings.HasPrefix(line, "-"):
+			line = removedStyle.Render(line)
+		default:
+			line = dimStyle.Render(line)
+		}
+		sb.WriteString(line)
+		sb.WriteString("\n")
+	}
+	sb.WriteString("\n")
+	sb.WriteString(statusStyle.Render("Enter/y: save  |  Esc/n: back to form"))
+	return sb.String()
+}
+
 // renderEditForm renders the 6-field host editor form.
 func renderEditForm(m Model) string {
 	form := m.edit
+	if form.diff != "" {
+		return renderConfirmDiff(form)
+	}
+	if form.adding {
+		return renderForm("Add Host", m.highlight, form, "↑/↓/Tab: next field  |  Enter: add to config  |  Esc: cancel  |  Ctrl+U: clear")
+	}
+	return renderForm("Edit Host", m.highlight, form, "↑/↓/Tab: next field  |  Enter: save  |  Esc: cancel  |  Ctrl+U: clear  |  Ctrl+R: revert")
+}
+
+// renderForm renders the host fields of form under title, followed by the
+// form's status message or, if there is none, hint. The active field's label
+// is drawn with highlight.
+func renderForm(title string, highlight lipgloss.Style, form *editForm, hint string) string {
 	var sb strings.Builder
 
-	sb.WriteString(titleStyle.Render("Edit Host"))
+	sb.WriteString(titleStyle.Render(title))
 	sb.WriteString("\n\n")
 
 	for i := editField(0); i < fieldCount; i++ {
@@ -181,10 +613,13 @@ func renderEditForm(m Model) string {
 		value := form.fields[i]
 
 		if i == form.activeField {
-			sb.WriteString(selectedStyle.Render(label))
+			sb.WriteString(highlight.Render(label))
 			sb.WriteString("  ")
 			sb.WriteString(value)
 			sb.WriteString("█")
+			if s := form.hostnameSuggestion(); s != "" {
+				sb.WriteString(dimStyle.Render(suggestionHint(value, s)))
+			}
 		} else {
 			sb.WriteString(dimStyle.Render(label))
 			sb.WriteString("  ")
@@ -197,7 +632,7 @@ func renderEditForm(m Model) string {
 	if form.statusMsg != "" {
 		sb.WriteString(statusStyle.Render(form.statusMsg))
 	} else {
-		sb.WriteString(statusStyle.Render("↑/↓: next field  |  Enter: save  |  Esc: cancel  |  Ctrl+U: clear"))
+		sb.WriteString(statusStyle.Render(hint))
 	}
 
 	return sb.String()
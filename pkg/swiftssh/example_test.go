@@ -0,0 +1,55 @@
+package swiftssh_test
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"github.com/srava/swiftssh/pkg/swiftssh"
+)
+
+// Example_listHosts parses an SSH config and lists its hosts in the order the
+// TUI would show them, most-used first.
+func Example_listHosts() {
+	dir, err := os.MkdirTemp("", "swiftssh-example")
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+	defer os.RemoveAll(dir)
+
+	configPath := filepath.Join(dir, "config")
+	content := `# @group Work
+Host prod
+    Hostname prod.example.com
+    User deploy
+
+Host dev
+    Hostname 10.0.0.2
+
+Host pi
+    Hostname 10.0.0.5
+    User pi
+`
+	if err := os.WriteFile(configPath, []byte(content), 0600); err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	hosts, err := swiftssh.Parse(configPath)
+	if err != nil {
+		fmt.Println(err)
+		return
+	}
+
+	st, _ := swiftssh.LoadState(filepath.Join(dir, "state.json"))
+	swiftssh.RecordConnection(st, "pi")
+
+	for _, h := range swiftssh.OrderHosts(hosts, st, false) {
+		fmt.Printf("%-5s %-17s %v\n", h.Alias, h.Hostname, h.Groups)
+	}
+	// Output:
+	// pi    10.0.0.5          []
+	// dev   10.0.0.2          []
+	// prod  prod.example.com  [Work]
+}
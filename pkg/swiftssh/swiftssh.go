@@ -0,0 +1,78 @@
+// Package swiftssh is the public Go API of SwiftSSH. It re-exports the stable
+// parts of the config parser/writer and the connection-history ordering so
+// other tools can embed them; the implementation lives under internal/.
+//
+// The API follows APIVersion: within a major version, exported identifiers
+// here are only ever added, never removed or changed incompatibly.
+package swiftssh
+
+import (
+	"github.com/srava/swiftssh/internal/config"
+	"github.com/srava/swiftssh/internal/state"
+)
+
+// APIVersion is the version of this package's API.
+const APIVersion = "1.1.0"
+
+// Host is a single Host block from an SSH config file.
+type Host = config.Host
+
+// ParsedConfig is a parsed SSH config: its hosts and primary source file.
+type ParsedConfig = config.ParsedConfig
+
+// State is the persisted connection history used for ordering.
+type State = state.State
+
+// Parse reads the SSH config at path, following Include directives, and
+// returns its hosts in file order.
+func Parse(path string) ([]Host, error) {
+	return config.Parse(path)
+}
+
+// IsKnownHost reports whether hostname matches the Hostname of any host.
+// Aliases are not compared. DNS names match case-insensitively.
+func IsKnownHost(hosts []Host, hostname string) bool {
+	return config.IsKnownHost(hosts, hostname)
+}
+
+// AppendHost backs up configPath to backupPath and appends a block for h.
+func AppendHost(configPath, backupPath string, h Host) error {
+	return config.AppendHost(configPath, backupPath, h)
+}
+
+// ReplaceHostBlock rewrites the block at h.LineStart in h.SourceFile with h.
+// It returns the block's new start line and how many lines it grew or shrank.
+func ReplaceHostBlock(h Host) (newLineStart, lineDelta int, err error) {
+	res, err := config.ReplaceHostBlock(h)
+	return res.NewLineStart, res.LineDelta, err
+}
+
+// DeleteHost removes the block at h.LineStart in h.SourceFile, backing the
+// file up first. lineDelta is minus the number of lines removed. A block
+// whose Host line names several aliases is refused.
+func DeleteHost(h Host) (lineDelta int, err error) {
+	return config.DeleteHost(h)
+}
+
+// LoadState loads connection history from path. A missing file yields an
+// empty State with FirstRun set.
+func LoadState(path string) (*State, error) {
+	return state.Load(path)
+}
+
+// SaveState atomically writes s to path.
+func SaveState(path string, s *State) error {
+	return state.Save(path, s)
+}
+
+// RecordConnection increments the connection count for alias.
+func RecordConnection(s *State, alias string) {
+	state.RecordConnection(s, alias)
+}
+
+// OrderHosts returns hosts in the order the SwiftSSH TUI lists them: frequent
+// hosts first, then the rest alphabetically (or purely alphabetical when
+// noFrequent is true).
+func OrderHosts(hosts []Host, s *State, noFrequent bool) []Host {
+	return state.OrderHosts(hosts, s, noFrequent)
+}